@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is how many unchanged lines a diff shows around changes.
+const diffContext = 3
+
+// diffLine is a line of a diff: kept (' '), removed ('-') or added ('+').
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff returns the unified diff turning a into b, labelled with
+// the given names, or "" when they are equal. Profiles are small, so
+// the lines are compared with a plain longest common subsequence.
+func unifiedDiff(fromName, toName, a, b string) string {
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	for start := 0; start < len(lines); {
+		// Find the next change and the end of its hunk: the changes
+		// closer than twice the context to each other share one.
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for i := first; i < len(lines) && i <= last+2*diffContext; i++ {
+			if lines[i].op != ' ' {
+				last = i
+			}
+		}
+		from, to := max(first-diffContext, start), min(last+diffContext+1, len(lines))
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		writeHunk(&out, lines, from, to)
+		start = to
+	}
+	return out.String()
+}
+
+// writeHunk writes lines[from:to] with its @@ header, which counts the
+// lines of a and b it covers and where they start.
+func writeHunk(out *strings.Builder, lines []diffLine, from, to int) {
+	aStart, bStart := 1, 1
+	for _, l := range lines[:from] {
+		if l.op != '+' {
+			aStart++
+		}
+		if l.op != '-' {
+			bStart++
+		}
+	}
+	var aLen, bLen int
+	for _, l := range lines[from:to] {
+		if l.op != '+' {
+			aLen++
+		}
+		if l.op != '-' {
+			bLen++
+		}
+	}
+	// An empty range starts at the line before it.
+	if aLen == 0 {
+		aStart--
+	}
+	if bLen == 0 {
+		bStart--
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+	for _, l := range lines[from:to] {
+		fmt.Fprintf(out, "%c%s\n", l.op, l.text)
+	}
+}
+
+// diffLines lines up a and b along their longest common subsequence.
+func diffLines(a, b []string) []diffLine {
+	// lcs[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]diffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+)
+
+// MigrateOptions holds the settings of `rapper migrate`. They are
+// parsed from its arguments by ParseMigrateFlags.
+type MigrateOptions struct {
+	ConfigDir string
+	// Files are the profiles to migrate; every legacy profile of
+	// ConfigDir when empty.
+	Files  []string
+	DryRun bool
+}
+
+// ParseMigrateFlags parses the arguments that follow the `migrate`
+// subcommand. Files given as arguments are migrated instead of the
+// legacy profiles found in -config.
+func ParseMigrateFlags(args []string, defaults MigrateOptions, output io.Writer) (MigrateOptions, error) {
+	opts := defaults
+
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&opts.ConfigDir, "config", opts.ConfigDir, "path to directory containing the profiles")
+	fs.BoolVar(&opts.DryRun, "dry-run", opts.DryRun, "show the changes without writing them")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	opts.Files = append(opts.Files, fs.Args()...)
+
+	return opts, nil
+}
+
+// Migrate converts legacy profiles (token, path and payload keys) to
+// the current format. For every file it prints the diff of the
+// conversion to stdout, then, unless opts.DryRun, copies the file to
+// <file>.bak and replaces it. Files that can't be converted are
+// reported on stderr and left as they are. It returns the process exit
+// code: 0 when every legacy profile was converted, 1 otherwise.
+func Migrate(opts MigrateOptions, stdout, stderr io.Writer) int {
+	files := opts.Files
+	if len(files) == 0 {
+		var err error
+		files, err = config.LegacyProfiles(opts.ConfigDir)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, "error:", err)
+			return 1
+		}
+		if len(files) == 0 {
+			_, _ = fmt.Fprintf(stdout, "no legacy profiles found in %s\n", opts.ConfigDir)
+			return 0
+		}
+	}
+
+	loader := config.NewLoader()
+	var migrated, failed int
+	for _, file := range files {
+		m, err := loader.Migrate(file)
+		if errors.Is(err, config.ErrNotLegacy) {
+			_, _ = fmt.Fprintf(stdout, "%s is already in the current format\n", file)
+			continue
+		}
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, "error:", err)
+			failed++
+			continue
+		}
+
+		_, _ = fmt.Fprint(stdout, unifiedDiff(file, file+" (migrated)", string(m.Old), string(m.New)))
+		for _, note := range m.Notes {
+			_, _ = fmt.Fprintf(stderr, "note: %s: %s\n", file, note)
+		}
+		if opts.DryRun {
+			continue
+		}
+		if err := m.Apply(); err != nil {
+			_, _ = fmt.Fprintln(stderr, "error:", err)
+			failed++
+			continue
+		}
+		_, _ = fmt.Fprintf(stdout, "migrated %s (backup: %s)\n", file, m.Backup())
+		migrated++
+	}
+
+	if opts.DryRun {
+		_, _ = fmt.Fprintf(stdout, "dry run: nothing written, %d files could not be converted\n", failed)
+	} else {
+		_, _ = fmt.Fprintf(stdout, "summary: %d migrated, %d failed\n", migrated, failed)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
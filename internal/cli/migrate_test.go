@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const legacyProfile = `token: ${API_TOKEN}
+url: https://api.example
+path:
+  method: PUT
+  template: https://api.example/users/{{.id}}
+payload:
+  template: '{"name": "{{.name}}"}'
+csv:
+  fields: [id, name]
+`
+
+func TestParseMigrateFlags(t *testing.T) {
+	opts, err := ParseMigrateFlags([]string{"-dry-run", "a.yml"}, MigrateOptions{ConfigDir: "/etc/rapper"}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.True(t, opts.DryRun)
+	assert.Equal(t, []string{"a.yml"}, opts.Files)
+	assert.Equal(t, "/etc/rapper", opts.ConfigDir, "global -config value must be kept as default")
+}
+
+func TestMigrate(t *testing.T) {
+	t.Setenv("API_TOKEN", "secret")
+	writeProfiles := func(t *testing.T, profiles map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, data := range profiles {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600))
+		}
+		return dir
+	}
+
+	t.Run("Should convert legacy profiles and keep a backup", func(t *testing.T) {
+		current := "request:\n  method: GET\n  url_template: https://api.example\ncsv:\n  fields: [id]\n"
+		dir := writeProfiles(t, map[string]string{"legacy.yml": legacyProfile, "current.yml": current})
+		path := filepath.Join(dir, "legacy.yml")
+
+		var stdout, stderr bytes.Buffer
+		code := Migrate(MigrateOptions{ConfigDir: dir}, &stdout, &stderr)
+
+		assert.Equal(t, 0, code, stderr.String())
+		assert.Contains(t, stdout.String(), "--- "+path+"\n+++ "+path+" (migrated)\n@@ -1,9 +1,13 @@\n-token: ${API_TOKEN}\n")
+		assert.Contains(t, stdout.String(), "+        Authorization: Bearer ${API_TOKEN}\n")
+		assert.Contains(t, stdout.String(), "summary: 1 migrated, 0 failed")
+		assert.Contains(t, stderr.String(), `url "https://api.example" is not used`)
+
+		backup, err := os.ReadFile(path + ".bak")
+		require.NoError(t, err)
+		assert.Equal(t, legacyProfile, string(backup))
+
+		migrated, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(migrated), "url_template: https://api.example/users/{{.id}}")
+		assert.NotContains(t, string(migrated), "secret", "references must be kept unresolved")
+
+		untouched, err := os.ReadFile(filepath.Join(dir, "current.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, current, string(untouched))
+	})
+
+	t.Run("Should not write anything in a dry run", func(t *testing.T) {
+		dir := writeProfiles(t, map[string]string{"legacy.yml": legacyProfile})
+
+		var stdout, stderr bytes.Buffer
+		code := Migrate(MigrateOptions{ConfigDir: dir, DryRun: true}, &stdout, &stderr)
+
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout.String(), "+request:\n")
+		data, err := os.ReadFile(filepath.Join(dir, "legacy.yml"))
+		require.NoError(t, err)
+		assert.Equal(t, legacyProfile, string(data))
+		assert.NoFileExists(t, filepath.Join(dir, "legacy.yml.bak"))
+	})
+
+	t.Run("Should report profiles that can't be converted", func(t *testing.T) {
+		dir := writeProfiles(t, map[string]string{
+			"invalid.yml":    "path:\n  method: FETCH\n  template: https://api.example\ncsv:\n  fields: [id]\n",
+			"backed.yml":     legacyProfile,
+			"backed.yml.bak": "earlier backup\n",
+		})
+
+		var stdout, stderr bytes.Buffer
+		code := Migrate(MigrateOptions{ConfigDir: dir}, &stdout, &stderr)
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr.String(), `invalid HTTP method "FETCH"`)
+		assert.Contains(t, stderr.String(), "failed to back up")
+		assert.Contains(t, stdout.String(), "summary: 0 migrated, 2 failed")
+		backup, err := os.ReadFile(filepath.Join(dir, "backed.yml.bak"))
+		require.NoError(t, err)
+		assert.Equal(t, "earlier backup\n", string(backup), "an earlier backup must not be replaced")
+	})
+
+	t.Run("Should skip profiles in the current format", func(t *testing.T) {
+		dir := writeProfiles(t, map[string]string{"current.yml": "request:\n  method: GET\n  url_template: https://api.example\ncsv:\n  fields: [id]\n"})
+
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, 0, Migrate(MigrateOptions{ConfigDir: dir}, &stdout, &stderr))
+		assert.Contains(t, stdout.String(), "no legacy profiles found")
+
+		stdout.Reset()
+		assert.Equal(t, 0, Migrate(MigrateOptions{Files: []string{filepath.Join(dir, "current.yml")}}, &stdout, &stderr))
+		assert.Contains(t, stdout.String(), "is already in the current format")
+	})
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+
+	assert.Equal(t, `--- a
++++ b
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`, unifiedDiff("a", "b", a, b))
+	assert.Empty(t, unifiedDiff("a", "b", a, a))
+}
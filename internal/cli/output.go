@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anibaldeboni/rapper/internal/logs"
+	"github.com/anibaldeboni/rapper/internal/processor"
+)
+
+// maxDetailLen caps how much of a message detail (usually a response
+// body) is echoed on a single output line. The full body still goes
+// to the -output file.
+const maxDetailLen = 200
+
+var (
+	ansiSeq  = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+	spaceSeq = regexp.MustCompile(`\s+`)
+)
+
+// fileLogger is the subset of the logs logger the headless runner
+// needs: WriteToFile for the per-request output file and Get to
+// surface the messages the logger produced about itself (e.g. the
+// output file could not be opened). SetSecrets masks the profile's
+// secrets in the output file.
+type fileLogger interface {
+	Get() []logs.LogMessage
+	WriteToFile(line logs.Line)
+	SetSecrets(secrets []string)
+}
+
+// metricsSource is the part of the processor the progress ticker reads.
+type metricsSource interface {
+	GetMetrics() processor.Metrics
+}
+
+// streamLogger implements processor.RequestLogger for headless runs.
+// Instead of buffering messages for the TUI to poll, every message is
+// printed as a plain-text line as soon as it is added: errors and
+// warnings to stderr, everything else to stdout. Per-request lines
+// are delegated to the regular logs logger.
+type streamLogger struct {
+	stdout   io.Writer
+	stderr   io.Writer
+	file     fileLogger
+	redactor logs.Redactor
+	mu       sync.Mutex
+}
+
+var _ processor.RequestLogger = (*streamLogger)(nil)
+
+func newStreamLogger(stdout, stderr io.Writer, file fileLogger) *streamLogger {
+	s := &streamLogger{stdout: stdout, stderr: stderr, file: file}
+	for _, msg := range file.Get() {
+		s.Add(msg)
+	}
+	return s
+}
+
+// Add prints the message as a single timestamped line.
+func (s *streamLogger) Add(msg logs.LogMessage) {
+	line := msg.Timestamp.Format(time.TimeOnly) + " " + plain(msg.BadgeIcon+" "+msg.Text)
+	if detail := plain(msg.Details); detail != "" {
+		if len(detail) > maxDetailLen {
+			cut := maxDetailLen
+			for cut > 0 && !utf8.RuneStart(detail[cut]) {
+				cut--
+			}
+			detail = detail[:cut] + "..."
+		}
+		line += ": " + detail
+	}
+
+	w := s.stdout
+	if msg.Type == logs.LogTypeError || msg.Type == logs.LogTypeWarning {
+		w = s.stderr
+	}
+
+	s.println(w, line)
+}
+
+// SetSecrets masks secrets in every line printed and written to the
+// output file from now on.
+func (s *streamLogger) SetSecrets(secrets []string) {
+	s.redactor.SetSecrets(secrets)
+	s.file.SetSecrets(secrets)
+}
+
+// WriteToFile delegates to the logs logger so the -output file has
+// the same JSON lines a TUI run produces.
+func (s *streamLogger) WriteToFile(line logs.Line) {
+	s.file.WriteToFile(line)
+}
+
+// progress prints a progress line every interval until the returned
+// stop function is called. A non-positive interval disables it. The
+// lines of a streamed input are counted as they are read, as there is
+// no telling how many are left.
+func (s *streamLogger) progress(proc metricsSource, interval time.Duration, streamed bool) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				m := proc.GetMetrics()
+				s.println(s.stdout, fmt.Sprintf(
+					"progress: %s%s, %d requests (%d errors), %.2f req/s, %s elapsed",
+					completion(m), linesRead(m.LinesProcessed, streamed, "so far"), m.TotalRequests, m.ErrorRequests, m.RequestsPerSec, elapsedSince(m.StartTime),
+				))
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// summary prints the end-of-run totals.
+func (s *streamLogger) summary(m processor.Metrics, streamed bool) {
+	s.println(s.stdout, fmt.Sprintf(
+		"summary: %s%s, %d requests, %d succeeded, %d failed, %d retries, %.2f req/s, %s elapsed%s",
+		linesRead(m.LinesProcessed, streamed, "in total"), completed(m), m.TotalRequests, m.SuccessRequests, m.ErrorRequests, m.RetryRequests, m.RequestsPerSec, elapsedSince(m.StartTime), latency(m),
+	))
+}
+
+// progressBarWidth is the number of characters of the progress bar.
+const progressBarWidth = 20
+
+// completion renders how much of a file is done as a bar, its
+// percentage, the total of lines, marked with "~" while estimated, and
+// the time left when known. It is empty while the total is unknown,
+// as it is for standard input.
+func completion(m processor.Metrics) string {
+	if m.TotalLines == 0 {
+		return ""
+	}
+	filled := int(min(max(m.PercentComplete, 0), 100) / 100 * progressBarWidth)
+	total := strconv.FormatUint(m.TotalLines, 10)
+	if m.TotalEstimated {
+		total = "~" + total
+	}
+	out := fmt.Sprintf("[%s%s] %.1f%% of %s lines, ",
+		strings.Repeat("#", filled), strings.Repeat("-", progressBarWidth-filled), m.PercentComplete, total)
+	if m.ETA > 0 {
+		out += fmt.Sprintf("ETA %s, ", m.ETA.Round(time.Second))
+	}
+	return out
+}
+
+// completed tells how much of a file a finished run got through.
+func completed(m processor.Metrics) string {
+	if m.TotalLines == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%.1f%% complete)", m.PercentComplete)
+}
+
+// latency renders the latency percentiles of a run, which a dry run,
+// sending nothing, doesn't have.
+func latency(m processor.Metrics) string {
+	if m.LatencyMax == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", latency p50 %s, p90 %s, p99 %s, max %s",
+		roundLatency(m.LatencyP50), roundLatency(m.LatencyP90), roundLatency(m.LatencyP99), roundLatency(m.LatencyMax))
+}
+
+// roundLatency rounds d to a precision that fits its magnitude.
+func roundLatency(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d.Round(100 * time.Microsecond)
+	}
+	return d.Round(time.Millisecond)
+}
+
+// linesRead counts the lines of a progress or summary line, saying
+// which of the lines of a stream it counts ("so far", "in total").
+func linesRead(lines uint64, streamed bool, which string) string {
+	if streamed {
+		return fmt.Sprintf("%d lines read from %s %s", lines, stdinName, which)
+	}
+	return fmt.Sprintf("%d lines", lines)
+}
+
+// note prints an informational line to stderr, where it doesn't mix
+// with the request log.
+func (s *streamLogger) note(line string) {
+	s.println(s.stderr, "note: "+line)
+}
+
+// fail prints err to stderr.
+func (s *streamLogger) fail(err error) {
+	s.println(s.stderr, "error: "+err.Error())
+}
+
+func (s *streamLogger) println(w io.Writer, line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = fmt.Fprintln(w, s.redactor.String(line))
+}
+
+// plain strips ANSI styling and collapses whitespace so a styled,
+// possibly multi-line message fits on one uncolored output line.
+func plain(s string) string {
+	return strings.TrimSpace(spaceSeq.ReplaceAllString(ansiSeq.ReplaceAllString(s, ""), " "))
+}
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/logs"
+	"github.com/anibaldeboni/rapper/internal/processor"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"golang.org/x/term"
+)
+
+// StdinFile is the file name that makes a run read its rows from
+// standard input: `rapper run -`.
+const StdinFile = "-"
+
+// RunOptions holds the settings of a headless run. They are parsed
+// from the `rapper run` arguments by ParseRunFlags; the zero value of
+// Workers means "use the workers set in the profile".
+type RunOptions struct {
+	ConfigDir string
+	Profile   string
+	File      string
+	Output    string
+	Workers   int
+	MaxErrors uint64
+	Progress  time.Duration
+	Resume    bool
+	DryRun    bool
+}
+
+// ParseRunFlags parses the arguments that follow the `run` subcommand.
+// The defaults carry the values of the global flags so
+// `rapper -config dir run ...` and `rapper run -config dir ...` behave
+// the same way.
+func ParseRunFlags(args []string, defaults RunOptions, output io.Writer) (RunOptions, error) {
+	opts := defaults
+	if opts.Progress == 0 {
+		opts.Progress = 5 * time.Second
+	}
+
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&opts.ConfigDir, "config", opts.ConfigDir, "path to directory containing the profiles")
+	fs.StringVar(&opts.Profile, "profile", opts.Profile, "name of the profile to use (default: first profile found)")
+	fs.StringVar(&opts.File, "file", opts.File, "path to the CSV or JSON Lines file to process, or - for standard input (default: standard input)")
+	fs.StringVar(&opts.Output, "output", opts.Output, "path to output file, including the file name")
+	fs.IntVar(&opts.Workers, "workers", opts.Workers, fmt.Sprintf("number of request workers (max: %d, default: profile workers)", processor.MaxWorkers))
+	fs.Uint64Var(&opts.MaxErrors, "max-errors", opts.MaxErrors, "number of failed requests tolerated before exiting with a non-zero code")
+	fs.DurationVar(&opts.Progress, "progress", opts.Progress, "interval between progress lines (0 disables them)")
+	fs.BoolVar(&opts.DryRun, "dry-run", opts.DryRun, "render the requests to the log and output file without sending them")
+	fs.BoolVar(&opts.Resume, "resume", opts.Resume, "resume from the checkpoint left by an interrupted run of the file")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.File == "" && fs.NArg() > 0 {
+		opts.File = fs.Arg(0)
+	}
+	if opts.File == "" {
+		opts.File = StdinFile
+	}
+
+	return opts, nil
+}
+
+// Run processes opts.File headlessly: it wires the same config
+// manager, HTTP gateway, processor and logger the TUI uses, streams
+// every log message to stdout (errors and warnings to stderr), prints
+// a progress line every opts.Progress and a final summary. When the
+// file has a checkpoint from an interrupted run, opts.Resume picks up
+// from it; otherwise the run starts over and says so on stderr. With
+// opts.DryRun nothing is sent and rows that render a missing CSV
+// column count as failed requests. When opts.File is StdinFile the
+// rows are read from stdin, which has no checkpoint. The returned
+// value is the process exit code: 0 on success, 1 when the run could
+// not start, was cancelled or produced more than opts.MaxErrors failed
+// requests.
+func Run(ctx context.Context, opts RunOptions, stdin io.Reader, stdout, stderr io.Writer) int {
+	out := newStreamLogger(stdout, stderr, logs.NewLogger(opts.Output))
+
+	streamed := opts.File == StdinFile
+	if streamed && (stdin == nil || isTerminal(stdin)) {
+		out.fail(errors.New("a CSV or JSON Lines file is required: rapper run -file <path>, or pipe the rows to standard input"))
+		return 1
+	}
+
+	cfg, err := loadProfile(opts.ConfigDir, opts.Profile)
+	if err != nil {
+		out.fail(err)
+		return 1
+	}
+	out.SetSecrets(cfg.Secrets())
+
+	hg, err := web.NewHttpGateway(
+		cfg.Request.Method,
+		cfg.Request.URLTemplate,
+		cfg.Request.BodyTemplate,
+		cfg.Request.Headers,
+	)
+	if err != nil {
+		out.fail(fmt.Errorf("could not create HTTP gateway: %w", err))
+		return 1
+	}
+
+	workerCount := opts.Workers
+	if workerCount <= 0 {
+		workerCount = max(cfg.Workers, 1)
+	}
+
+	proc := processor.NewProcessor(cfg.CSV, hg, out, workerCount)
+	if err := proc.UpdateRequestConfig(cfg.Request); err != nil {
+		out.fail(fmt.Errorf("could not create HTTP gateway: %w", err))
+		return 1
+	}
+	proc.UpdateRateLimit(cfg.RateLimit)
+	proc.SetDryRun(opts.DryRun)
+
+	var (
+		runCtx context.Context
+		cancel context.CancelFunc
+	)
+	// A dry run ignores checkpoints: it must not resume from one nor
+	// suggest doing so.
+	var (
+		cp    processor.Checkpoint
+		found bool
+	)
+	if !streamed {
+		cp, found = proc.Checkpoint(opts.File)
+		found = found && !opts.DryRun
+	}
+	switch {
+	case streamed:
+		if opts.Resume {
+			out.note("standard input can't be resumed, processing it from the start")
+		}
+		runCtx, cancel = proc.DoStream(ctx, stdinName, stdin)
+	case found && opts.Resume:
+		runCtx, cancel = proc.Resume(ctx, opts.File, cp)
+	case found:
+		out.note(fmt.Sprintf("found a checkpoint at line %d, starting over (pass -resume to continue from it)", cp.Completed+1))
+		fallthrough
+	default:
+		runCtx, cancel = proc.Do(ctx, opts.File)
+	}
+	if runCtx == nil {
+		out.fail(fmt.Errorf("could not process %s", inputName(opts.File)))
+		return 1
+	}
+	defer cancel()
+
+	stopProgress := out.progress(proc, opts.Progress, streamed)
+	summary := proc.Wait()
+	stopProgress()
+
+	out.summary(summary, streamed)
+
+	switch {
+	case ctx.Err() != nil:
+		out.fail(errors.New("run cancelled"))
+		return 1
+	case summary.ErrorRequests > opts.MaxErrors:
+		out.fail(fmt.Errorf("%d failed requests exceed the tolerated %d", summary.ErrorRequests, opts.MaxErrors))
+		return 1
+	}
+
+	return 0
+}
+
+// stdinName names standard input in the log.
+const stdinName = "standard input"
+
+func inputName(file string) string {
+	if file == StdinFile {
+		return stdinName
+	}
+	return filepath.Base(file)
+}
+
+// isTerminal reports whether r is a terminal, which has no rows piped
+// to it.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// loadProfile discovers the profiles in dir and returns the named one,
+// or the default active profile when name is empty.
+func loadProfile(dir, name string) (*config.Config, error) {
+	configMgr, err := config.NewManager(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+
+	if name != "" {
+		if err := configMgr.SetActiveProfile(name); err != nil {
+			return nil, fmt.Errorf("could not select profile: %w", err)
+		}
+	}
+
+	cfg := configMgr.Get()
+	if cfg == nil {
+		return nil, errors.New("no active configuration found")
+	}
+
+	return cfg, nil
+}
+
+// elapsedSince formats the time elapsed since start with the same
+// precision the TUI metrics panel uses.
+func elapsedSince(start time.Time) string {
+	if start.IsZero() {
+		return "0s"
+	}
+	return time.Since(start).Round(time.Millisecond).String()
+}
@@ -0,0 +1,364 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anibaldeboni/rapper/internal/logs"
+	"github.com/anibaldeboni/rapper/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRunFixture creates a config dir holding a single "api" profile
+// pointed at serverURL and a CSV file with the given rows, and returns
+// both paths.
+func writeRunFixture(t *testing.T, serverURL, csvData string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	profile := `request:
+  method: POST
+  url_template: ` + serverURL + `/users/{{.id}}
+  body_template: '{"name": "{{.name}}"}'
+csv:
+  separator: ","
+  fields: [id, name]
+workers: 2
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api.yml"), []byte(profile), 0o600))
+
+	csvPath := filepath.Join(dir, "users.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte(csvData), 0o600))
+
+	return dir, csvPath
+}
+
+func TestParseRunFlags(t *testing.T) {
+	t.Run("Should read standard input without a file", func(t *testing.T) {
+		opts, err := ParseRunFlags([]string{"-profile", "api"}, RunOptions{}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, StdinFile, opts.File)
+
+		opts, err = ParseRunFlags([]string{"-profile", "api", "-"}, RunOptions{}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, StdinFile, opts.File)
+	})
+
+	t.Run("Should accept the file as a positional argument", func(t *testing.T) {
+		opts, err := ParseRunFlags([]string{"-profile", "api", "users.csv"}, RunOptions{ConfigDir: "/etc/rapper"}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, "users.csv", opts.File)
+		assert.Equal(t, "api", opts.Profile)
+		assert.Equal(t, "/etc/rapper", opts.ConfigDir, "global -config value must be kept as default")
+	})
+
+	t.Run("Should parse double-dash flags", func(t *testing.T) {
+		opts, err := ParseRunFlags([]string{"--profile", "staging", "--file", "users.csv", "--max-errors", "3"}, RunOptions{}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, "staging", opts.Profile)
+		assert.Equal(t, "users.csv", opts.File)
+		assert.Equal(t, uint64(3), opts.MaxErrors)
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("Should process every row and exit with zero", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+		dir, csvPath := writeRunFixture(t, server.URL, "id,name\n1,ana\n2,bob\n")
+
+		var stdout, stderr bytes.Buffer
+		code := Run(context.Background(), RunOptions{ConfigDir: dir, Profile: "api", File: csvPath}, nil, &stdout, &stderr)
+
+		assert.Equal(t, 0, code, "stderr: %s", stderr.String())
+		assert.Contains(t, stdout.String(), "Processing file users.csv")
+		assert.Contains(t, stdout.String(), "summary: 2 lines (100.0% complete), 2 requests, 2 succeeded, 0 failed")
+		assert.Contains(t, stdout.String(), ", latency p50 ")
+		assert.Contains(t, stdout.String(), "Finished with no errors: 200: 2\n")
+		assert.Empty(t, stderr.String())
+	})
+
+	t.Run("Should exit with non-zero when errors exceed the threshold", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/2") {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+		dir, csvPath := writeRunFixture(t, server.URL, "id,name\n1,ana\n2,bob\n")
+
+		var stdout, stderr bytes.Buffer
+		code := Run(context.Background(), RunOptions{ConfigDir: dir, File: csvPath}, nil, &stdout, &stderr)
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stdout.String(), "1 succeeded, 1 failed")
+		assert.Contains(t, stderr.String(), "500 POST "+server.URL+"/users/2")
+		assert.Contains(t, stderr.String(), "1 failed requests exceed the tolerated 0")
+	})
+
+	t.Run("Should tolerate errors up to the threshold", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+		dir, csvPath := writeRunFixture(t, server.URL, "id,name\n1,ana\n")
+
+		var stdout, stderr bytes.Buffer
+		code := Run(context.Background(), RunOptions{ConfigDir: dir, File: csvPath, MaxErrors: 1}, nil, &stdout, &stderr)
+
+		assert.Equal(t, 0, code)
+	})
+
+	t.Run("Should fail when the profile does not exist", func(t *testing.T) {
+		dir, csvPath := writeRunFixture(t, "http://localhost", "id,name\n1,ana\n")
+
+		var stdout, stderr bytes.Buffer
+		code := Run(context.Background(), RunOptions{ConfigDir: dir, Profile: "missing", File: csvPath}, nil, &stdout, &stderr)
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr.String(), "profile missing not found")
+	})
+
+	t.Run("Should fail when the CSV file cannot be read", func(t *testing.T) {
+		dir, _ := writeRunFixture(t, "http://localhost", "id,name\n")
+
+		var stdout, stderr bytes.Buffer
+		code := Run(context.Background(), RunOptions{ConfigDir: dir, File: filepath.Join(dir, "missing.csv")}, nil, &stdout, &stderr)
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr.String(), "CSV error")
+	})
+}
+
+func TestRun_Stdin(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Run("Should process CSV rows piped to standard input", func(t *testing.T) {
+		requests.Store(0)
+		dir, _ := writeRunFixture(t, server.URL, "")
+
+		var stdout, stderr bytes.Buffer
+		stdin := strings.NewReader("id,name\n1,ana\n2,bob\n3,cid\n")
+		code := Run(context.Background(), RunOptions{ConfigDir: dir, File: StdinFile}, stdin, &stdout, &stderr)
+
+		assert.Equal(t, 0, code, "stderr: %s", stderr.String())
+		assert.Equal(t, int32(3), requests.Load())
+		assert.Contains(t, stdout.String(), "Processing standard input")
+		assert.Contains(t, stdout.String(), "summary: 3 lines read from standard input in total, 3 requests")
+	})
+
+	t.Run("Should process JSON Lines piped to standard input", func(t *testing.T) {
+		requests.Store(0)
+		dir, _ := writeRunFixture(t, server.URL, "")
+
+		var stdout, stderr bytes.Buffer
+		stdin := strings.NewReader(`{"id": 1, "name": "ana"}` + "\n" + `{"id": 2, "name": "bob"}` + "\n")
+		code := Run(context.Background(), RunOptions{ConfigDir: dir, File: StdinFile}, stdin, &stdout, &stderr)
+
+		assert.Equal(t, 0, code, "stderr: %s", stderr.String())
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("Should fail without rows to read", func(t *testing.T) {
+		dir, _ := writeRunFixture(t, server.URL, "")
+
+		var stdout, stderr bytes.Buffer
+		code := Run(context.Background(), RunOptions{ConfigDir: dir, File: StdinFile}, nil, &stdout, &stderr)
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr.String(), "a CSV or JSON Lines file is required")
+	})
+}
+
+func TestRun_DryRun(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	t.Run("Should render every row without sending it", func(t *testing.T) {
+		dir, csvPath := writeRunFixture(t, server.URL, "id,name\n1,ana\n2,bob\n")
+
+		var stdout, stderr bytes.Buffer
+		code := Run(context.Background(), RunOptions{ConfigDir: dir, File: csvPath, DryRun: true}, nil, &stdout, &stderr)
+
+		assert.Equal(t, 0, code, "stderr: %s", stderr.String())
+		assert.Zero(t, hits.Load())
+		assert.Contains(t, stdout.String(), "DRY POST "+server.URL+"/users/1")
+		assert.Contains(t, stdout.String(), `{"name": "bob"}`)
+	})
+
+	t.Run("Should fail when a row renders a missing column", func(t *testing.T) {
+		dir, csvPath := writeRunFixture(t, server.URL, "id\n1\n")
+
+		var stdout, stderr bytes.Buffer
+		code := Run(context.Background(), RunOptions{ConfigDir: dir, File: csvPath, DryRun: true}, nil, &stdout, &stderr)
+
+		assert.Equal(t, 1, code)
+		assert.Zero(t, hits.Load())
+		assert.Contains(t, stderr.String(), "<no value> in body")
+	})
+}
+
+func TestRun_MasksSecrets(t *testing.T) {
+	t.Setenv("RAPPER_TEST_TOKEN", "prod-token-xyz")
+	t.Setenv("RAPPER_TEST_HOST", "localhost")
+	dir := t.TempDir()
+	profile := `request:
+  method: POST
+  url_template: http://${RAPPER_TEST_HOST}/users/{{.id}}
+  body_template: '{"id": "{{.id}}"}'
+  headers:
+    Authorization: Bearer ${RAPPER_TEST_TOKEN}
+csv:
+  fields: [id]
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api.yml"), []byte(profile), 0o600))
+	csvPath := filepath.Join(dir, "users.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("id\n1\n"), 0o600))
+	output := filepath.Join(dir, "output.log")
+
+	var stdout, stderr bytes.Buffer
+	code := Run(context.Background(), RunOptions{ConfigDir: dir, File: csvPath, Output: output, DryRun: true}, nil, &stdout, &stderr)
+	require.Equal(t, 0, code, "stderr: %s", stderr.String())
+
+	written, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, stdout.String(), "Bearer ****")
+	assert.Contains(t, string(written), "Bearer ****")
+	assert.NotContains(t, stdout.String(), "prod-token-xyz")
+	assert.NotContains(t, string(written), "prod-token-xyz")
+	assert.Contains(t, stdout.String(), "http://localhost/users/1", "a host isn't a secret")
+}
+
+// writeCheckpoint saves a checkpoint for csvPath recording completed
+// as the last completed line. The CSV fixtures are smaller than the
+// fingerprinted head, so the hash covers the whole file.
+func writeCheckpoint(t *testing.T, csvPath string, completed uint64) {
+	t.Helper()
+	data, err := os.ReadFile(csvPath)
+	require.NoError(t, err)
+	sum := sha256.Sum256(data)
+	cp, err := json.Marshal(processor.Checkpoint{
+		File:       csvPath,
+		Size:       int64(len(data)),
+		Hash:       hex.EncodeToString(sum[:]),
+		Completed:  completed,
+		Dispatched: completed,
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(csvPath+processor.CheckpointExt, cp, 0o600))
+}
+
+func TestRun_Checkpoint(t *testing.T) {
+	newServer := func(t *testing.T, hits *atomic.Int32) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	t.Run("Should resume from the checkpoint when asked to", func(t *testing.T) {
+		var hits atomic.Int32
+		server := newServer(t, &hits)
+		dir, csvPath := writeRunFixture(t, server.URL, "id,name\n1,ana\n2,bob\n3,cid\n")
+		writeCheckpoint(t, csvPath, 2)
+
+		var stdout, stderr bytes.Buffer
+		code := Run(context.Background(), RunOptions{ConfigDir: dir, File: csvPath, Resume: true}, nil, &stdout, &stderr)
+
+		assert.Equal(t, 0, code, "stderr: %s", stderr.String())
+		assert.Equal(t, int32(1), hits.Load())
+		assert.Contains(t, stdout.String(), "Resuming from line 3")
+		assert.NoFileExists(t, csvPath+processor.CheckpointExt, "a finished run removes its checkpoint")
+	})
+
+	t.Run("Should start over and say so without -resume", func(t *testing.T) {
+		var hits atomic.Int32
+		server := newServer(t, &hits)
+		dir, csvPath := writeRunFixture(t, server.URL, "id,name\n1,ana\n2,bob\n3,cid\n")
+		writeCheckpoint(t, csvPath, 2)
+
+		var stdout, stderr bytes.Buffer
+		code := Run(context.Background(), RunOptions{ConfigDir: dir, File: csvPath}, nil, &stdout, &stderr)
+
+		assert.Equal(t, 0, code)
+		assert.Equal(t, int32(3), hits.Load())
+		assert.Contains(t, stderr.String(), "note: found a checkpoint at line 3")
+	})
+}
+
+func TestPlain(t *testing.T) {
+	assert.Equal(t, "Processing file users.csv", plain("Processing file \x1b[32musers.csv\x1b[0m"))
+	assert.Equal(t, `{ "ok": true }`, plain("{\n  \"ok\": true\n}\n"))
+}
+
+func TestStreamLogger_TruncatesDetails(t *testing.T) {
+	var stdout bytes.Buffer
+	out := newStreamLogger(&stdout, &bytes.Buffer{}, logs.NewLogger(""))
+
+	detail := "a" + strings.Repeat("é", maxDetailLen)
+	out.Add(logs.NewMessage("Response", logs.WithDetail(detail)))
+
+	line := strings.TrimSuffix(stdout.String(), "\n")
+	assert.True(t, utf8.ValidString(line), "a rune must not be cut")
+	assert.True(t, strings.HasSuffix(line, ": a"+strings.Repeat("é", (maxDetailLen-1)/2)+"..."), line)
+}
+
+func TestCompletion(t *testing.T) {
+	t.Run("Should render a bar with the percentage and ETA", func(t *testing.T) {
+		m := processor.Metrics{TotalLines: 1000, TotalEstimated: true, PercentComplete: 25, ETA: 90500 * time.Millisecond}
+		assert.Equal(t, "[#####---------------] 25.0% of ~1000 lines, ETA 1m31s, ", completion(m))
+	})
+
+	t.Run("Should leave out an unknown ETA", func(t *testing.T) {
+		m := processor.Metrics{TotalLines: 4, PercentComplete: 100}
+		assert.Equal(t, "[####################] 100.0% of 4 lines, ", completion(m))
+	})
+
+	t.Run("Should be empty without a total", func(t *testing.T) {
+		assert.Empty(t, completion(processor.Metrics{LinesProcessed: 10}))
+		assert.Empty(t, completed(processor.Metrics{LinesProcessed: 10}))
+	})
+}
+
+func TestLatency(t *testing.T) {
+	t.Run("Should render the percentiles", func(t *testing.T) {
+		m := processor.Metrics{
+			LatencyP50: 12345 * time.Microsecond,
+			LatencyP90: 48 * time.Millisecond,
+			LatencyP99: 310 * time.Millisecond,
+			LatencyMax: 1500400 * time.Microsecond,
+		}
+		assert.Equal(t, ", latency p50 12.3ms, p90 48ms, p99 310ms, max 1.5s", latency(m))
+	})
+
+	t.Run("Should be empty without requests", func(t *testing.T) {
+		assert.Empty(t, latency(processor.Metrics{}))
+	})
+}
@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/processor"
+)
+
+// ValidateOptions holds the settings of `rapper validate`. They are
+// parsed from its arguments by ParseValidateFlags.
+type ValidateOptions struct {
+	ConfigDir string
+	Profile   string
+	Files     []string
+	JSON      bool
+}
+
+// ParseValidateFlags parses the arguments that follow the `validate`
+// subcommand. CSV files are given with -file or as positional
+// arguments, so a pre-commit hook can pass the staged files.
+func ParseValidateFlags(args []string, defaults ValidateOptions, output io.Writer) (ValidateOptions, error) {
+	opts := defaults
+	format := "text"
+
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&opts.ConfigDir, "config", opts.ConfigDir, "path to directory containing the profiles")
+	fs.StringVar(&opts.Profile, "profile", opts.Profile, "name of the profile to validate (default: every profile; with CSV files, the first valid one)")
+	fs.Func("file", "path to a CSV or JSON Lines file to check against the profile (repeatable)", func(path string) error {
+		opts.Files = append(opts.Files, path)
+		return nil
+	})
+	fs.StringVar(&format, "format", format, "output format: text or json")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	opts.Files = append(opts.Files, fs.Args()...)
+	switch format {
+	case "text":
+	case "json":
+		opts.JSON = true
+	default:
+		return opts, fmt.Errorf("invalid format %q: use text or json", format)
+	}
+
+	return opts, nil
+}
+
+// ValidateReport is what Validate found. It is printed as text, or as
+// JSON with -format json.
+type ValidateReport struct {
+	OK       bool            `json:"ok"`
+	Profiles []ProfileReport `json:"profiles"`
+	Files    []FileReport    `json:"files"`
+	// Problems are those not tied to a profile or file, e.g. a
+	// directory without profiles.
+	Problems []config.Problem `json:"problems,omitempty"`
+}
+
+// ProfileReport lists the problems of a profile file. Warnings are
+// problems the profile still loads with, e.g. unknown keys; they don't
+// fail the validation.
+type ProfileReport struct {
+	Name     string           `json:"name"`
+	Problems []config.Problem `json:"problems"`
+	Warnings []config.Problem `json:"warnings,omitempty"`
+}
+
+// FileReport lists the problems of a CSV file checked against a
+// profile. Omitted counts the problems found beyond those listed.
+type FileReport struct {
+	File     string           `json:"file"`
+	Profile  string           `json:"profile"`
+	Rows     int              `json:"rows"`
+	Problems []config.Problem `json:"problems"`
+	Omitted  int              `json:"omitted,omitempty"`
+}
+
+// Validate loads the profiles in opts.ConfigDir, the named one or all
+// of them, and checks opts.Files against the profile: their header
+// must have the csv.fields columns and every column the templates
+// read, and their rows must be well-formed UTF-8 with as many columns
+// as the header. It prints what it found to stdout and returns the
+// process exit code: 0 when nothing is wrong, 1 otherwise.
+func Validate(opts ValidateOptions, stdout io.Writer) int {
+	report := validate(opts)
+	if opts.JSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	} else {
+		printReport(stdout, report)
+	}
+
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
+
+func validate(opts ValidateOptions) ValidateReport {
+	report := ValidateReport{Profiles: []ProfileReport{}, Files: []FileReport{}}
+
+	configMgr, err := config.NewManager(opts.ConfigDir)
+	if err != nil {
+		report.Problems = problemsOf(opts.ConfigDir, err)
+		return report
+	}
+
+	names := configMgr.ListProfiles()
+	if opts.Profile != "" {
+		if !slices.Contains(names, opts.Profile) {
+			report.Problems = []config.Problem{{File: opts.ConfigDir, Message: fmt.Sprintf("profile %s not found", opts.Profile)}}
+			return report
+		}
+		names = []string{opts.Profile}
+	}
+	for _, name := range names {
+		profile := ProfileReport{Name: name, Problems: []config.Problem{}}
+		problems := problemsOf(name, configMgr.ProfileError(name))
+		if cfg := configMgr.GetProfile(name); cfg != nil {
+			problems = append(problems, cfg.Warnings()...)
+		}
+		for _, p := range problems {
+			if p.Warning {
+				profile.Warnings = append(profile.Warnings, p)
+			} else {
+				profile.Problems = append(profile.Problems, p)
+			}
+		}
+		report.Profiles = append(report.Profiles, profile)
+	}
+
+	if len(opts.Files) > 0 {
+		// Without -profile, files are checked against the profile a
+		// run would use.
+		name := opts.Profile
+		if name == "" {
+			name = configMgr.GetActiveProfile()
+		}
+		if cfg := configMgr.GetProfile(name); cfg != nil && configMgr.ProfileError(name) == nil {
+			for _, file := range opts.Files {
+				report.Files = append(report.Files, validateCSV(file, name, cfg))
+			}
+		}
+	}
+
+	report.OK = len(report.Problems) == 0 &&
+		!slices.ContainsFunc(report.Profiles, func(p ProfileReport) bool { return len(p.Problems) > 0 }) &&
+		!slices.ContainsFunc(report.Files, func(f FileReport) bool { return len(f.Problems) > 0 })
+	return report
+}
+
+// validateCSV checks the CSV file at path against the profile cfg.
+func validateCSV(path, profile string, cfg *config.Config) FileReport {
+	report := FileReport{File: path, Profile: profile, Problems: []config.Problem{}}
+
+	csv, err := processor.InspectCSV(path, cfg.CSV)
+	if err != nil {
+		report.Problems = append(report.Problems, config.Problem{File: path, Message: err.Error()})
+		return report
+	}
+	report.Rows = csv.Rows
+	report.Problems = append(report.Problems, csv.Problems...)
+	report.Omitted = csv.Omitted
+
+	for _, field := range cfg.TemplateFields() {
+		if !slices.Contains(csv.Headers, field) {
+			report.Problems = append(report.Problems, config.Problem{
+				File: path, Line: 1,
+				Message: fmt.Sprintf("the templates of profile %s use %s, which is not in the header", profile, config.FieldRef(field)),
+			})
+		}
+	}
+	return report
+}
+
+// problemsOf lists the problems err reports: those of a
+// *config.ValidationError, of every error it joins, or err itself
+// placed at file.
+func problemsOf(file string, err error) []config.Problem {
+	problems := []config.Problem{}
+	switch e := err.(type) {
+	case nil:
+		return problems
+	case *config.ValidationError:
+		return append(problems, e.Problems...)
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			problems = append(problems, problemsOf(file, err)...)
+		}
+		return problems
+	}
+
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		// e.g. "no valid config files found: " and why each failed
+		reason, _, _ := strings.Cut(err.Error(), ": ")
+		problems = append(problems, config.Problem{File: file, Message: reason})
+		return append(problems, problemsOf(file, joined.(error))...)
+	}
+	return append(problems, config.Problem{File: file, Message: err.Error()})
+}
+
+// printReport prints report as one line per profile and file, each
+// followed by its problems.
+func printReport(w io.Writer, report ValidateReport) {
+	for _, p := range report.Problems {
+		_, _ = fmt.Fprintf(w, "error: %s\n", p)
+	}
+	for _, p := range report.Profiles {
+		_, _ = fmt.Fprintf(w, "profile %s: %s%s\n", p.Name, status(len(p.Problems), 0), warnings(len(p.Warnings)))
+		printProblems(w, p.Problems)
+		for _, warning := range p.Warnings {
+			_, _ = fmt.Fprintf(w, "  warning: %s\n", warning)
+		}
+	}
+	for _, f := range report.Files {
+		_, _ = fmt.Fprintf(w, "file %s (profile %s, %d rows): %s\n", f.File, f.Profile, f.Rows, status(len(f.Problems), f.Omitted))
+		printProblems(w, f.Problems)
+		if f.Omitted > 0 {
+			_, _ = fmt.Fprintf(w, "  ... and %d more\n", f.Omitted)
+		}
+	}
+}
+
+func printProblems(w io.Writer, problems []config.Problem) {
+	for _, p := range problems {
+		_, _ = fmt.Fprintf(w, "  %s\n", p)
+	}
+}
+
+func status(problems, omitted int) string {
+	switch n := problems + omitted; n {
+	case 0:
+		return "ok"
+	case 1:
+		return "1 problem"
+	default:
+		return fmt.Sprintf("%d problems", n)
+	}
+}
+
+func warnings(n int) string {
+	switch n {
+	case 0:
+		return ""
+	case 1:
+		return ", 1 warning"
+	default:
+		return fmt.Sprintf(", %d warnings", n)
+	}
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValidateFlags(t *testing.T) {
+	t.Run("Should collect CSV files from flags and arguments", func(t *testing.T) {
+		opts, err := ParseValidateFlags([]string{"-file", "a.csv", "-format", "json", "b.csv", "c.csv"}, ValidateOptions{ConfigDir: "/etc/rapper"}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.csv", "b.csv", "c.csv"}, opts.Files)
+		assert.True(t, opts.JSON)
+		assert.Equal(t, "/etc/rapper", opts.ConfigDir, "global -config value must be kept as default")
+	})
+
+	t.Run("Should reject an unknown format", func(t *testing.T) {
+		_, err := ParseValidateFlags([]string{"-format", "xml"}, ValidateOptions{}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, `invalid format "xml"`)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("Should pass valid profiles and a matching CSV file", func(t *testing.T) {
+		dir, csvPath := writeRunFixture(t, "http://localhost", "id,name\n1,ana\n2,bob\n")
+
+		var stdout bytes.Buffer
+		code := Validate(ValidateOptions{ConfigDir: dir, Files: []string{csvPath}}, &stdout)
+
+		assert.Equal(t, 0, code, stdout.String())
+		assert.Equal(t, "profile api: ok\nfile "+csvPath+" (profile api, 2 rows): ok\n", stdout.String())
+	})
+
+	t.Run("Should report every problem of profiles and CSV files", func(t *testing.T) {
+		dir, csvPath := writeRunFixture(t, "http://localhost", "id,nome\n1,ana\n2,bob,extra\n3,\xff\n")
+		broken := "request:\n  method: FETCH\n  url_template: http://localhost\ncsv:\n  fields: [id]\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yml"), []byte(broken), 0o600))
+
+		var stdout bytes.Buffer
+		code := Validate(ValidateOptions{ConfigDir: dir, Files: []string{csvPath}}, &stdout)
+
+		assert.Equal(t, 1, code)
+		out := stdout.String()
+		assert.Contains(t, out, "profile api: ok\n")
+		assert.Contains(t, out, "profile broken: 1 problem\n  "+filepath.Join(dir, "broken.yml")+`:2:11: request.method: invalid HTTP method "FETCH"`)
+		assert.Contains(t, out, "file "+csvPath+" (profile api, 3 rows): 4 problems\n")
+		assert.Contains(t, out, csvPath+`:1: csv.fields column "name" is not in the header`)
+		assert.Contains(t, out, csvPath+":3: the row has 3 columns, the header has 2")
+		assert.Contains(t, out, csvPath+":4:3: column 2 is not valid UTF-8")
+		assert.Contains(t, out, csvPath+":1: the templates of profile api use {{.name}}, which is not in the header")
+	})
+
+	t.Run("Should pass a profile with warnings", func(t *testing.T) {
+		dir := t.TempDir()
+		profile := "request:\n  method: GET\n  url_template: http://localhost/{{.email}}\n  timeout: 5s\ncsv:\n  fields: [id]\n"
+		path := filepath.Join(dir, "api.yml")
+		require.NoError(t, os.WriteFile(path, []byte(profile), 0o600))
+
+		var stdout bytes.Buffer
+		code := Validate(ValidateOptions{ConfigDir: dir}, &stdout)
+
+		assert.Equal(t, 0, code, stdout.String())
+		assert.Equal(t, "profile api: ok, 2 warnings\n"+
+			"  warning: "+path+":3:17: request.url_template: {{.email}} is not one of csv.fields\n"+
+			"  warning: "+path+":4:3: unknown key \"timeout\" in request\n", stdout.String())
+	})
+
+	t.Run("Should report a malformed row and stop reading", func(t *testing.T) {
+		dir, csvPath := writeRunFixture(t, "http://localhost", "id,name\n1,\"ana\n")
+
+		var stdout bytes.Buffer
+		code := Validate(ValidateOptions{ConfigDir: dir, Profile: "api", Files: []string{csvPath}}, &stdout)
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stdout.String(), `extraneous or missing " in quoted-field`)
+	})
+
+	t.Run("Should output JSON", func(t *testing.T) {
+		dir, csvPath := writeRunFixture(t, "http://localhost", "id\n1\n")
+
+		var stdout bytes.Buffer
+		code := Validate(ValidateOptions{ConfigDir: dir, Files: []string{csvPath}, JSON: true}, &stdout)
+		assert.Equal(t, 1, code)
+
+		var report ValidateReport
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+		assert.False(t, report.OK)
+		require.Len(t, report.Profiles, 1)
+		assert.Empty(t, report.Profiles[0].Problems)
+		require.Len(t, report.Files, 1)
+		assert.Equal(t, 1, report.Files[0].Rows)
+		assert.Len(t, report.Files[0].Problems, 2)
+	})
+
+	t.Run("Should fail without profiles", func(t *testing.T) {
+		var stdout bytes.Buffer
+		code := Validate(ValidateOptions{ConfigDir: t.TempDir()}, &stdout)
+
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stdout.String(), "error: ")
+	})
+}
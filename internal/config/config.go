@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"time"
 
 	"github.com/anibaldeboni/rapper/internal/styles"
 	"github.com/anibaldeboni/rapper/internal/utils"
@@ -11,23 +14,223 @@ import (
 
 // CSVConfig holds CSV-specific configuration
 type CSVConfig struct {
-	Separator string   `yaml:"separator"`
-	Fields    []string `yaml:"fields"`
+	Separator  string           `yaml:"separator"`
+	Fields     []string         `yaml:"fields"`
+	FailedRows FailedRowsConfig `yaml:"failed_rows,omitempty"`
+	Results    ResultsConfig    `yaml:"results,omitempty"`
+}
+
+// FailedRowsConfig controls the re-runnable CSV of failed rows. When
+// enabled, the source records of the rows whose request failed are
+// written, with every column and the original header, to
+// "<file>.failed.csv" next to the processed file.
+type FailedRowsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// StatusColumn and ErrorColumn append a "_status" column with the
+	// response status code and an "_error" column with the transport
+	// error or the failed assertion of each failed row.
+	StatusColumn bool `yaml:"status_column,omitempty"`
+	ErrorColumn  bool `yaml:"error_column,omitempty"`
+}
+
+// ResultsConfig controls the results CSV. When enabled, every row sent
+// is written, with every column and the original header, to
+// "<file>.results.csv" next to the processed file, followed by a column
+// per request.capture entry.
+type ResultsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// StatusColumn appends a "_status" column with the response status
+	// code of each row.
+	StatusColumn bool `yaml:"status_column,omitempty"`
 }
 
 // RequestConfig holds HTTP request configuration
 type RequestConfig struct {
-	Method       string            `yaml:"method"`
-	URLTemplate  string            `yaml:"url_template"`
-	BodyTemplate string            `yaml:"body_template"`
-	Headers      map[string]string `yaml:"headers"` // Flexible headers (Authorization, Cookie, etc)
+	Method       string             `yaml:"method"`
+	URLTemplate  string             `yaml:"url_template"`
+	BodyTemplate string             `yaml:"body_template"`
+	Headers      map[string]string  `yaml:"headers"` // Flexible headers (Authorization, Cookie, etc)
+	Retry        RetryConfig        `yaml:"retry,omitempty"`
+	Assertions   []Assertion        `yaml:"assertions,omitempty"`
+	Capture      map[string]Capture `yaml:"capture,omitempty"`
+	Steps        []StepConfig       `yaml:"steps,omitempty"`
+}
+
+// StepConfig is one request of a multi-step chain sent for every row.
+// When request.steps is set it replaces the request-level method,
+// templates, assertions and capture; the request-level headers are
+// sent by every step unless the step sets the same header, and the
+// retry policy applies to every step. Later steps can reference the
+// values captured by earlier ones like any CSV column.
+type StepConfig struct {
+	Name         string             `yaml:"name,omitempty"`
+	Method       string             `yaml:"method"`
+	URLTemplate  string             `yaml:"url_template"`
+	BodyTemplate string             `yaml:"body_template,omitempty"`
+	Headers      map[string]string  `yaml:"headers,omitempty"`
+	Assertions   []Assertion        `yaml:"assertions,omitempty"`
+	Capture      map[string]Capture `yaml:"capture,omitempty"`
+}
+
+// Capture extracts a value from a response, keyed by the name it is
+// written under in the output file and the results CSV. Each capture
+// sets exactly one of JSONPath, Header or Regex.
+type Capture struct {
+	// JSONPath selects a value of the JSON body, with the same syntax
+	// as Assertion.JSONPath.
+	JSONPath string `yaml:"json_path,omitempty"`
+	// Header names a response header.
+	Header string `yaml:"header,omitempty"`
+	// Regex is matched against the body; the value is its first
+	// capture group, or the whole match when it has none.
+	Regex string `yaml:"regex,omitempty"`
+}
+
+// Assertion is a check a response must pass for its row to count as
+// successful. Each assertion sets exactly one of Status, JSONPath,
+// Header or MaxLatency. Without a Status assertion any 2xx status is
+// expected; with one, the listed statuses replace that default.
+type Assertion struct {
+	// Status lists the accepted status codes.
+	Status []int `yaml:"status,omitempty,flow"`
+	// JSONPath selects a value of the JSON body with a dot-separated
+	// path ("data.items.0.id", "items.#" for the length of an array).
+	// The value must exist unless Exists is false; Equals and Matches
+	// further constrain it.
+	JSONPath string `yaml:"json_path,omitempty"`
+	// Header names a response header that must be present; Matches
+	// further constrains its value.
+	Header string `yaml:"header,omitempty"`
+	// MaxLatency is the longest the request may take.
+	MaxLatency time.Duration `yaml:"max_latency,omitempty"`
+
+	Equals  any    `yaml:"equals,omitempty"`
+	Matches string `yaml:"matches,omitempty"`
+	Exists  *bool  `yaml:"exists,omitempty"`
+}
+
+// RetryConfig controls how failed requests are retried. Retries are
+// disabled unless MaxAttempts is greater than 1. Transport errors are
+// always retryable; responses are retried when their status code is in
+// StatusCodes (DefaultRetryStatusCodes when empty).
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+	// BaseDelay is the delay before the first retry; it doubles on
+	// every further attempt up to MaxDelay.
+	BaseDelay time.Duration `yaml:"base_delay,omitempty"`
+	MaxDelay  time.Duration `yaml:"max_delay,omitempty"`
+	// Jitter is the fraction (0 to 1) of each delay that is randomized
+	// so workers retrying at the same time spread out.
+	Jitter      float64 `yaml:"jitter,omitempty"`
+	StatusCodes []int   `yaml:"status_codes,omitempty"`
+	// IgnoreRetryAfter disables honoring the Retry-After response
+	// header, which otherwise replaces the computed backoff (capped by
+	// MaxDelay).
+	IgnoreRetryAfter bool `yaml:"ignore_retry_after,omitempty"`
+}
+
+// Retry defaults applied when the corresponding field is not set.
+const (
+	DefaultRetryBaseDelay = 200 * time.Millisecond
+	DefaultRetryMaxDelay  = 10 * time.Second
+)
+
+// DefaultRetryStatusCodes are the transient statuses retried when
+// retry.status_codes is empty.
+var DefaultRetryStatusCodes = []int{429, 502, 503, 504}
+
+// Enabled reports whether failed requests should be retried at all.
+func (r RetryConfig) Enabled() bool {
+	return r.MaxAttempts > 1
+}
+
+// RateLimitConfig caps how fast the workers send requests. A zero
+// RequestsPerSecond means unlimited. Hosts overrides the global limit
+// for requests whose URL host matches the key; those requests do not
+// count against the global limit.
+type RateLimitConfig struct {
+	RequestsPerSecond float64                  `yaml:"requests_per_second,omitempty"`
+	Burst             int                      `yaml:"burst,omitempty"`
+	Hosts             map[string]HostRateLimit `yaml:"hosts,omitempty"`
+}
+
+// HostRateLimit is the limit applied to a single host.
+type HostRateLimit struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst,omitempty"`
 }
 
 // Config is the main configuration structure
 type Config struct {
-	Request RequestConfig `yaml:"request"`
-	CSV     CSVConfig     `yaml:"csv"`
-	Workers int           `yaml:"workers"`
+	Request   RequestConfig   `yaml:"request"`
+	CSV       CSVConfig       `yaml:"csv"`
+	Workers   int             `yaml:"workers"`
+	RateLimit RateLimitConfig `yaml:"rate_limit,omitempty"`
+
+	// refs records the values resolved from ${...} references, keyed
+	// by their YAML path, so they can be masked and saved unresolved.
+	refs map[string]reference
+	// base is the merged profile named by extends, which Save leaves
+	// out of the file.
+	base    *Config
+	extends string
+	// warnings are the problems found when the profile was loaded
+	// that don't keep it from being used.
+	warnings []Problem
+}
+
+// Warnings returns the problems found when the profile was loaded that
+// don't keep it from being used, e.g. unknown keys.
+func (c *Config) Warnings() []Problem {
+	return c.warnings
+}
+
+// clone returns a copy of c that shares nothing with it that callers
+// may change, so the manager can hand out its profiles without the
+// copies racing with a reload. The base profile is shared: it is
+// never changed once loaded.
+func (c *Config) clone() *Config {
+	if c == nil {
+		return nil
+	}
+	cp := *c
+	cp.Request = c.Request.clone()
+	cp.CSV.Fields = slices.Clone(c.CSV.Fields)
+	cp.RateLimit.Hosts = maps.Clone(c.RateLimit.Hosts)
+	cp.refs = maps.Clone(c.refs)
+	cp.warnings = slices.Clone(c.warnings)
+	return &cp
+}
+
+func (r RequestConfig) clone() RequestConfig {
+	r.Headers = maps.Clone(r.Headers)
+	r.Retry.StatusCodes = slices.Clone(r.Retry.StatusCodes)
+	r.Assertions = cloneAssertions(r.Assertions)
+	r.Capture = maps.Clone(r.Capture)
+	if r.Steps != nil {
+		steps := make([]StepConfig, len(r.Steps))
+		for i, step := range r.Steps {
+			step.Headers = maps.Clone(step.Headers)
+			step.Assertions = cloneAssertions(step.Assertions)
+			step.Capture = maps.Clone(step.Capture)
+			steps[i] = step
+		}
+		r.Steps = steps
+	}
+	return r
+}
+
+func cloneAssertions(assertions []Assertion) []Assertion {
+	if assertions == nil {
+		return nil
+	}
+	cp := make([]Assertion, len(assertions))
+	for i, a := range assertions {
+		a.Status = slices.Clone(a.Status)
+		cp[i] = a
+	}
+	return cp
 }
 
 // AppConfig is the legacy structure for backward compatibility
@@ -86,7 +289,7 @@ func LoadConfig(path string) (*Config, error) {
 	// Try new format first
 	var config Config
 	err = yaml.Unmarshal(file, &config)
-	if err == nil && config.Request.Method != "" {
+	if err == nil && (config.Request.Method != "" || len(config.Request.Steps) > 0) {
 		return &config, nil
 	}
 
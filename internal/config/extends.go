@@ -0,0 +1,272 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// extendsKey is the top-level key a profile names the profile it
+// extends with, e.g. "extends: base" for base.yml in the same directory.
+const extendsKey = "extends"
+
+// ExtendsError reports a profile whose extends chain can't be resolved:
+// a missing parent or a cycle. Unlike other invalid files, which may
+// belong to other tools, such a profile is reported instead of skipped.
+type ExtendsError struct {
+	Profile string
+	Reason  string
+}
+
+func (e *ExtendsError) Error() string {
+	return fmt.Sprintf("profile %s: %s", e.Profile, e.Reason)
+}
+
+// Extends returns the name of the profile c extends, or "" when it
+// doesn't extend any.
+func (c *Config) Extends() string {
+	return c.extends
+}
+
+// Inherited reports whether the value at path (dot-separated YAML keys,
+// e.g. "request.headers.Authorization") comes unchanged from the
+// extended profile.
+func (c *Config) Inherited(path string) bool {
+	if c.base == nil {
+		return false
+	}
+	own, ok := nodeAt(encodeNode(c), path)
+	if !ok {
+		return false
+	}
+	inherited, ok := nodeAt(encodeNode(c.base), path)
+	return ok && nodesEqual(own, inherited)
+}
+
+// readProfile reads the profile at filePath and merges it over the
+// chain of profiles it extends. It returns the merged document, the
+// merged document of its parent (nil without extends) and the parent's
+// name. chain holds the profiles being read, to detect cycles.
+func readProfile(filePath string, chain []string) (doc, parent *yaml.Node, extends string, err error) {
+	name := profileName(filePath)
+	file, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to read config file %s: %w", filePath, err)
+	}
+
+	doc = &yaml.Node{}
+	if err := yaml.Unmarshal(file, doc); err != nil {
+		return nil, nil, "", &ValidationError{File: filePath, Problems: []Problem{syntaxProblem(filePath, err)}}
+	}
+
+	extends = takeExtends(doc)
+	if extends == "" {
+		return doc, nil, "", nil
+	}
+
+	chain = append(chain, name)
+	for _, visited := range chain {
+		if visited == extends {
+			return nil, nil, "", &ExtendsError{Profile: chain[0], Reason: "extends cycle " + strings.Join(append(chain, extends), " → ")}
+		}
+	}
+
+	parentPath := siblingProfile(filePath, extends)
+	if parentPath == "" {
+		return nil, nil, "", &ExtendsError{Profile: name, Reason: fmt.Sprintf("extends %q, which does not exist", extends)}
+	}
+	parent, _, _, err = readProfile(parentPath, chain)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	merged := cloneNode(parent)
+	mergeNodes(merged, doc)
+	return merged, parent, extends, nil
+}
+
+// profileName is the name of the profile at filePath: its file name
+// without the extension.
+func profileName(filePath string) string {
+	base := filepath.Base(filePath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// siblingProfile returns the path of the profile called name in the
+// directory of filePath, or "" when there is none.
+func siblingProfile(filePath, name string) string {
+	for _, ext := range []string{".yml", ".yaml"} {
+		path := filepath.Join(filepath.Dir(filePath), name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// takeExtends removes the extends key from doc and returns its value.
+func takeExtends(doc *yaml.Node) string {
+	root := rootMapping(doc)
+	if root == nil {
+		return ""
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == extendsKey {
+			value := root.Content[i+1].Value
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			return value
+		}
+	}
+	return ""
+}
+
+// mergeNodes merges src over dst: mappings are merged key by key,
+// anything else (scalars, lists) in src replaces what dst has.
+func mergeNodes(dst, src *yaml.Node) {
+	if dst.Kind == yaml.DocumentNode && src.Kind == yaml.DocumentNode {
+		if len(dst.Content) == 0 {
+			*dst = *src
+			return
+		}
+		if len(src.Content) > 0 {
+			mergeNodes(dst.Content[0], src.Content[0])
+		}
+		return
+	}
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		*dst = *src
+		return
+	}
+
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		if j := mappingIndex(dst, key.Value); j >= 0 {
+			mergeNodes(dst.Content[j+1], value)
+		} else {
+			dst.Content = append(dst.Content, key, value)
+		}
+	}
+}
+
+// pruneInherited removes from doc every value equal to the one at the
+// same place in base, so only overridden keys are left.
+func pruneInherited(doc, base *yaml.Node) {
+	if doc.Kind == yaml.DocumentNode && base.Kind == yaml.DocumentNode {
+		if len(doc.Content) > 0 && len(base.Content) > 0 {
+			pruneInherited(doc.Content[0], base.Content[0])
+		}
+		return
+	}
+	if doc.Kind != yaml.MappingNode || base.Kind != yaml.MappingNode {
+		return
+	}
+
+	kept := doc.Content[:0]
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		if j := mappingIndex(base, key.Value); j >= 0 {
+			inherited := base.Content[j+1]
+			if nodesEqual(value, inherited) {
+				continue
+			}
+			pruneInherited(value, inherited)
+		}
+		kept = append(kept, key, value)
+	}
+	doc.Content = kept
+}
+
+// setExtends adds the extends key at the top of doc.
+func setExtends(doc *yaml.Node, name string) {
+	root := rootMapping(doc)
+	if root == nil {
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: extendsKey}
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+func rootMapping(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	if doc.Kind != yaml.MappingNode {
+		return nil
+	}
+	return doc
+}
+
+func mappingIndex(n *yaml.Node, key string) int {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// nodeAt returns the node at a dot-separated path of mapping keys.
+func nodeAt(doc *yaml.Node, path string) (*yaml.Node, bool) {
+	n := rootMapping(doc)
+	if n == nil {
+		return nil, false
+	}
+	for key := range strings.SplitSeq(path, ".") {
+		if n.Kind != yaml.MappingNode {
+			return nil, false
+		}
+		i := mappingIndex(n, key)
+		if i < 0 {
+			return nil, false
+		}
+		n = n.Content[i+1]
+	}
+	return n, true
+}
+
+// nodesEqual compares two YAML values, ignoring styles and comments.
+func nodesEqual(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || len(a.Content) != len(b.Content) {
+		return false
+	}
+	if a.Kind == yaml.ScalarNode {
+		return a.Value == b.Value
+	}
+	if a.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(a.Content); i += 2 {
+			j := mappingIndex(b, a.Content[i].Value)
+			if j < 0 || !nodesEqual(a.Content[i+1], b.Content[j+1]) {
+				return false
+			}
+		}
+		return true
+	}
+	for i := range a.Content {
+		if !nodesEqual(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func cloneNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = cloneNode(child)
+	}
+	return &c
+}
+
+// encodeNode encodes cfg with its references restored, the way Save
+// writes it.
+func encodeNode(cfg *Config) *yaml.Node {
+	var doc yaml.Node
+	_ = doc.Encode(cfg)
+	restoreReferences(&doc, cfg.refs)
+	return &doc
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// referencePattern matches ${NAME}, ${NAME:-default} and ${file:path}.
+// A leading "$$" escapes the reference: "$${NAME}" is the literal text
+// "${NAME}".
+var referencePattern = regexp.MustCompile(`\$(\$?)\{([^}]*)\}`)
+
+// minSecretLen is the shortest resolved value that is masked. Shorter
+// values (a port, a flag) would mask unrelated text everywhere.
+const minSecretLen = 4
+
+// secretNameParts mark the environment variables whose values are
+// secrets, e.g. API_TOKEN, DB_PASSWORD or PROD_AUTH. Others, like
+// API_HOST, are shown as they are.
+var secretNameParts = []string{"TOKEN", "KEY", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL", "AUTH"}
+
+// sensitiveHeaders are the headers whose values are secrets whatever
+// the variables they read are named; so are headers ending in
+// "-Api-Key".
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// headerPathPattern matches the paths of request and step headers,
+// capturing the header name.
+var headerPathPattern = regexp.MustCompile(`^request\.(?:steps\.\d+\.)?headers\.(.+)$`)
+
+// isSecretName reports whether the environment variable name holds a
+// secret: one of its words, separated by underscores or other
+// punctuation, is one of secretNameParts, in the singular or plural.
+// MONKEY is not a secret.
+func isSecretName(name string) bool {
+	words := strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return (r < 'A' || r > 'Z') && (r < '0' || r > '9')
+	})
+	return slices.ContainsFunc(words, func(word string) bool {
+		return slices.Contains(secretNameParts, strings.TrimSuffix(word, "S")) || slices.Contains(secretNameParts, word)
+	})
+}
+
+// isSensitivePath reports whether every value resolved into the value
+// at path is a secret: the legacy token and sensitive headers.
+func isSensitivePath(path string) bool {
+	if path == "token" {
+		return true
+	}
+	m := headerPathPattern.FindStringSubmatch(path)
+	if m == nil {
+		return false
+	}
+	return slices.ContainsFunc(sensitiveHeaders, func(h string) bool { return strings.EqualFold(h, m[1]) }) ||
+		strings.HasSuffix(strings.ToLower(m[1]), "-api-key")
+}
+
+// reference records a profile value that contained ${...} references:
+// the text as written in the file, what it resolved to and which parts
+// of it are secrets: read from a file, from an environment variable
+// whose name says it holds one or into a sensitive header.
+type reference struct {
+	raw      string
+	resolved string
+	secrets  []string
+}
+
+// Raw returns the text the profile file holds for the value at path
+// (dot-separated YAML keys, e.g. "request.headers.Authorization"),
+// with its ${...} references unresolved. Values that didn't come from
+// references, or that changed since they were resolved, are returned
+// as they are.
+func (c *Config) Raw(path, value string) string {
+	if ref, ok := c.refs[path]; ok && ref.resolved == value {
+		return ref.raw
+	}
+	return value
+}
+
+// Secrets returns the values resolved from files and from environment
+// variables named like secrets, which must not be shown in the TUI or
+// written to the logs.
+func (c *Config) Secrets() []string {
+	var secrets []string
+	for _, ref := range c.refs {
+		for _, s := range ref.secrets {
+			if len(s) >= minSecretLen {
+				secrets = append(secrets, s)
+			}
+		}
+	}
+	slices.Sort(secrets)
+	return slices.Compact(secrets)
+}
+
+// Resolve resolves the ${...} references of values set since the
+// config was loaded, e.g. from the Settings form. Values resolved
+// before keep their references, so Save still writes them back.
+func (c *Config) Resolve() error {
+	var doc yaml.Node
+	if err := doc.Encode(c); err != nil {
+		return err
+	}
+	refs, err := resolveReferences(&doc, c.refs)
+	if err != nil {
+		return err
+	}
+
+	var resolved Config
+	if err := doc.Decode(&resolved); err != nil {
+		return err
+	}
+	resolved.refs = refs
+	resolved.base, resolved.extends, resolved.warnings = c.base, c.extends, c.warnings
+	*c = resolved
+	return nil
+}
+
+// resolveReferences replaces the references in every scalar of doc
+// with their values and returns what each changed value was. Scalars
+// that still hold the value previous recorded for them keep that
+// reference instead of being resolved again.
+func resolveReferences(doc *yaml.Node, previous map[string]reference) (map[string]reference, error) {
+	var refs map[string]reference
+	err := walkScalars(doc, "", func(path string, n *yaml.Node) error {
+		if ref, ok := previous[path]; ok && ref.resolved == n.Value {
+			refs = setReference(refs, path, ref)
+			return nil
+		}
+		if !strings.Contains(n.Value, "${") {
+			return nil
+		}
+
+		resolved, secrets, err := expandReferences(n.Value, isSensitivePath(path))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		refs = setReference(refs, path, reference{raw: n.Value, resolved: resolved, secrets: secrets})
+		n.Value = resolved
+		if n.Style == 0 {
+			// Let a plain scalar be typed by its value, so
+			// "workers: ${WORKERS}" decodes as a number.
+			n.Tag = ""
+		}
+		return nil
+	})
+	return refs, err
+}
+
+func setReference(refs map[string]reference, path string, ref reference) map[string]reference {
+	if refs == nil {
+		refs = make(map[string]reference)
+	}
+	refs[path] = ref
+	return refs
+}
+
+// restoreReferences writes back into doc the raw text of every value
+// that still holds what its reference resolved to.
+func restoreReferences(doc *yaml.Node, refs map[string]reference) {
+	if len(refs) == 0 {
+		return
+	}
+	_ = walkScalars(doc, "", func(path string, n *yaml.Node) error {
+		if ref, ok := refs[path]; ok && ref.resolved == n.Value {
+			n.Value = ref.raw
+			n.Tag = "!!str"
+		}
+		return nil
+	})
+}
+
+// expandReferences resolves the references in text and returns the
+// values among them that are secrets: all of them when sensitive.
+// Environment variables without a default must be set; ${file:path}
+// reads the file and drops its trailing line break.
+func expandReferences(text string, sensitive bool) (string, []string, error) {
+	var (
+		secrets []string
+		errs    []string
+	)
+	resolved := referencePattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := referencePattern.FindStringSubmatch(match)
+		if m[1] == "$" {
+			return match[1:]
+		}
+
+		ref := m[2]
+		if path, ok := strings.CutPrefix(ref, "file:"); ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, err.Error())
+				return ""
+			}
+			value := strings.TrimRight(string(data), "\r\n")
+			secrets = append(secrets, value)
+			return value
+		}
+
+		name, def, hasDefault := strings.Cut(ref, ":-")
+		if value, ok := os.LookupEnv(name); ok && (value != "" || !hasDefault) {
+			if sensitive || isSecretName(name) {
+				secrets = append(secrets, value)
+			}
+			return value
+		}
+		if !hasDefault {
+			errs = append(errs, "environment variable "+name+" is not set")
+		}
+		return def
+	})
+	if len(errs) > 0 {
+		return "", nil, errors.New(strings.Join(errs, "; "))
+	}
+	return resolved, secrets, nil
+}
+
+// walkScalars calls fn for every scalar of the YAML tree with its
+// dot-separated path; sequence items are keyed by their index.
+func walkScalars(n *yaml.Node, path string, fn func(path string, n *yaml.Node) error) error {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			if err := walkScalars(c, path, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if err := walkScalars(n.Content[i+1], join(n.Content[i].Value), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			if err := walkScalars(c, join(strconv.Itoa(i)), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		return fn(path, n)
+	}
+	return nil
+}
+
+// legacyReferencePaths maps the paths of a legacy profile to the paths
+// of the Config it converts to.
+var legacyReferencePaths = map[string]string{
+	"path.method":      "request.method",
+	"path.template":    "request.url_template",
+	"payload.template": "request.body_template",
+}
+
+// convertLegacyReferences moves the references of a legacy profile to
+// the paths of its converted Config, so Save writes them back.
+func convertLegacyReferences(refs map[string]reference) map[string]reference {
+	var converted map[string]reference
+	for path, ref := range refs {
+		switch {
+		case path == "token":
+			ref.raw, ref.resolved = "Bearer "+ref.raw, "Bearer "+ref.resolved
+			path = "request.headers.Authorization"
+		case legacyReferencePaths[path] != "":
+			path = legacyReferencePaths[path]
+		}
+		converted = setReference(converted, path, ref)
+	}
+	return converted
+}
@@ -3,81 +3,317 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
 
 	yaml "gopkg.in/yaml.v3"
 )
 
 // Loader handles loading and parsing YAML configuration files
-type Loader struct{}
+type Loader struct {
+	// backups is how many backups of a profile Save keeps.
+	backups int
+}
 
 // NewLoader creates a new Loader instance
-func NewLoader() *Loader {
-	return &Loader{}
+func NewLoader(opts ...LoaderOption) *Loader {
+	l := &Loader{backups: DefaultBackups}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
 }
 
 // Load reads and parses a YAML configuration file
-// Supports both new and legacy config formats
+// Supports both new and legacy config formats. ${ENV_VAR},
+// ${ENV_VAR:-default} and ${file:path} references in any value are
+// resolved; Save writes them back unresolved. A profile with
+// "extends: <name>" is merged over the profile of that name in the same
+// directory: mappings such as headers are merged key by key, scalars
+// and lists replace the inherited ones.
 func (l *Loader) Load(filePath string) (*Config, error) {
-	file, err := os.ReadFile(filePath)
+	doc, parent, extends, err := readProfile(filePath, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file %s: %w", filePath, err)
+		return nil, err
+	}
+	refs, err := resolveReferences(doc, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config in %s: %w", filePath, err)
+	}
+
+	var base *Config
+	if parent != nil {
+		parentRefs, err := resolveReferences(parent, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config in %s: %w", filePath, err)
+		}
+		base = &Config{}
+		if err := parent.Decode(base); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", filePath, err)
+		}
+		base.refs = parentRefs
 	}
 
 	// Try new format first
 	var config Config
-	err = yaml.Unmarshal(file, &config)
-	if err == nil && config.Request.Method != "" {
-		// Validate required fields
-		if err := l.validateConfig(&config); err != nil {
-			return nil, fmt.Errorf("invalid config in %s: %w", filePath, err)
+	decodeErr := doc.Decode(&config)
+	if decodeErr == nil && (config.Request.Method != "" || len(config.Request.Steps) > 0) {
+		problems := validateProfile(filePath, &config, reflect.TypeFor[Config](), l.validateConfig(&config))
+		if hasErrors(problems) {
+			return nil, &ValidationError{File: filePath, Problems: problems}
 		}
+		config.warnings = problems
+		config.refs = refs
+		config.base, config.extends = base, extends
 		return &config, nil
 	}
 
+	// A profile in the new format that doesn't decode isn't a legacy one
+	schema := schemaOf(doc)
+	if decodeErr != nil && schema == reflect.TypeFor[Config]() {
+		return nil, &ValidationError{File: filePath, Problems: decodeProblems(filePath, decodeErr)}
+	}
+
 	// Fallback to legacy format
 	var legacyConfig AppConfig
-	err = yaml.Unmarshal(file, &legacyConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse config file %s: %w", filePath, err)
+	if err := doc.Decode(&legacyConfig); err != nil {
+		if decodeErr == nil {
+			decodeErr = err
+		}
+		return nil, &ValidationError{File: filePath, Problems: decodeProblems(filePath, decodeErr)}
 	}
 
 	converted := legacyConfig.ToConfig()
-	if err := l.validateConfig(converted); err != nil {
-		return nil, fmt.Errorf("invalid config in %s: %w", filePath, err)
+	problems := validateProfile(filePath, converted, schema, l.validateConfig(converted))
+	if hasErrors(problems) {
+		return nil, &ValidationError{File: filePath, Problems: problems}
 	}
+	converted.warnings = problems
+	converted.refs = convertLegacyReferences(refs)
 
 	return converted, nil
 }
 
-// validateConfig validates the configuration structure
-func (l *Loader) validateConfig(cfg *Config) error {
-	if cfg.Request.Method == "" {
-		return errors.New("request.method is required")
+// schemaOf returns the type a profile that didn't decode as the new
+// format is checked against: AppConfig when it uses legacy keys only,
+// Config otherwise, so a profile missing request.method is still
+// checked as one.
+func schemaOf(doc *yaml.Node) reflect.Type {
+	root := rootMapping(doc)
+	if root == nil || mappingIndex(root, "request") >= 0 {
+		return reflect.TypeFor[Config]()
+	}
+	for _, key := range []string{"token", "url", "path", "payload"} {
+		if mappingIndex(root, key) >= 0 {
+			return reflect.TypeFor[AppConfig]()
+		}
 	}
-	if cfg.Request.URLTemplate == "" {
-		return errors.New("request.url_template is required")
+	return reflect.TypeFor[Config]()
+}
+
+// validateConfig validates the configuration structure and returns
+// every problem found
+func (l *Loader) validateConfig(cfg *Config) []error {
+	var errs []error
+	if len(cfg.Request.Steps) == 0 {
+		if cfg.Request.Method == "" {
+			errs = append(errs, errors.New("request.method is required"))
+		}
+		if cfg.Request.URLTemplate == "" {
+			errs = append(errs, errors.New("request.url_template is required"))
+		}
 	}
 	if len(cfg.CSV.Fields) == 0 {
-		return errors.New("csv.fields is required")
+		errs = append(errs, errors.New("csv.fields is required"))
 	}
 	if cfg.Workers < 0 {
-		return errors.New("workers must be >= 0")
+		errs = append(errs, errors.New("workers must be >= 0"))
 	}
-	return nil
+	errs = append(errs, validateRetry(cfg.Request.Retry)...)
+	errs = append(errs, validateRateLimit(cfg.RateLimit)...)
+	errs = append(errs, validateAssertions(cfg.Request.Assertions)...)
+	errs = append(errs, validateCapture(cfg.Request.Capture)...)
+	errs = append(errs, validateSteps(cfg.Request.Steps)...)
+	return errs
+}
+
+// validateRetry validates the request.retry block
+func validateRetry(r RetryConfig) []error {
+	var errs []error
+	if r.MaxAttempts < 0 {
+		errs = append(errs, errors.New("request.retry.max_attempts must be >= 0"))
+	}
+	if r.BaseDelay < 0 || r.MaxDelay < 0 {
+		errs = append(errs, errors.New("request.retry delays must be >= 0"))
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		errs = append(errs, errors.New("request.retry.jitter must be between 0 and 1"))
+	}
+	for _, code := range r.StatusCodes {
+		if code < 100 || code > 599 {
+			errs = append(errs, fmt.Errorf("request.retry.status_codes: invalid HTTP status %d", code))
+		}
+	}
+	return errs
+}
+
+// validateRateLimit validates the rate_limit block
+func validateRateLimit(r RateLimitConfig) []error {
+	var errs []error
+	if r.RequestsPerSecond < 0 || r.Burst < 0 {
+		errs = append(errs, errors.New("rate_limit values must be >= 0"))
+	}
+	for _, host := range slices.Sorted(maps.Keys(r.Hosts)) {
+		if limit := r.Hosts[host]; limit.RequestsPerSecond < 0 || limit.Burst < 0 {
+			errs = append(errs, fmt.Errorf("rate_limit.hosts.%s values must be >= 0", host))
+		}
+	}
+	return errs
+}
+
+// validateAssertions validates the request.assertions list
+func validateAssertions(assertions []Assertion) []error {
+	var errs []error
+	for i, a := range assertions {
+		name := fmt.Sprintf("request.assertions[%d]", i)
+
+		kinds := 0
+		for _, set := range []bool{len(a.Status) > 0, a.JSONPath != "", a.Header != "", a.MaxLatency != 0} {
+			if set {
+				kinds++
+			}
+		}
+		if kinds != 1 {
+			errs = append(errs, fmt.Errorf("%s must set exactly one of status, json_path, header or max_latency", name))
+		}
+
+		for _, code := range a.Status {
+			if code < 100 || code > 599 {
+				errs = append(errs, fmt.Errorf("%s.status: invalid HTTP status %d", name, code))
+			}
+		}
+		if a.MaxLatency < 0 {
+			errs = append(errs, fmt.Errorf("%s.max_latency must be >= 0", name))
+		}
+		if a.Equals != nil && a.JSONPath == "" {
+			errs = append(errs, fmt.Errorf("%s.equals requires json_path", name))
+		}
+		if a.Exists != nil && a.JSONPath == "" {
+			errs = append(errs, fmt.Errorf("%s.exists requires json_path", name))
+		}
+		if a.Matches != "" {
+			if a.JSONPath == "" && a.Header == "" {
+				errs = append(errs, fmt.Errorf("%s.matches requires json_path or header", name))
+			}
+			if _, err := regexp.Compile(a.Matches); err != nil {
+				errs = append(errs, fmt.Errorf("%s.matches: %w", name, err))
+			}
+		}
+	}
+	return errs
+}
+
+// validateCapture validates the request.capture map
+func validateCapture(capture map[string]Capture) []error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(capture)) {
+		c := capture[name]
+		kinds := 0
+		for _, set := range []bool{c.JSONPath != "", c.Header != "", c.Regex != ""} {
+			if set {
+				kinds++
+			}
+		}
+		if kinds != 1 {
+			errs = append(errs, fmt.Errorf("request.capture.%s must set exactly one of json_path, header or regex", name))
+		}
+		if c.Regex != "" {
+			if _, err := regexp.Compile(c.Regex); err != nil {
+				errs = append(errs, fmt.Errorf("request.capture.%s.regex: %w", name, err))
+			}
+		}
+	}
+	return errs
+}
+
+// validateSteps validates the request.steps list
+func validateSteps(steps []StepConfig) []error {
+	var errs []error
+	for i, s := range steps {
+		name := fmt.Sprintf("request.steps[%d]", i)
+		if s.Method == "" {
+			errs = append(errs, fmt.Errorf("%s.method is required", name))
+		}
+		if s.URLTemplate == "" {
+			errs = append(errs, fmt.Errorf("%s.url_template is required", name))
+		}
+		for _, err := range append(validateAssertions(s.Assertions), validateCapture(s.Capture)...) {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errs
 }
 
-// Save writes a configuration to a YAML file
+// Save writes a configuration to a YAML file. Values resolved from
+// ${...} references are written as the references, not their values.
+// A profile that extends another only gets the keys it overrides.
+// An existing file is updated in place, keeping its comments, key
+// order and quoting, and a timestamped copy of it is kept as a backup.
+// The file is replaced atomically.
 func (l *Loader) Save(filePath string, cfg *Config) error {
-	data, err := yaml.Marshal(cfg)
+	existing, err := os.ReadFile(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file %s: %w", filePath, err)
+	}
+	data, err := marshalProfile(cfg, existing)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return err
+	}
+	if existing != nil && unchanged(existing, data) {
+		return nil
 	}
 
-	err = os.WriteFile(filePath, data, 0644)
+	if existing != nil && l.backups > 0 {
+		if err := backupProfile(filePath, existing, l.backups); err != nil {
+			return fmt.Errorf("failed to back up config file %s: %w", filePath, err)
+		}
+	}
+	err = writeFileAtomic(filePath, data, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write config file %s: %w", filePath, err)
 	}
 
 	return nil
 }
+
+// marshalProfile encodes cfg the way Save writes it: with its ${...}
+// references unresolved and, when it extends a profile, only the
+// values it overrides. existing is the file being replaced, if any: its
+// comments, key order, quoting and indentation are kept.
+func marshalProfile(cfg *Config, existing []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	restoreReferences(&doc, cfg.refs)
+	if cfg.base != nil {
+		pruneInherited(&doc, encodeNode(cfg.base))
+		setExtends(&doc, cfg.extends)
+	}
+
+	indent := defaultIndent
+	var file yaml.Node
+	if len(existing) > 0 && yaml.Unmarshal(existing, &file) == nil && rootMapping(&file) != nil {
+		updateNode(rootMapping(&file), &doc)
+		doc, indent = file, detectIndent(existing)
+	}
+
+	data, err := encodeYAML(&doc, indent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return data, nil
+}
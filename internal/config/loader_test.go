@@ -0,0 +1,790 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeProfile writes a YAML profile into a temp dir and returns its path.
+func writeProfile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoader_Load_Retry(t *testing.T) {
+	t.Run("Should parse the retry block with durations", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  method: POST
+  url_template: https://api.example/{{.id}}
+  retry:
+    max_attempts: 4
+    base_delay: 250ms
+    max_delay: 5s
+    jitter: 0.2
+    status_codes: [429, 503]
+    ignore_retry_after: true
+csv:
+  fields: [id]
+`)
+
+		cfg, err := NewLoader().Load(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, RetryConfig{
+			MaxAttempts:      4,
+			BaseDelay:        250 * time.Millisecond,
+			MaxDelay:         5 * time.Second,
+			Jitter:           0.2,
+			StatusCodes:      []int{429, 503},
+			IgnoreRetryAfter: true,
+		}, cfg.Request.Retry)
+		assert.True(t, cfg.Request.Retry.Enabled())
+	})
+
+	t.Run("Should leave retries disabled when the block is absent", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  method: GET
+  url_template: https://api.example/{{.id}}
+csv:
+  fields: [id]
+`)
+
+		cfg, err := NewLoader().Load(path)
+		require.NoError(t, err)
+		assert.False(t, cfg.Request.Retry.Enabled())
+	})
+
+	t.Run("Should reject invalid retry settings", func(t *testing.T) {
+		for name, retry := range map[string]string{
+			"negative attempts": "max_attempts: -1",
+			"jitter above one":  "jitter: 1.5",
+			"invalid status":    "status_codes: [42]",
+			"negative delay":    "base_delay: -1s",
+		} {
+			t.Run(name, func(t *testing.T) {
+				path := writeProfile(t, "api.yml", `request:
+  method: GET
+  url_template: https://api.example
+  retry:
+    `+retry+`
+csv:
+  fields: [id]
+`)
+				_, err := NewLoader().Load(path)
+				assert.ErrorContains(t, err, "request.retry")
+			})
+		}
+	})
+}
+
+func TestLoader_Load_RateLimit(t *testing.T) {
+	t.Run("Should parse the global limit and host overrides", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  method: GET
+  url_template: https://api.example/{{.id}}
+csv:
+  fields: [id]
+rate_limit:
+  requests_per_second: 20
+  burst: 5
+  hosts:
+    api.example:
+      requests_per_second: 2
+`)
+
+		cfg, err := NewLoader().Load(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, RateLimitConfig{
+			RequestsPerSecond: 20,
+			Burst:             5,
+			Hosts:             map[string]HostRateLimit{"api.example": {RequestsPerSecond: 2}},
+		}, cfg.RateLimit)
+	})
+
+	t.Run("Should reject negative values", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  method: GET
+  url_template: https://api.example
+csv:
+  fields: [id]
+rate_limit:
+  hosts:
+    api.example:
+      requests_per_second: -1
+`)
+		_, err := NewLoader().Load(path)
+		assert.ErrorContains(t, err, "rate_limit.hosts.api.example")
+	})
+}
+
+func TestLoader_Load_FailedRows(t *testing.T) {
+	path := writeProfile(t, "api.yml", `request:
+  method: GET
+  url_template: https://api.example/{{.id}}
+csv:
+  fields: [id]
+  failed_rows:
+    enabled: true
+    status_column: true
+`)
+
+	cfg, err := NewLoader().Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, FailedRowsConfig{Enabled: true, StatusColumn: true}, cfg.CSV.FailedRows)
+}
+
+func TestLoader_Load_Assertions(t *testing.T) {
+	t.Run("Should parse every kind of assertion", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  method: POST
+  url_template: https://api.example/{{.id}}
+  assertions:
+    - status: [200, 201]
+    - json_path: ok
+      equals: true
+    - header: Content-Type
+      matches: ^application/json
+    - max_latency: 500ms
+csv:
+  fields: [id]
+`)
+
+		cfg, err := NewLoader().Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, []Assertion{
+			{Status: []int{200, 201}},
+			{JSONPath: "ok", Equals: true},
+			{Header: "Content-Type", Matches: "^application/json"},
+			{MaxLatency: 500 * time.Millisecond},
+		}, cfg.Request.Assertions)
+	})
+
+	t.Run("Should reject invalid assertions", func(t *testing.T) {
+		for assertion, want := range map[string]string{
+			"- {status: [200], header: X-Id}": "must set exactly one of",
+			"- {status: [999]}":               "invalid HTTP status 999",
+			"- {header: X-Id, matches: '('}":  "request.assertions[0].matches",
+			"- {max_latency: 1s, equals: 1}":  "equals requires json_path",
+		} {
+			path := writeProfile(t, "api.yml", `request:
+  method: POST
+  url_template: https://api.example/{{.id}}
+  assertions:
+    `+assertion+`
+csv:
+  fields: [id]
+`)
+			_, err := NewLoader().Load(path)
+			assert.ErrorContains(t, err, want, assertion)
+		}
+	})
+
+	t.Run("Should report every invalid assertion", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  method: POST
+  url_template: https://api.example/{{.id}}
+  assertions:
+    - {status: [999], header: X-Id}
+    - {max_latency: 1s, equals: 1}
+  retry:
+    jitter: 2
+csv:
+  fields: [id]
+`)
+
+		_, err := NewLoader().Load(path)
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		got := make([]string, len(validationErr.Problems))
+		for i, p := range validationErr.Problems {
+			got[i] = p.Message
+		}
+		assert.Equal(t, []string{
+			"request.assertions[0] must set exactly one of status, json_path, header or max_latency",
+			"request.assertions[0].status: invalid HTTP status 999",
+			"request.assertions[1].equals requires json_path",
+			"request.retry.jitter must be between 0 and 1",
+		}, got)
+	})
+}
+
+func TestLoader_Load_Capture(t *testing.T) {
+	t.Run("Should parse the capture map and the results block", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  method: POST
+  url_template: https://api.example/users
+  capture:
+    id: {json_path: data.id}
+    location: {header: Location}
+csv:
+  fields: [name]
+  results:
+    enabled: true
+`)
+
+		cfg, err := NewLoader().Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]Capture{
+			"id":       {JSONPath: "data.id"},
+			"location": {Header: "Location"},
+		}, cfg.Request.Capture)
+		assert.Equal(t, ResultsConfig{Enabled: true}, cfg.CSV.Results)
+	})
+
+	t.Run("Should reject a capture without exactly one source", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  method: POST
+  url_template: https://api.example/users
+  capture:
+    id: {json_path: data.id, header: X-Id}
+csv:
+  fields: [name]
+`)
+
+		_, err := NewLoader().Load(path)
+		assert.ErrorContains(t, err, "request.capture.id must set exactly one of")
+	})
+}
+
+func TestLoader_Load_Steps(t *testing.T) {
+	t.Run("Should accept a profile made of steps only", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  headers:
+    Authorization: Bearer t
+  steps:
+    - name: lookup
+      method: GET
+      url_template: https://api.example/users?email={{.email}}
+      capture:
+        id: {json_path: data.0.id}
+    - method: PUT
+      url_template: https://api.example/users/{{.id}}
+      body_template: '{"email":"{{.email}}"}'
+csv:
+  fields: [email]
+`)
+
+		cfg, err := NewLoader().Load(path)
+		require.NoError(t, err)
+		require.Len(t, cfg.Request.Steps, 2)
+		assert.Equal(t, "lookup", cfg.Request.Steps[0].Name)
+		assert.Equal(t, map[string]Capture{"id": {JSONPath: "data.0.id"}}, cfg.Request.Steps[0].Capture)
+		assert.Equal(t, "https://api.example/users/{{.id}}", cfg.Request.Steps[1].URLTemplate)
+	})
+
+	t.Run("Should reject a step without a method", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  steps:
+    - url_template: https://api.example/users
+csv:
+  fields: [email]
+`)
+
+		_, err := NewLoader().Load(path)
+		assert.ErrorContains(t, err, "request.steps[0].method is required")
+	})
+}
+
+func TestLoader_Load_References(t *testing.T) {
+	t.Setenv("RAPPER_TOKEN", "prod-token-xyz")
+	t.Setenv("RAPPER_WORKERS", "3")
+	t.Setenv("RAPPER_EMPTY", "")
+	t.Setenv("RAPPER_HOST", "api.example")
+	secret := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secret, []byte("s3cr3t-cookie\n"), 0o600))
+
+	profile := `request:
+    method: ${RAPPER_METHOD:-PUT}
+    url_template: https://${RAPPER_HOST}/{{.id}}?tag=$${literal}
+    headers:
+        Authorization: Bearer ${RAPPER_TOKEN}
+        Cookie: SESSID=${file:` + secret + `}
+        X-Region: ${RAPPER_EMPTY:-eu}
+csv:
+    fields: [id]
+workers: ${RAPPER_WORKERS}
+`
+
+	t.Run("Should resolve environment variables, defaults and files", func(t *testing.T) {
+		cfg, err := NewLoader().Load(writeProfile(t, "api.yml", profile))
+		require.NoError(t, err)
+
+		assert.Equal(t, "PUT", cfg.Request.Method)
+		assert.Equal(t, "https://api.example/{{.id}}?tag=${literal}", cfg.Request.URLTemplate)
+		assert.Equal(t, map[string]string{
+			"Authorization": "Bearer prod-token-xyz",
+			"Cookie":        "SESSID=s3cr3t-cookie",
+			"X-Region":      "eu",
+		}, cfg.Request.Headers)
+		assert.Equal(t, 3, cfg.Workers)
+		assert.Equal(t, []string{"prod-token-xyz", "s3cr3t-cookie"}, cfg.Secrets(), "a host isn't masked")
+		assert.Equal(t, "Bearer ${RAPPER_TOKEN}", cfg.Raw("request.headers.Authorization", cfg.Request.Headers["Authorization"]))
+		assert.Equal(t, "edited", cfg.Raw("request.headers.Authorization", "edited"))
+	})
+
+	t.Run("Should save the references instead of their values", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", profile)
+		loader := NewLoader()
+		cfg, err := loader.Load(path)
+		require.NoError(t, err)
+
+		cfg.Request.Headers["X-Region"] = "${RAPPER_REGION:-us}"
+		require.NoError(t, cfg.Resolve())
+		assert.Equal(t, "us", cfg.Request.Headers["X-Region"])
+		require.NoError(t, loader.Save(path, cfg))
+
+		saved, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(saved), "Bearer ${RAPPER_TOKEN}")
+		assert.Contains(t, string(saved), "${RAPPER_REGION:-us}")
+		assert.Contains(t, string(saved), "$${literal}")
+		assert.NotContains(t, string(saved), "prod-token-xyz")
+		assert.NotContains(t, string(saved), "s3cr3t-cookie")
+
+		reloaded, err := loader.Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, cfg.Request, reloaded.Request)
+		assert.Equal(t, 3, reloaded.Workers)
+	})
+
+	t.Run("Should reject a variable that is not set", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+    method: GET
+    url_template: https://api.example
+    headers:
+        Authorization: Bearer ${RAPPER_MISSING}
+csv:
+    fields: [id]
+`)
+
+		_, err := NewLoader().Load(path)
+		assert.ErrorContains(t, err, "request.headers.Authorization: environment variable RAPPER_MISSING is not set")
+	})
+}
+
+func TestLoader_Load_SensitiveHeaders(t *testing.T) {
+	t.Setenv("RAPPER_PROD_AUTH", "prod-auth-xyz")
+	t.Setenv("RAPPER_ACCESS", "access-123")
+	t.Setenv("RAPPER_SESSION", "session-456")
+	t.Setenv("RAPPER_REGION", "eu-west")
+
+	cfg, err := NewLoader().Load(writeProfile(t, "api.yml", `request:
+    method: GET
+    url_template: https://api.example/{{.id}}
+    headers:
+        authorization: Bearer ${RAPPER_ACCESS}
+        X-Api-Key: ${RAPPER_SESSION}
+        X-Auth: ${RAPPER_PROD_AUTH}
+        X-Region: ${RAPPER_REGION}
+csv:
+    fields: [id]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"access-123", "prod-auth-xyz", "session-456"}, cfg.Secrets(), "a region isn't masked")
+}
+
+func TestIsSecretName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"API_TOKEN":       true,
+		"DB_PASSWORD":     true,
+		"API_CREDENTIALS": true,
+		"PROD_AUTH":       true,
+		"stripe_keys":     true,
+		"API_HOST":        false,
+		"MONKEY":          false,
+		"TOKENIZER_MODE":  false,
+	} {
+		assert.Equal(t, want, isSecretName(name), name)
+	}
+}
+
+func TestLoader_Load_Extends(t *testing.T) {
+	writeProfiles := func(t *testing.T, profiles map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range profiles {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+		}
+		return dir
+	}
+	base := `request:
+    method: POST
+    url_template: https://staging.example/users/{{.id}}
+    headers:
+        Authorization: Bearer staging-token
+        Content-Type: application/json
+    retry:
+        max_attempts: 3
+csv:
+    fields: [id, name]
+workers: 2
+`
+
+	t.Run("Should merge a profile over the one it extends", func(t *testing.T) {
+		dir := writeProfiles(t, map[string]string{"base.yml": base, "production.yml": `extends: base
+request:
+    url_template: https://api.example/users/{{.id}}
+    headers:
+        Authorization: Bearer prod-token
+csv:
+    fields: [id]
+`})
+
+		cfg, err := NewLoader().Load(filepath.Join(dir, "production.yml"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "base", cfg.Extends())
+		assert.Equal(t, "POST", cfg.Request.Method)
+		assert.Equal(t, "https://api.example/users/{{.id}}", cfg.Request.URLTemplate)
+		assert.Equal(t, map[string]string{"Authorization": "Bearer prod-token", "Content-Type": "application/json"}, cfg.Request.Headers)
+		assert.Equal(t, 3, cfg.Request.Retry.MaxAttempts)
+		assert.Equal(t, []string{"id"}, cfg.CSV.Fields, "lists are replaced, not merged")
+		assert.Equal(t, 2, cfg.Workers)
+
+		assert.True(t, cfg.Inherited("request.method"))
+		assert.True(t, cfg.Inherited("request.headers.Content-Type"))
+		assert.False(t, cfg.Inherited("request.headers.Authorization"))
+		assert.False(t, cfg.Inherited("csv.fields"))
+	})
+
+	t.Run("Should save only the overridden keys", func(t *testing.T) {
+		dir := writeProfiles(t, map[string]string{"base.yml": base, "production.yml": `extends: base
+request:
+    url_template: https://api.example/users/{{.id}}
+`})
+		path := filepath.Join(dir, "production.yml")
+		loader := NewLoader()
+		cfg, err := loader.Load(path)
+		require.NoError(t, err)
+
+		cfg.Request.Headers["X-API-Version"] = "v2"
+		require.NoError(t, loader.Save(path, cfg))
+
+		saved, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, `extends: base
+request:
+    url_template: https://api.example/users/{{.id}}
+    headers:
+        X-API-Version: v2
+`, string(saved))
+
+		reloaded, err := loader.Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, cfg.Request, reloaded.Request)
+	})
+
+	t.Run("Should report a missing parent", func(t *testing.T) {
+		dir := writeProfiles(t, map[string]string{"production.yml": "extends: base\n"})
+
+		_, err := NewLoader().Load(filepath.Join(dir, "production.yml"))
+		var extendsErr *ExtendsError
+		require.ErrorAs(t, err, &extendsErr)
+		assert.EqualError(t, err, `profile production: extends "base", which does not exist`)
+	})
+
+	t.Run("Should report a cycle", func(t *testing.T) {
+		dir := writeProfiles(t, map[string]string{
+			"a.yml": "extends: b\n",
+			"b.yml": "extends: c\n",
+			"c.yml": "extends: a\n",
+		})
+
+		_, err := NewLoader().Load(filepath.Join(dir, "a.yml"))
+		assert.EqualError(t, err, "profile a: extends cycle a → b → c → a")
+	})
+
+	t.Run("Should list a profile with a broken extends chain as broken", func(t *testing.T) {
+		dir := writeProfiles(t, map[string]string{"base.yml": base, "production.yml": "extends: missing\n"})
+
+		mgr, err := NewManager(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"base", "production"}, mgr.ListProfiles())
+		assert.Equal(t, "base", mgr.GetActiveProfile())
+		assert.ErrorContains(t, mgr.ProfileError("production"), `profile production: extends "missing"`)
+		assert.Error(t, mgr.SetActiveProfile("production"))
+	})
+}
+
+func TestLoader_Load_Validation(t *testing.T) {
+	t.Run("Should report every problem with its line and column", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  metod: GET
+  method: FETCH
+  url_template: https://api.example/{{.id}/{{.name}}
+  body_template: '{"email": {{.email}}}'
+csv:
+  fields: [id, name]
+  separator: ";;"
+`)
+
+		_, err := NewLoader().Load(path)
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+
+		got := make([]string, len(validationErr.Problems))
+		for i, p := range validationErr.Problems {
+			got[i] = p.String()
+		}
+		assert.ElementsMatch(t, []string{
+			path + `:2:3: unknown key "metod" in request (did you mean "method"?)`,
+			path + `:3:11: request.method: invalid HTTP method "FETCH" (use one of GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS, CONNECT, TRACE)`,
+			path + `:4:17: request.url_template: bad character U+007D '}'`,
+			path + `:5:18: request.body_template: {{.email}} is not one of csv.fields`,
+			path + `:8:14: csv.separator: ";;" is not a single character other than '"' or a line break`,
+		}, got)
+	})
+
+	t.Run("Should place semantic errors at their key", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  method: GET
+  url_template: https://api.example
+  retry:
+    jitter: 1.5
+csv:
+  fields: [id]
+`)
+
+		_, err := NewLoader().Load(path)
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Len(t, validationErr.Problems, 1)
+		assert.Equal(t, 5, validationErr.Problems[0].Line)
+		assert.Equal(t, 5, validationErr.Problems[0].Column)
+	})
+
+	t.Run("Should report the line of a YAML syntax error", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", "request:\n  method: GET\n  headers: [\n")
+
+		_, err := NewLoader().Load(path)
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Len(t, validationErr.Problems, 1)
+		assert.NotZero(t, validationErr.Problems[0].Line)
+	})
+
+	t.Run("Should report values of the wrong type", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  method: GET
+  url_template: https://api.example
+csv:
+  fields: [id]
+workers: many
+`)
+
+		_, err := NewLoader().Load(path)
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.NotEmpty(t, validationErr.Problems)
+		assert.Equal(t, 6, validationErr.Problems[0].Line)
+	})
+
+	t.Run("Should accept captured values in templates", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  steps:
+    - name: login
+      method: POST
+      url_template: https://api.example/login
+      capture:
+        token: {json_path: token}
+    - name: update
+      method: PUT
+      url_template: https://api.example/users/{{.id}}
+      headers:
+        Authorization: Bearer {{.token}}
+csv:
+  fields: [id]
+`)
+
+		_, err := NewLoader().Load(path)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Should check dotted keys read with index", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  method: GET
+  url_template: https://api.example/users/{{.id}}?city={{index . "address.city"}}&zip={{index $ "address.zip"}}
+csv:
+  fields: [id, address.city]
+`)
+
+		cfg, err := NewLoader().Load(path)
+		require.NoError(t, err)
+		require.Len(t, cfg.Warnings(), 1)
+		assert.Equal(t, `request.url_template: {{index . "address.zip"}} is not one of csv.fields`, cfg.Warnings()[0].Message)
+	})
+
+	t.Run("Should load a profile with warnings only", func(t *testing.T) {
+		path := writeProfile(t, "api.yml", `request:
+  method: GET
+  url_template: https://api.example/{{.id}}
+  body_template: '{"email": {{.email}}}'
+  timeout: 5s
+csv:
+  fields: [id]
+`)
+
+		cfg, err := NewLoader().Load(path)
+		require.NoError(t, err)
+
+		got := make([]string, len(cfg.Warnings()))
+		for i, p := range cfg.Warnings() {
+			assert.True(t, p.Warning)
+			got[i] = p.String()
+		}
+		assert.Equal(t, []string{
+			path + `:4:18: request.body_template: {{.email}} is not one of csv.fields`,
+			path + `:5:3: unknown key "timeout" in request`,
+		}, got)
+	})
+}
+
+func TestLoader_Save(t *testing.T) {
+	const profile = `# Users API, production
+request:
+  method: POST # the API rejects PUT
+  url_template: "https://api.example/users/{{.id}}"
+
+  # The token is rotated monthly.
+  headers:
+    Authorization: 'Bearer ${TOKEN:-secret}'
+  body_template: |
+    {"id": {{.id}}}
+csv:
+  fields: [id, name]
+workers: 2
+`
+	writeProfile := func(t *testing.T) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "production.yml")
+		require.NoError(t, os.WriteFile(path, []byte(profile), 0o600))
+		return path
+	}
+
+	t.Run("Should keep comments, key order and quoting", func(t *testing.T) {
+		path := writeProfile(t)
+		loader := NewLoader()
+		cfg, err := loader.Load(path)
+		require.NoError(t, err)
+
+		cfg.Request.Method = "PATCH"
+		cfg.CSV.Fields = append(cfg.CSV.Fields, "email")
+		cfg.Workers = 4
+		require.NoError(t, loader.Save(path, cfg))
+
+		saved, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, `# Users API, production
+request:
+  method: PATCH # the API rejects PUT
+  url_template: "https://api.example/users/{{.id}}"
+  # The token is rotated monthly.
+  headers:
+    Authorization: 'Bearer ${TOKEN:-secret}'
+  body_template: |
+    {"id": {{.id}}}
+csv:
+  fields: [id, name, email]
+workers: 4
+`, string(saved))
+	})
+
+	t.Run("Should back up the previous file and keep the latest backups", func(t *testing.T) {
+		path := writeProfile(t)
+		dir := filepath.Dir(path)
+		loader := NewLoader(WithBackups(2))
+		cfg, err := loader.Load(path)
+		require.NoError(t, err)
+
+		for workers := 3; workers <= 5; workers++ {
+			cfg.Workers = workers
+			require.NoError(t, loader.Save(path, cfg))
+		}
+
+		backups, err := filepath.Glob(filepath.Join(dir, ".production.yml.*.bak"))
+		require.NoError(t, err)
+		require.Len(t, backups, 2)
+		latest, err := os.ReadFile(backups[1])
+		require.NoError(t, err)
+		assert.Contains(t, string(latest), "workers: 4")
+
+		files, err := profileFiles(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{path}, files, "backups are not profiles")
+	})
+
+	t.Run("Should not back up a file that did not change", func(t *testing.T) {
+		path := writeProfile(t)
+		dir := filepath.Dir(path)
+		loader := NewLoader()
+		cfg, err := loader.Load(path)
+		require.NoError(t, err)
+
+		require.NoError(t, loader.Save(path, cfg))
+
+		backups, err := filepath.Glob(filepath.Join(dir, ".production.yml.*.bak"))
+		require.NoError(t, err)
+		assert.Empty(t, backups)
+	})
+
+	t.Run("Should not keep backups when they are disabled", func(t *testing.T) {
+		path := writeProfile(t)
+		dir := filepath.Dir(path)
+		loader := NewLoader(WithBackups(0))
+		cfg, err := loader.Load(path)
+		require.NoError(t, err)
+
+		cfg.Workers = 8
+		require.NoError(t, loader.Save(path, cfg))
+
+		backups, err := filepath.Glob(filepath.Join(dir, ".production.yml.*.bak"))
+		require.NoError(t, err)
+		assert.Empty(t, backups)
+	})
+}
+
+func TestLoader_Migrate(t *testing.T) {
+	t.Run("Should keep references to variables that are not set", func(t *testing.T) {
+		path := writeProfile(t, "legacy.yml", `token: ${RAPPER_MISSING_TOKEN}
+path:
+    method: ${RAPPER_MISSING_METHOD}
+    template: https://${RAPPER_MISSING_HOST}/users/{{.id}}
+payload:
+    template: '{"id": "{{.id}}"}'
+csv:
+    fields: [id]
+`)
+
+		m, err := NewLoader().Migrate(path)
+		require.NoError(t, err)
+
+		migrated := string(m.New)
+		assert.Contains(t, migrated, "Authorization: Bearer ${RAPPER_MISSING_TOKEN}")
+		assert.Contains(t, migrated, "method: ${RAPPER_MISSING_METHOD}")
+		assert.Contains(t, migrated, "url_template: https://${RAPPER_MISSING_HOST}/users/{{.id}}")
+	})
+
+	t.Run("Should not write the values of the references", func(t *testing.T) {
+		t.Setenv("RAPPER_TOKEN", "prod-token-xyz")
+		path := writeProfile(t, "legacy.yml", `token: ${RAPPER_TOKEN}
+path:
+    method: POST
+    template: https://api.example/users
+csv:
+    fields: [id]
+`)
+
+		m, err := NewLoader().Migrate(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(m.New), "Authorization: Bearer ${RAPPER_TOKEN}")
+		assert.NotContains(t, string(m.New), "prod-token-xyz")
+	})
+}
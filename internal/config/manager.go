@@ -12,8 +12,9 @@ type managerImpl struct {
 
 // NewManager creates a new Manager instance.
 // It discovers all .yml files in the specified directory and loads them as profiles.
-func NewManager(dir string) (*managerImpl, error) {
-	loader := NewLoader()
+// The options configure the loader reading and saving them.
+func NewManager(dir string, opts ...LoaderOption) (*managerImpl, error) {
+	loader := NewLoader(opts...)
 	profileMgr := newProfileManager(loader)
 
 	// Discover profiles in the directory
@@ -28,7 +29,8 @@ func NewManager(dir string) (*managerImpl, error) {
 	}, nil
 }
 
-// Get returns the current configuration from the active profile
+// Get returns a copy of the configuration of the active profile. The
+// copy can be changed and passed to Update.
 func (m *managerImpl) Get() *Config {
 	active := m.profileMgr.getActive()
 	if active == nil {
@@ -49,20 +51,19 @@ func (m *managerImpl) GetProfile(name string) *Config {
 	return profile.Config
 }
 
-// Update updates the current configuration and notifies all listeners
+// Update updates the current configuration and notifies all listeners.
+// ${...} references in values set since cfg was loaded are resolved
+// first; an unset environment variable or unreadable file is an error.
+// The manager keeps a copy of cfg.
 func (m *managerImpl) Update(cfg *Config) error {
-	if err := m.profileMgr.updateActive(cfg); err != nil {
+	if err := cfg.Resolve(); err != nil {
 		return err
 	}
-
-	// Notify all registered listeners
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	for _, listener := range m.listeners {
-		listener(cfg)
+	if err := m.profileMgr.updateActive(cfg); err != nil {
+		return err
 	}
 
+	m.notify(cfg.clone())
 	return nil
 }
 
@@ -89,6 +90,17 @@ func (m *managerImpl) ListProfiles() []string {
 	return names
 }
 
+// ProfileError returns why the named profile failed to load, or nil
+// when it loaded (or doesn't exist). A *ValidationError lists every
+// problem found with its file, line and column.
+func (m *managerImpl) ProfileError(name string) error {
+	profile := m.profileMgr.getByName(name)
+	if profile == nil {
+		return nil
+	}
+	return profile.Err
+}
+
 // GetActiveProfile returns the name of the active profile
 func (m *managerImpl) GetActiveProfile() string {
 	active := m.profileMgr.getActive()
@@ -99,15 +111,19 @@ func (m *managerImpl) GetActiveProfile() string {
 }
 
 // SetActiveProfile switches to the specified profile and notifies all
-// OnChange listeners with the new active *Config, mirroring the notification
-// block in Update.
+// OnChange listeners with the new active *Config, like Update does.
 func (m *managerImpl) SetActiveProfile(name string) error {
 	if err := m.profileMgr.setActive(name); err != nil {
 		return err
 	}
 
-	cfg := m.Get()
+	m.notify(m.Get())
+	return nil
+}
 
+// notify calls every OnChange listener with cfg. The listeners are
+// copied first, so a listener may register another.
+func (m *managerImpl) notify(cfg *Config) {
 	m.mu.RLock()
 	listeners := make([]func(*Config), len(m.listeners))
 	copy(listeners, m.listeners)
@@ -116,6 +132,4 @@ func (m *managerImpl) SetActiveProfile(name string) error {
 	for _, fn := range listeners {
 		fn(cfg)
 	}
-
-	return nil
 }
@@ -1,7 +1,16 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 )
 
 // TestManager_GetProfile_ReturnsNamedConfig is the S-30.1 acceptance
@@ -110,3 +119,343 @@ func TestManager_SetActiveProfile_NotifiesListeners(t *testing.T) {
 		t.Fatalf("expected URLTemplate from profile p2, got %q", got.Request.URLTemplate)
 	}
 }
+
+// TestManager_Update_ResolvesReferences proves references typed into
+// the Settings form are resolved before listeners see the config, and
+// that an unset variable rejects the update.
+func TestManager_Update_ResolvesReferences(t *testing.T) {
+	t.Setenv("RAPPER_TOKEN", "token-123")
+
+	pm := newProfileManager(NewLoader())
+	pm.profiles = []Profile{{
+		Name: "p1",
+		Config: &Config{
+			Request: RequestConfig{Method: "POST", URLTemplate: "https://api1.example"},
+			CSV:     CSVConfig{Fields: []string{"a"}},
+		},
+	}}
+	mgr := &managerImpl{profileMgr: pm}
+
+	var got *Config
+	mgr.OnChange(func(cfg *Config) { got = cfg })
+
+	cfg := mgr.Get()
+	cfg.Request.Headers = map[string]string{"Authorization": "Bearer ${RAPPER_TOKEN}"}
+	if err := mgr.Update(cfg); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got == nil || got.Request.Headers["Authorization"] != "Bearer token-123" {
+		t.Fatalf("expected the listener to receive the resolved header, got %+v", got)
+	}
+	if secrets := got.Secrets(); len(secrets) != 1 || secrets[0] != "token-123" {
+		t.Fatalf("expected the resolved token to be a secret, got %v", secrets)
+	}
+
+	cfg.Request.Headers["Authorization"] = "Bearer ${RAPPER_UNSET_TOKEN}"
+	if err := mgr.Update(cfg); err == nil {
+		t.Fatalf("expected Update to fail for an unset environment variable")
+	}
+}
+
+// TestManager_Reload_PicksUpDiskChanges proves profiles added, edited
+// and removed on disk are reloaded, that listeners only hear about
+// changes to the active profile, and that a broken edit keeps the
+// profile's previous configuration.
+func TestManager_Reload_PicksUpDiskChanges(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, url string, age time.Duration) {
+		t.Helper()
+		path := filepath.Join(dir, name+".yml")
+		data := "request:\n  method: GET\n  url_template: " + url + "\ncsv:\n  fields: [id]\n"
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Distinct modification times, as the filesystem may not tell
+		// writes within the same tick apart.
+		modTime := time.Now().Add(-age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("default", "https://v1.example", time.Hour)
+	write("staging", "https://staging.example", time.Hour)
+
+	mgr, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	var notified []string
+	mgr.OnChange(func(cfg *Config) { notified = append(notified, cfg.Request.URLTemplate) })
+
+	result, err := mgr.Reload()
+	if err != nil || !result.Empty() || result.ActiveChanged {
+		t.Fatalf("expected nothing to reload without changes, got %+v, %v", result, err)
+	}
+
+	write("default", "https://v2.example", time.Minute)
+	write("production", "https://prod.example", time.Minute)
+	if err := os.Remove(filepath.Join(dir, "staging.yml")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = mgr.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	want := ReloadResult{Added: []string{"production"}, Removed: []string{"staging"}, Changed: []string{"default"}, ActiveChanged: true}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("expected %+v, got %+v", want, result)
+	}
+	if !reflect.DeepEqual(mgr.ListProfiles(), []string{"default", "production"}) {
+		t.Fatalf("expected the profile list to follow the directory, got %v", mgr.ListProfiles())
+	}
+	if !reflect.DeepEqual(notified, []string{"https://v2.example"}) {
+		t.Fatalf("expected listeners to receive the edited active profile, got %v", notified)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "default.yml"), []byte("request: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = mgr.Reload()
+	if err != nil || len(result.Errors) != 1 || result.ActiveChanged {
+		t.Fatalf("expected a reported error without changing the active profile, got %+v, %v", result, err)
+	}
+	if got := mgr.Get().Request.URLTemplate; got != "https://v2.example" {
+		t.Fatalf("expected the broken profile to keep its previous config, got %q", got)
+	}
+}
+
+// TestManager_ProfileFiles proves profiles can be created, cloned,
+// renamed and deleted, that the files follow, and that operations which
+// would lose a profile or break one extending it are refused.
+func TestManager_ProfileFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("base.yml", "request:\n  method: GET\n  url_template: https://${HOST:-base.example}\ncsv:\n  fields: [id]\n")
+	write("child.yaml", "extends: base\nworkers: 2\n")
+
+	mgr, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	var notified []string
+	mgr.OnChange(func(cfg *Config) { notified = append(notified, cfg.Request.URLTemplate) })
+
+	if err := mgr.CreateProfile("fresh"); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	if cfg := mgr.GetProfile("fresh"); cfg == nil || cfg.Request.Method != "POST" {
+		t.Fatalf("expected the blank profile to load, got %+v", cfg)
+	}
+
+	if err := mgr.CloneProfile("base", "copy"); err != nil {
+		t.Fatalf("CloneProfile: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "copy.yml"))
+	if !strings.Contains(string(data), "${HOST:-base.example}") {
+		t.Fatalf("expected the clone to keep the file as written, got %q", data)
+	}
+
+	if err := mgr.RenameProfile("child", "renamed"); err != nil {
+		t.Fatalf("RenameProfile: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "renamed.yaml")); err != nil {
+		t.Fatalf("expected the file to move keeping its extension: %v", err)
+	}
+	if !reflect.DeepEqual(mgr.ListProfiles(), []string{"base", "renamed", "fresh", "copy"}) {
+		t.Fatalf("unexpected profiles %v", mgr.ListProfiles())
+	}
+
+	for name, err := range map[string]error{
+		"existing name":      mgr.CreateProfile("copy"),
+		"invalid name":       mgr.CloneProfile("base", "../escape"),
+		"rename an extended": mgr.RenameProfile("base", "other"),
+		"delete an extended": mgr.DeleteProfile("base"),
+		"delete unknown":     mgr.DeleteProfile("missing"),
+	} {
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if err := mgr.DeleteProfile("renamed"); err != nil {
+		t.Fatalf("DeleteProfile: %v", err)
+	}
+	if len(notified) != 0 {
+		t.Fatalf("expected no notification when a non-active profile is deleted, got %v", notified)
+	}
+	if err := mgr.DeleteProfile("base"); err != nil {
+		t.Fatalf("DeleteProfile(active): %v", err)
+	}
+	if mgr.GetActiveProfile() != "fresh" || len(notified) != 1 {
+		t.Fatalf("expected the first remaining profile to become active and be notified, got %q, %v", mgr.GetActiveProfile(), notified)
+	}
+
+	if result, err := mgr.Reload(); err != nil || !result.Empty() {
+		t.Fatalf("expected the watcher not to report changes made through the manager, got %+v, %v", result, err)
+	}
+}
+
+// TestManager_ProfileFiles_WithoutHardLinks proves profiles can be
+// created, cloned and renamed on a filesystem without hard links, still
+// without replacing an existing file.
+func TestManager_ProfileFiles_WithoutHardLinks(t *testing.T) {
+	link = func(oldname, newname string) error {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: syscall.EPERM}
+	}
+	t.Cleanup(func() { link = os.Link })
+
+	dir := t.TempDir()
+	base := "request:\n  method: GET\n  url_template: https://api.example\ncsv:\n  fields: [id]\n"
+	if err := os.WriteFile(filepath.Join(dir, "base.yml"), []byte(base), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	if err := mgr.CreateProfile("fresh"); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	if err := mgr.CloneProfile("base", "copy"); err != nil {
+		t.Fatalf("CloneProfile: %v", err)
+	}
+	if err := mgr.RenameProfile("copy", "renamed"); err != nil {
+		t.Fatalf("RenameProfile: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "renamed.yml"))
+	if err != nil || string(data) != base {
+		t.Fatalf("expected the renamed file to hold the clone, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "copy.yml")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the old file to be removed, got %v", err)
+	}
+
+	if err := createFileAtomic(filepath.Join(dir, "fresh.yml"), []byte("replaced\n"), 0o644); err == nil {
+		t.Fatalf("expected an existing file not to be replaced")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "fresh.yml")); string(data) != blankProfile {
+		t.Fatalf("expected the existing file to be kept, got %q", data)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, ".*.tmp"))
+	if len(files) != 0 {
+		t.Fatalf("expected no temporary files left, got %v", files)
+	}
+}
+
+// TestManager_BrokenProfiles proves a profile file that fails
+// validation is listed with its problems instead of skipped, can't be
+// made active, and becomes usable once fixed on disk, while files of
+// other tools are still skipped.
+func TestManager_BrokenProfiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string, age time.Duration) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := time.Now().Add(-age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("api.yml", "request:\n  method: GET\n  url_template: https://api.example/{{.id}}\ncsv:\n  fields: [id]\n", time.Hour)
+	write("broken.yml", "request:\n  method: GET\n  url_templat: https://api.example/{{.id}}\ncsv:\n  fields: [id]\n", time.Hour)
+	write("compose.yml", "services:\n  db:\n    image: postgres\n", time.Hour)
+
+	mgr, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	if !reflect.DeepEqual(mgr.ListProfiles(), []string{"api", "broken"}) {
+		t.Fatalf("expected the broken profile listed and other files skipped, got %v", mgr.ListProfiles())
+	}
+
+	var validationErr *ValidationError
+	if !errors.As(mgr.ProfileError("broken"), &validationErr) {
+		t.Fatalf("expected a validation error, got %v", mgr.ProfileError("broken"))
+	}
+	if !strings.Contains(validationErr.Error(), `broken.yml:3:3: unknown key "url_templat" in request (did you mean "url_template"?)`) {
+		t.Fatalf("expected the misspelled key located with a suggestion, got %v", validationErr)
+	}
+	if mgr.ProfileError("api") != nil || mgr.GetProfile("broken") != nil {
+		t.Fatalf("expected only the broken profile to have an error and no config")
+	}
+	if err := mgr.SetActiveProfile("broken"); err == nil {
+		t.Fatalf("expected a broken profile not to become active")
+	}
+
+	write("broken.yml", "request:\n  method: GET\n  url_template: https://api.example/{{.id}}\ncsv:\n  fields: [id]\n", time.Minute)
+	result, err := mgr.Reload()
+	if err != nil || !reflect.DeepEqual(result.Changed, []string{"broken"}) {
+		t.Fatalf("expected the fixed profile reported as changed, got %+v, %v", result, err)
+	}
+	if mgr.ProfileError("broken") != nil || mgr.SetActiveProfile("broken") != nil {
+		t.Fatalf("expected the fixed profile to be usable")
+	}
+}
+
+// TestManager_Watch_ConcurrentUpdates proves the configuration can be
+// read, changed and updated while Watch reloads the profiles from
+// another goroutine. Run it with -race.
+func TestManager_Watch_ConcurrentUpdates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, url string) {
+		t.Helper()
+		data := "request:\n  method: GET\n  url_template: " + url + "\ncsv:\n  fields: [id]\n"
+		if err := os.WriteFile(filepath.Join(dir, name+".yml"), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("default", "https://default.example")
+	write("staging", "https://staging.example")
+
+	mgr, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	mgr.OnChange(func(cfg *Config) { _ = cfg.Request.Headers["X-Run"] })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		mgr.Watch(ctx, time.Millisecond, func(ReloadResult, error) {})
+	}()
+
+	for i := range 200 {
+		cfg := mgr.Get()
+		cfg.Request.Headers = map[string]string{"X-Run": strconv.Itoa(i)}
+		cfg.CSV.Fields = append(cfg.CSV.Fields, "name")
+		if err := mgr.Update(cfg); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		cfg.Request.Headers["X-Run"] = "changed after the update"
+		write("staging", "https://staging.example/"+strconv.Itoa(i))
+	}
+	cancel()
+	<-done
+
+	cfg := mgr.Get()
+	cfg.Request.Headers = map[string]string{"X-Run": "last"}
+	if err := mgr.Update(cfg); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	cfg.Request.Headers["X-Run"] = "changed after the update"
+	if got := mgr.Get().Request.Headers["X-Run"]; got != "last" {
+		t.Fatalf("expected the manager to keep its own copy of the update, got %q", got)
+	}
+	cfg = mgr.Get()
+	cfg.CSV.Fields[0] = "changed"
+	if got := mgr.Get().CSV.Fields[0]; got != "id" {
+		t.Fatalf("expected Get to return a copy, got field %q", got)
+	}
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// backupSuffix is appended to the name of a migrated file to name its
+// backup. The backup has no YAML extension, so it isn't a profile.
+const backupSuffix = ".bak"
+
+// ErrNotLegacy is returned by Migrate for a file already in the current
+// format.
+var ErrNotLegacy = errors.New("not a legacy profile")
+
+// Migration is the conversion of a legacy profile file (token, path
+// and payload keys) to the current format.
+type Migration struct {
+	File string
+	Old  []byte // the file as it is
+	New  []byte // the file in the current format
+	// Notes are the legacy values the conversion drops.
+	Notes []string
+}
+
+// Backup is the path the file is copied to before it is replaced.
+func (m *Migration) Backup() string {
+	return m.File + backupSuffix
+}
+
+// Apply copies the file to its backup and replaces it with the
+// converted profile. An existing backup, e.g. of an earlier migration,
+// is never replaced: Apply fails instead.
+func (m *Migration) Apply() error {
+	info, err := os.Stat(m.File)
+	if err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", m.File, err)
+	}
+	if err := createFileAtomic(m.Backup(), m.Old, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", m.File, err)
+	}
+	if err := writeFileAtomic(m.File, m.New, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", m.File, err)
+	}
+	return nil
+}
+
+// LegacyProfiles returns the YAML files in dir that are legacy
+// profiles. Files that aren't valid YAML are left out; other invalid
+// profiles are included, for Migrate to report.
+func LegacyProfiles(dir string) ([]string, error) {
+	files, err := profileFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var legacy []string
+	for _, filePath := range files {
+		if strings.HasPrefix(filepath.Base(filePath), ".") {
+			continue
+		}
+		doc, err := readYAML(filePath)
+		if err == nil && isLegacy(doc) {
+			legacy = append(legacy, filePath)
+		}
+	}
+	return legacy, nil
+}
+
+// Migrate converts the legacy profile at filePath to the current
+// format, the way Load reads it: the token becomes an Authorization
+// header. ${...} references aren't resolved but kept as written, so
+// the variables and files they read needn't exist. Nothing is written
+// until the migration is applied. A file already in the current format
+// is an ErrNotLegacy error; one that isn't valid, its problems.
+func (l *Loader) Migrate(filePath string) (*Migration, error) {
+	old, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", filePath, err)
+	}
+	doc, err := readYAML(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !isLegacy(doc) {
+		return nil, fmt.Errorf("%s: %w", filePath, ErrNotLegacy)
+	}
+	if root := rootMapping(doc); mappingIndex(root, extendsKey) >= 0 {
+		return nil, fmt.Errorf("%s: a legacy profile that extends another can't be migrated", filePath)
+	}
+
+	var legacy AppConfig
+	if err := doc.Decode(&legacy); err != nil {
+		return nil, &ValidationError{File: filePath, Problems: decodeProblems(filePath, err)}
+	}
+	cfg := legacy.ToConfig()
+	schema := reflect.TypeFor[AppConfig]()
+	if problems := validateProfile(filePath, cfg, schema, l.validateConfig(cfg)); hasErrors(problems) {
+		return nil, &ValidationError{File: filePath, Problems: problems}
+	}
+	converted, err := marshalProfile(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migration{File: filePath, Old: old, New: converted}
+	if n, ok := nodeAt(doc, "url"); ok && n.Value != "" {
+		m.Notes = append(m.Notes, fmt.Sprintf("url %q is not used (path.template holds the whole URL) and was dropped", n.Value))
+	}
+	return m, nil
+}
+
+// isLegacy reports whether doc is a legacy profile: it has legacy keys
+// and no request.
+func isLegacy(doc *yaml.Node) bool {
+	return rootMapping(doc) != nil && schemaOf(doc) == reflect.TypeFor[AppConfig]()
+}
+
+func readYAML(filePath string) (*yaml.Node, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", filePath, err)
+	}
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, &ValidationError{File: filePath, Problems: []Problem{syntaxProblem(filePath, err)}}
+	}
+	return doc, nil
+}
@@ -3,9 +3,13 @@ package config
 import (
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+
+	yaml "gopkg.in/yaml.v3"
 )
 
 // Profile represents a configuration profile
@@ -13,8 +17,20 @@ type Profile struct {
 	Name     string  // Name of the profile (e.g., "api1", "production")
 	FilePath string  // Path to the YAML file (e.g., "./api1.yml")
 	Config   *Config // Loaded configuration
+	// Err is why the file didn't load. Config is nil then, unless the
+	// profile loaded before and keeps that configuration.
+	Err error
 }
 
+// usable reports whether the profile has a configuration, so it can be
+// made active.
+func (p Profile) usable() bool { return p.Config != nil }
+
+// profileKeys are the top-level keys only rapper profiles use, current
+// or legacy. A file that fails to load is listed as a broken profile
+// when it has one of them.
+var profileKeys = []string{"request", "csv", "workers", "rate_limit", extendsKey, "token", "url", "path", "payload"}
+
 // profileManagerImpl manages multiple configuration profiles.
 // This is an internal implementation - not exposed as an interface.
 type profileManagerImpl struct {
@@ -22,6 +38,11 @@ type profileManagerImpl struct {
 	activeIndex  int
 	configLoader *Loader
 	mu           sync.RWMutex
+
+	// dir is the discovered directory and stamps the versions of its
+	// files that were last loaded, so reload can tell what changed.
+	dir    string
+	stamps map[string]fileStamp
 }
 
 // newProfileManager creates a new profile manager instance
@@ -38,61 +59,122 @@ func (pm *profileManagerImpl) discover(dir string) ([]Profile, error) {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
-	// Search for .yml files
-	ymlFiles, err := filepath.Glob(filepath.Join(dir, "*.yml"))
+	files, err := profileFiles(dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to glob yml files: %w", err)
+		return nil, err
 	}
 
-	// Search for .yaml files
-	yamlFiles, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to glob yaml files: %w", err)
+	profiles := make([]Profile, 0, len(files))
+	extended := extendedProfiles(files)
+	var errs []error
+
+	for _, filePath := range files {
+		// Skip hidden files (starting with .)
+		if strings.HasPrefix(filepath.Base(filePath), ".") {
+			continue
+		}
+
+		profile, ok := pm.loadProfile(filePath, extended)
+		if !ok {
+			continue
+		}
+		if profile.Err != nil {
+			errs = append(errs, profile.Err)
+		}
+		profiles = append(profiles, profile)
 	}
 
-	// Combine both lists
-	files := append(ymlFiles, yamlFiles...)
+	// The first valid profile is active by default
+	active := slices.IndexFunc(profiles, Profile.usable)
+	if active < 0 {
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("no valid config files found: %w", errors.Join(errs...))
+		}
+		return nil, errors.New("no valid config files found")
+	}
 
-	if len(files) == 0 {
-		return nil, fmt.Errorf("no .yml or .yaml files found in %s", dir)
+	pm.profiles = profiles
+	pm.activeIndex = active
+	pm.dir = dir
+	pm.stamps = stampFiles(files)
+
+	return profiles, nil
+}
+
+// loadProfile loads the profile at filePath. A file that fails to load
+// is kept as a broken profile, listed with its error, when it looks like
+// a profile; other files, which may belong to other tools, and profiles
+// others extend, which may be incomplete on their own, are skipped.
+func (pm *profileManagerImpl) loadProfile(filePath string, extended []string) (Profile, bool) {
+	p := Profile{Name: profileName(filePath), FilePath: filePath}
+	p.Config, p.Err = pm.configLoader.Load(filePath)
+	if p.Err != nil && (slices.Contains(extended, p.Name) || !looksLikeProfile(filePath, p.Err)) {
+		return p, false
 	}
+	return p, true
+}
 
-	profiles := make([]Profile, 0, len(files))
+// looksLikeProfile reports whether the file at filePath, which failed
+// to load with err, is meant as a profile: it uses extends, isn't valid
+// YAML or has a top-level key only profiles use.
+func looksLikeProfile(filePath string, err error) bool {
+	var extendsErr *ExtendsError
+	if errors.As(err, &extendsErr) {
+		return true
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return false
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return true
+	}
+	root := rootMapping(&doc)
+	return root != nil && slices.ContainsFunc(profileKeys, func(key string) bool { return mappingIndex(root, key) >= 0 })
+}
 
+// extendedProfiles returns the names of the profiles the given files
+// extend.
+func extendedProfiles(files []string) []string {
+	var names []string
 	for _, filePath := range files {
-		// Extract filename to check if it should be skipped
-		baseName := filepath.Base(filePath)
-
-		// Skip hidden files (starting with .) and known non-profile config files
-		if strings.HasPrefix(baseName, ".") {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
 			continue
 		}
-
-		// Load the configuration file
-		cfg, err := pm.configLoader.Load(filePath)
-		if err != nil {
-			// Silently skip invalid files (they might be config files for other tools)
+		var doc yaml.Node
+		if yaml.Unmarshal(data, &doc) != nil {
 			continue
 		}
+		if name := takeExtends(&doc); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
 
-		// Extract profile name from filename (without extension)
-		name := strings.TrimSuffix(baseName, filepath.Ext(baseName))
-
-		profiles = append(profiles, Profile{
-			Name:     name,
-			FilePath: filePath,
-			Config:   cfg,
-		})
+// profileFiles returns the .yml and .yaml files in dir.
+func profileFiles(dir string) ([]string, error) {
+	// Search for .yml files
+	ymlFiles, err := filepath.Glob(filepath.Join(dir, "*.yml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob yml files: %w", err)
 	}
 
-	if len(profiles) == 0 {
-		return nil, errors.New("no valid config files found")
+	// Search for .yaml files
+	yamlFiles, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob yaml files: %w", err)
 	}
 
-	pm.profiles = profiles
-	pm.activeIndex = 0 // First profile is active by default
+	// Combine both lists
+	files := append(ymlFiles, yamlFiles...)
 
-	return profiles, nil
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .yml or .yaml files found in %s", dir)
+	}
+	return files, nil
 }
 
 // list returns all available profiles
@@ -106,7 +188,9 @@ func (pm *profileManagerImpl) list() []Profile {
 	return profiles
 }
 
-// getActive returns the currently active profile
+// getActive returns a copy of the currently active profile, whose
+// Config the caller may change: a reload replaces the profiles from
+// another goroutine.
 func (pm *profileManagerImpl) getActive() *Profile {
 	pm.mu.RLock()
 	defer pm.mu.RUnlock()
@@ -114,28 +198,29 @@ func (pm *profileManagerImpl) getActive() *Profile {
 	if pm.activeIndex < 0 || pm.activeIndex >= len(pm.profiles) {
 		return nil
 	}
-
-	// Return a pointer to the profile (not a copy)
-	return &pm.profiles[pm.activeIndex]
+	return pm.profiles[pm.activeIndex].clone()
 }
 
-// getByName returns the profile with the given name, or nil if no
-// profile has that name. The read lock is held only for the duration
-// of the scan; the returned pointer is into the live slice but
-// callers should treat it as read-only (or hold the manager's
-// external lock for any mutation).
+// getByName returns a copy of the profile with the given name, like
+// getActive, or nil if no profile has that name.
 func (pm *profileManagerImpl) getByName(name string) *Profile {
 	pm.mu.RLock()
 	defer pm.mu.RUnlock()
 
 	for i := range pm.profiles {
 		if pm.profiles[i].Name == name {
-			return &pm.profiles[i]
+			return pm.profiles[i].clone()
 		}
 	}
 	return nil
 }
 
+// clone returns a copy of the profile with its own Config.
+func (p Profile) clone() *Profile {
+	p.Config = p.Config.clone()
+	return &p
+}
+
 // setActive switches to a different profile by name
 func (pm *profileManagerImpl) setActive(name string) error {
 	pm.mu.Lock()
@@ -143,6 +228,9 @@ func (pm *profileManagerImpl) setActive(name string) error {
 
 	for i, profile := range pm.profiles {
 		if profile.Name == name {
+			if !profile.usable() {
+				return profile.Err
+			}
 			pm.activeIndex = i
 			return nil
 		}
@@ -151,7 +239,8 @@ func (pm *profileManagerImpl) setActive(name string) error {
 	return fmt.Errorf("profile %s not found", name)
 }
 
-// updateActive updates the configuration of the active profile
+// updateActive updates the configuration of the active profile with a
+// copy of cfg, so the caller changing cfg later doesn't change it.
 func (pm *profileManagerImpl) updateActive(cfg *Config) error {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
@@ -160,14 +249,14 @@ func (pm *profileManagerImpl) updateActive(cfg *Config) error {
 		return errors.New("no active profile")
 	}
 
-	pm.profiles[pm.activeIndex].Config = cfg
+	pm.profiles[pm.activeIndex].Config = cfg.clone()
 	return nil
 }
 
 // save persists the active profile to its YAML file
 func (pm *profileManagerImpl) save() error {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
 
 	if pm.activeIndex < 0 || pm.activeIndex >= len(pm.profiles) {
 		return errors.New("no active profile")
@@ -180,6 +269,8 @@ func (pm *profileManagerImpl) save() error {
 	if err != nil {
 		return fmt.Errorf("failed to save profile %s: %w", active.Name, err)
 	}
+	// The file now matches memory: don't reload our own write.
+	pm.restamp(active.FilePath)
 
 	return nil
 }
@@ -0,0 +1,347 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"syscall"
+)
+
+// link is os.Link, replaced in tests to act like a filesystem without
+// hard links.
+var link = os.Link
+
+// profileNamePattern is what a profile name may look like: it becomes
+// the file name, so no path separators and no leading dot (hidden files
+// aren't profiles).
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// blankProfile is the file CreateProfile writes: the smallest profile
+// that loads, for the user to fill in from the Settings view.
+const blankProfile = `request:
+    method: POST
+    url_template: http://localhost:8080/api/users/{{.id}}
+    headers:
+        Content-Type: application/json
+csv:
+    fields:
+        - id
+`
+
+// validateProfileName reports why name can't be used for a new profile.
+func validateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '-' and '_'", name)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to path through a temporary file in the
+// same directory, so readers (and the profile watcher) never see a
+// half-written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := writeTemp(path, data, perm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	return os.Rename(tmp, path)
+}
+
+// createFileAtomic is writeFileAtomic for a file that must not exist
+// yet: linking the temporary file fails instead of replacing a file
+// created in the meantime. On filesystems without hard links the file
+// is created exclusively and written in place instead.
+func createFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := writeTemp(path, data, perm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if err := link(tmp, path); !linkUnsupported(err) {
+		return err
+	}
+	return createFile(path, data, perm)
+}
+
+// linkUnsupported reports whether err is a link failing because the
+// filesystem has no hard links, e.g. some FUSE, SMB and container
+// mounts.
+func linkUnsupported(err error) bool {
+	return errors.Is(err, syscall.EPERM) || errors.Is(err, errors.ErrUnsupported)
+}
+
+// createFile writes data to path, which must not exist, and syncs it.
+// A file it fails to write is removed.
+func createFile(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(path, perm)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
+
+// moveFile moves oldPath to newPath, failing instead of replacing an
+// existing newPath: it links then removes, or copies then removes
+// without hard links.
+func moveFile(oldPath, newPath string) error {
+	if err := link(oldPath, newPath); linkUnsupported(err) {
+		if err := copyFile(oldPath, newPath); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	if err := os.Remove(oldPath); err != nil {
+		_ = os.Remove(newPath)
+		return err
+	}
+	return nil
+}
+
+// copyFile copies oldPath to newPath, which must not exist.
+func copyFile(oldPath, newPath string) error {
+	info, err := os.Stat(oldPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(oldPath)
+	if err != nil {
+		return err
+	}
+	return createFile(newPath, data, info.Mode().Perm())
+}
+
+// writeTemp writes data to a temporary file next to path and returns
+// its name. It is hidden and has no YAML extension, so it is never
+// taken for a profile.
+func writeTemp(path string, data []byte, perm os.FileMode) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// create writes data as the profile called name and loads it.
+func (pm *profileManagerImpl) create(name string, data []byte) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if err := pm.checkNameFree(name); err != nil {
+		return err
+	}
+	filePath := filepath.Join(pm.dir, name+".yml")
+	if err := createFileAtomic(filePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to create profile %s: %w", name, err)
+	}
+
+	cfg, err := pm.configLoader.Load(filePath)
+	if err != nil {
+		_ = os.Remove(filePath)
+		return fmt.Errorf("failed to create profile %s: %w", name, err)
+	}
+	pm.profiles = append(pm.profiles, Profile{Name: name, FilePath: filePath, Config: cfg})
+	pm.restamp(filePath)
+	return nil
+}
+
+// rename renames the profile and moves its file, keeping its extension.
+func (pm *profileManagerImpl) rename(oldName, newName string) error {
+	if err := validateProfileName(newName); err != nil {
+		return err
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	i := pm.indexOf(oldName)
+	if i < 0 {
+		return fmt.Errorf("profile %s not found", oldName)
+	}
+	if err := pm.checkNotExtended(oldName, "rename"); err != nil {
+		return err
+	}
+	if err := pm.checkNameFree(newName); err != nil {
+		return err
+	}
+
+	oldPath := pm.profiles[i].FilePath
+	newPath := filepath.Join(filepath.Dir(oldPath), newName+filepath.Ext(oldPath))
+	if err := moveFile(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename profile %s: %w", oldName, err)
+	}
+
+	pm.profiles[i].Name, pm.profiles[i].FilePath = newName, newPath
+	pm.restamp(oldPath, newPath)
+	return nil
+}
+
+// remove deletes the profile and its file. Removing the active profile
+// makes the first remaining valid one active; it reports whether it did.
+func (pm *profileManagerImpl) remove(name string) (activeChanged bool, err error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	i := pm.indexOf(name)
+	if i < 0 {
+		return false, fmt.Errorf("profile %s not found", name)
+	}
+	others := slices.Delete(slices.Clone(pm.profiles), i, i+1)
+	if pm.profiles[i].usable() && !slices.ContainsFunc(others, Profile.usable) {
+		return false, fmt.Errorf("profile %s is the only valid profile", name)
+	}
+	if err := pm.checkNotExtended(name, "delete"); err != nil {
+		return false, err
+	}
+
+	filePath := pm.profiles[i].FilePath
+	if err := os.Remove(filePath); err != nil {
+		return false, fmt.Errorf("failed to delete profile %s: %w", name, err)
+	}
+
+	pm.profiles = others
+	switch {
+	case i == pm.activeIndex:
+		pm.activeIndex = slices.IndexFunc(pm.profiles, Profile.usable)
+		activeChanged = true
+	case i < pm.activeIndex:
+		pm.activeIndex--
+	}
+	pm.restamp(filePath)
+	return activeChanged, nil
+}
+
+// fileOf returns the file of the named profile. The caller must not
+// hold the lock.
+func (pm *profileManagerImpl) fileOf(name string) (string, error) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	i := pm.indexOf(name)
+	if i < 0 {
+		return "", fmt.Errorf("profile %s not found", name)
+	}
+	return pm.profiles[i].FilePath, nil
+}
+
+// indexOf returns the index of the named profile, or -1. The caller
+// must hold the lock.
+func (pm *profileManagerImpl) indexOf(name string) int {
+	return slices.IndexFunc(pm.profiles, func(p Profile) bool { return p.Name == name })
+}
+
+// checkNameFree reports an error when a profile, or a profile file that
+// didn't load, already uses name. The caller must hold the lock.
+func (pm *profileManagerImpl) checkNameFree(name string) error {
+	if pm.indexOf(name) >= 0 {
+		return fmt.Errorf("profile %s already exists", name)
+	}
+	for _, ext := range []string{".yml", ".yaml"} {
+		if _, err := os.Stat(filepath.Join(pm.dir, name+ext)); !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("file %s already exists", name+ext)
+		}
+	}
+	return nil
+}
+
+// checkNotExtended reports an error when another profile extends name,
+// since renaming or deleting it would break that profile. The caller
+// must hold the lock.
+func (pm *profileManagerImpl) checkNotExtended(name, action string) error {
+	for _, p := range pm.profiles {
+		if p.Config != nil && p.Config.Extends() == name {
+			return fmt.Errorf("cannot %s profile %s: profile %s extends it", action, name, p.Name)
+		}
+	}
+	return nil
+}
+
+// restamp records the current version of the given files, or that they
+// are gone, so the watcher doesn't report changes made from the app.
+// The caller must hold the lock.
+func (pm *profileManagerImpl) restamp(paths ...string) {
+	if pm.stamps == nil {
+		return
+	}
+	for _, path := range paths {
+		if stamp, ok := stampFile(path); ok {
+			pm.stamps[path] = stamp
+		} else {
+			delete(pm.stamps, path)
+		}
+	}
+}
+
+// CreateProfile creates a profile called name from a blank template.
+func (m *managerImpl) CreateProfile(name string) error {
+	return m.profileMgr.create(name, []byte(blankProfile))
+}
+
+// CloneProfile creates a profile called name as a copy of the source
+// profile's file, so its references and extends are kept as written.
+func (m *managerImpl) CloneProfile(source, name string) error {
+	filePath, err := m.profileMgr.fileOf(source)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to clone profile %s: %w", source, err)
+	}
+	return m.profileMgr.create(name, data)
+}
+
+// RenameProfile renames a profile and its file. A profile other
+// profiles extend can't be renamed.
+func (m *managerImpl) RenameProfile(oldName, newName string) error {
+	return m.profileMgr.rename(oldName, newName)
+}
+
+// DeleteProfile deletes a profile and its file. The only valid profile,
+// or one other profiles extend, can't be deleted. When the active
+// profile is deleted, the first remaining valid one becomes active and
+// the OnChange listeners are notified.
+func (m *managerImpl) DeleteProfile(name string) error {
+	activeChanged, err := m.profileMgr.remove(name)
+	if err != nil {
+		return err
+	}
+	if activeChanged {
+		m.notify(m.Get())
+	}
+	return nil
+}
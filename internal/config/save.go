@@ -0,0 +1,170 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// DefaultBackups is how many backups of each profile Save keeps unless
+// WithBackups says otherwise.
+const DefaultBackups = 3
+
+// backupTimeFormat names backups so they sort by the time they were
+// taken.
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+// defaultIndent is the indentation of profiles written from scratch,
+// and of those whose indentation can't be told.
+const defaultIndent = 4
+
+// LoaderOption configures a Loader.
+type LoaderOption func(*Loader)
+
+// WithBackups sets how many timestamped backups of a profile Save
+// keeps, the most recent ones. 0 disables them.
+func WithBackups(n int) LoaderOption {
+	return func(l *Loader) {
+		l.backups = max(n, 0)
+	}
+}
+
+// encodeYAML encodes doc with the given indentation.
+func encodeYAML(doc *yaml.Node, indent int) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// updateNode updates dst, a node of the file being saved, to the value
+// of src, the same node encoded from the configuration. The keys of dst
+// keep their order and comments; keys src doesn't have are removed and
+// those only src has are appended, unless they hold a zero value the
+// file can do without.
+func updateNode(dst, src *yaml.Node) {
+	switch {
+	case dst.Kind != src.Kind:
+		replaceNode(dst, src)
+
+	case dst.Kind == yaml.MappingNode:
+		content := make([]*yaml.Node, 0, len(src.Content))
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			key, value := dst.Content[i], dst.Content[i+1]
+			if j := mappingIndex(src, key.Value); j >= 0 {
+				updateNode(value, src.Content[j+1])
+				content = append(content, key, value)
+			}
+		}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			key, value := src.Content[i], src.Content[i+1]
+			if mappingIndex(dst, key.Value) < 0 && !isZeroNode(value) {
+				content = append(content, key, value)
+			}
+		}
+		dst.Content = content
+
+	case dst.Kind == yaml.SequenceNode:
+		for i, item := range src.Content {
+			if i < len(dst.Content) {
+				updateNode(dst.Content[i], item)
+			} else {
+				dst.Content = append(dst.Content, item)
+			}
+		}
+		dst.Content = dst.Content[:len(src.Content)]
+
+	case dst.Value != src.Value:
+		// A scalar keeps its style (quotes, block literal) and comments.
+		dst.Value, dst.Tag = src.Value, src.Tag
+	}
+}
+
+// unchanged reports whether data, the file Save would write in place
+// of existing, holds the same: saving would only reformat the file.
+func unchanged(existing, data []byte) bool {
+	var doc yaml.Node
+	if yaml.Unmarshal(existing, &doc) != nil {
+		return false
+	}
+	normalized, err := encodeYAML(&doc, detectIndent(existing))
+	return err == nil && bytes.Equal(normalized, data)
+}
+
+// replaceNode replaces dst with src, keeping the comments of dst.
+func replaceNode(dst, src *yaml.Node) {
+	head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+	*dst = *src
+	dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+}
+
+// isZeroNode reports whether n encodes a zero value, which decodes the
+// same as a missing key.
+func isZeroNode(n *yaml.Node) bool {
+	switch n.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(n.Content) == 0
+	case yaml.ScalarNode:
+		switch n.Tag {
+		case "!!null":
+			return true
+		case "!!bool":
+			return n.Value == "false"
+		case "!!int", "!!float":
+			return n.Value == "0"
+		case "!!str":
+			return n.Value == "" || n.Value == "0s"
+		}
+	}
+	return false
+}
+
+// detectIndent returns the indentation of a YAML file: the indentation
+// of its first indented key, or defaultIndent.
+func detectIndent(data []byte) int {
+	for line := range strings.Lines(string(data)) {
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		if indent == 0 || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") || strings.TrimSpace(trimmed) == "" {
+			continue
+		}
+		if indent >= 2 && indent <= 9 {
+			return indent
+		}
+		return defaultIndent
+	}
+	return defaultIndent
+}
+
+// backupProfile keeps data, the file at filePath before it is saved, as
+// a hidden timestamped backup next to it (.<file>.<time>.bak), and
+// removes the oldest backups beyond keep. Hidden files without a YAML
+// extension are never taken for profiles.
+func backupProfile(filePath string, data []byte, keep int) error {
+	dir, base := filepath.Split(filePath)
+	stamp := time.Now().UTC().Format(backupTimeFormat)
+	if err := createFileAtomic(filepath.Join(dir, "."+base+"."+stamp+backupSuffix), data, 0600); err != nil {
+		return err
+	}
+
+	backups, err := filepath.Glob(filepath.Join(dir, "."+base+".*"+backupSuffix))
+	if err != nil {
+		return err
+	}
+	slices.Sort(backups)
+	for _, old := range backups[:max(len(backups)-keep, 0)] {
+		_ = os.Remove(old)
+	}
+	return nil
+}
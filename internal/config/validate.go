@@ -0,0 +1,589 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/anibaldeboni/rapper/internal/web"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Problem is one thing wrong with a profile file. Line and Column
+// locate the YAML key or value it is about; they are 0 when it has no
+// place in the file.
+type Problem struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+	// Warning marks a problem the profile still loads with, e.g. an
+	// unknown key, which older versions ignored.
+	Warning bool `json:"-"`
+}
+
+// String formats the problem the way compilers do: file:line:column:
+// message.
+func (p Problem) String() string {
+	switch {
+	case p.Line == 0:
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	case p.Column == 0:
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Column, p.Message)
+}
+
+// ValidationError reports every problem found in a profile and in the
+// profiles it extends.
+type ValidationError struct {
+	File     string
+	Problems []Problem
+}
+
+// hasErrors reports whether any of problems isn't a warning, so the
+// profile can't be used.
+func hasErrors(problems []Problem) bool {
+	return slices.ContainsFunc(problems, func(p Problem) bool { return !p.Warning })
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = p.String()
+	}
+	return fmt.Sprintf("invalid config in %s: %s", e.File, strings.Join(problems, "; "))
+}
+
+// httpMethods are the methods a request or step may use.
+var httpMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "CONNECT", "TRACE"}
+
+// templateKind tells how the value at a path is parsed: as a URL or
+// header template, or as a body template, which escapes JSON.
+type templateKind int
+
+const (
+	notTemplate templateKind = iota
+	textTemplate
+	bodyTemplate
+)
+
+// templatePaths are the values parsed as templates, as dot-separated
+// paths where "*" matches any key or index.
+var templatePaths = map[string]templateKind{
+	"request.url_template":          textTemplate,
+	"request.body_template":         bodyTemplate,
+	"request.headers.*":             textTemplate,
+	"request.steps.*.url_template":  textTemplate,
+	"request.steps.*.body_template": bodyTemplate,
+	"request.steps.*.headers.*":     textTemplate,
+	"path.template":                 textTemplate,
+	"payload.template":              bodyTemplate,
+}
+
+// methodPaths are the values that name an HTTP method.
+var methodPaths = []string{"request.method", "request.steps.*.method", "path.method"}
+
+// yamlLinePattern matches the line yaml.v3 puts in its error messages.
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// templateErrorPattern matches the "template: name:line: " prefix of
+// template parse errors; the problem already names the path and line.
+var templateErrorPattern = regexp.MustCompile(`^template: .*?:\d+: `)
+
+// identPattern matches the field names a template can read as {{.name}}.
+var identPattern = regexp.MustCompile(`^[\p{L}_][\p{L}\p{Nd}_]*$`)
+
+// problemLayer is a file of the profile being validated, as written:
+// the profile itself or one of the profiles it extends.
+type problemLayer struct {
+	file string
+	doc  *yaml.Node
+}
+
+// readLayers reads the profile at filePath and the profiles it
+// extends, the profile first. readProfile has already checked the chain.
+func readLayers(filePath string) []problemLayer {
+	var layers []problemLayer
+	for filePath != "" && len(layers) < 32 {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			break
+		}
+		doc := &yaml.Node{}
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			break
+		}
+		extends := takeExtends(doc)
+		_, _ = resolveReferences(doc, nil)
+		layers = append(layers, problemLayer{file: filePath, doc: doc})
+		if extends == "" {
+			break
+		}
+		filePath = siblingProfile(filePath, extends)
+	}
+	return layers
+}
+
+// validateProfile checks the profile at filePath, which decoded to cfg
+// (merged with the profiles it extends), and returns every problem
+// found. schema is the type the file decodes to: Config, or AppConfig
+// for a legacy profile. Unknown keys and template references to values
+// that aren't CSV fields are warnings.
+func validateProfile(filePath string, cfg *Config, schema reflect.Type, semantic []error) []Problem {
+	layers := readLayers(filePath)
+
+	var problems []Problem
+	for _, err := range semantic {
+		problems = append(problems, locate(filePath, layers, err.Error()))
+	}
+
+	known := slices.Clone(cfg.CSV.Fields)
+	known = append(known, captureNames(cfg)...)
+	for _, layer := range layers {
+		problems = append(problems, checkKeys(layer.file, layer.doc, schema, "")...)
+		problems = append(problems, checkValues(layer.file, layer.doc, known)...)
+	}
+	// In the order of the files, so they read top to bottom.
+	slices.SortStableFunc(problems, func(a, b Problem) int {
+		return cmp.Or(strings.Compare(a.File, b.File), cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	return problems
+}
+
+// TemplateFields returns the names the request templates read from the
+// CSV row, in the order they first appear: every {{.name}} of the URL,
+// body and header templates and of the steps', except the values steps
+// capture. Templates that don't parse are skipped; Load reports them.
+func (c *Config) TemplateFields() []string {
+	var fields []string
+	captured := captureNames(c)
+	add := func(kind templateKind, name, text string) {
+		tmpl, err := parseTemplate(kind, name, text)
+		if err != nil {
+			return
+		}
+		for _, field := range templateFields(tmpl) {
+			if !slices.Contains(fields, field) && !slices.Contains(captured, field) {
+				fields = append(fields, field)
+			}
+		}
+	}
+	addRequest := func(path, url, body string, headers map[string]string) {
+		add(textTemplate, path+".url_template", url)
+		add(bodyTemplate, path+".body_template", body)
+		for _, name := range slices.Sorted(maps.Keys(headers)) {
+			add(textTemplate, path+".headers."+name, headers[name])
+		}
+	}
+
+	r := c.Request
+	addRequest("request", r.URLTemplate, r.BodyTemplate, r.Headers)
+	for i, s := range r.Steps {
+		addRequest("request.steps."+strconv.Itoa(i), s.URLTemplate, s.BodyTemplate, s.Headers)
+	}
+	return fields
+}
+
+// captureNames returns the names every capture of cfg stores a value
+// under, which templates can reference like CSV fields.
+func captureNames(cfg *Config) []string {
+	var names []string
+	for name := range cfg.Request.Capture {
+		names = append(names, name)
+	}
+	for _, s := range cfg.Request.Steps {
+		for name := range s.Capture {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// locate turns a semantic validation error, whose message starts with
+// the path it is about ("request.retry.jitter must be ..."), into a
+// problem placed at that path, or at the closest enclosing key present
+// in a layer when the path itself is missing.
+func locate(filePath string, layers []problemLayer, message string) Problem {
+	path := message
+	if i := strings.IndexAny(path, " :"); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+
+	for keys := strings.Split(path, "."); len(keys) > 0; keys = keys[:len(keys)-1] {
+		for _, layer := range layers {
+			if n, ok := nodeAtPath(layer.doc, keys); ok {
+				return Problem{File: layer.file, Line: n.Line, Column: n.Column, Message: message}
+			}
+		}
+	}
+	return Problem{File: filePath, Message: message}
+}
+
+// nodeAtPath returns the key node at a path of mapping keys and
+// sequence indexes, so a problem points at the key rather than the
+// value below it.
+func nodeAtPath(doc *yaml.Node, keys []string) (*yaml.Node, bool) {
+	n := rootMapping(doc)
+	if n == nil {
+		return nil, false
+	}
+	var at *yaml.Node
+	for _, key := range keys {
+		switch n.Kind {
+		case yaml.MappingNode:
+			i := mappingIndex(n, key)
+			if i < 0 {
+				return nil, false
+			}
+			at, n = n.Content[i], n.Content[i+1]
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(n.Content) {
+				return nil, false
+			}
+			at, n = n.Content[i], n.Content[i]
+		default:
+			return nil, false
+		}
+	}
+	return at, at != nil
+}
+
+// checkKeys reports the mapping keys of n that the type t it decodes to
+// doesn't have, e.g. a misspelled "metod".
+func checkKeys(file string, n *yaml.Node, t reflect.Type, path string) []Problem {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if n.Kind == yaml.DocumentNode {
+		if len(n.Content) == 0 {
+			return nil
+		}
+		return checkKeys(file, n.Content[0], t, path)
+	}
+
+	var problems []Problem
+	switch {
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				problems = append(problems, Problem{
+					File: file, Line: key.Line, Column: key.Column,
+					Message: unknownKey(key.Value, path, fields),
+					Warning: true,
+				})
+				continue
+			}
+			problems = append(problems, checkKeys(file, value, field, joinPath(path, key.Value))...)
+		}
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			problems = append(problems, checkKeys(file, n.Content[i+1], t.Elem(), joinPath(path, n.Content[i].Value))...)
+		}
+	case n.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice:
+		for i, item := range n.Content {
+			problems = append(problems, checkKeys(file, item, t.Elem(), joinPath(path, strconv.Itoa(i)))...)
+		}
+	}
+	return problems
+}
+
+// yamlFields returns the types of the fields of struct t by YAML key.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// unknownKey describes an unknown key, suggesting the known key it is
+// most likely a typo of.
+func unknownKey(key, path string, fields map[string]reflect.Type) string {
+	where := "at the top level"
+	if path != "" {
+		where = "in " + path
+	}
+	msg := fmt.Sprintf("unknown key %q %s", key, where)
+
+	best, bestDistance := "", 3
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDistance || d == bestDistance && name < best {
+			best, bestDistance = name, d
+		}
+	}
+	if best != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", best)
+	}
+	return msg
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// checkValues reports invalid HTTP methods, CSV separators and
+// templates among the values of a profile file, and, as warnings,
+// template references to values that are neither CSV fields nor
+// captured.
+func checkValues(file string, doc *yaml.Node, known []string) []Problem {
+	var problems []Problem
+	report := func(n *yaml.Node, format string, args ...any) {
+		problems = append(problems, Problem{File: file, Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(n *yaml.Node, format string, args ...any) {
+		report(n, format, args...)
+		problems[len(problems)-1].Warning = true
+	}
+
+	_ = walkScalars(doc, "", func(path string, n *yaml.Node) error {
+		switch {
+		case matchAny(path, methodPaths):
+			// A reference left unresolved, e.g. by Migrate, is checked
+			// once it loads.
+			if n.Value != "" && !slices.Contains(httpMethods, n.Value) && !referencePattern.MatchString(n.Value) {
+				report(n, "%s: invalid HTTP method %q (use one of %s)", path, n.Value, strings.Join(httpMethods, ", "))
+			}
+		case path == "csv.separator":
+			if !validSeparator(n.Value) {
+				report(n, "csv.separator: %q is not a single character other than '\"' or a line break", n.Value)
+			}
+		default:
+			kind := templateKindOf(path)
+			if kind == notTemplate {
+				return nil
+			}
+			tmpl, err := parseTemplate(kind, path, n.Value)
+			if err != nil {
+				report(n, "%s: %s", path, templateErrorPattern.ReplaceAllString(err.Error(), ""))
+				return nil
+			}
+			for _, field := range templateFields(tmpl) {
+				if !slices.Contains(known, field) {
+					warn(n, "%s: %s is not one of csv.fields", path, FieldRef(field))
+				}
+			}
+		}
+		return nil
+	})
+	return problems
+}
+
+func templateKindOf(path string) templateKind {
+	for pattern, kind := range templatePaths {
+		if matchPath(path, pattern) {
+			return kind
+		}
+	}
+	return notTemplate
+}
+
+func parseTemplate(kind templateKind, name, text string) (*template.Template, error) {
+	if kind == bodyTemplate {
+		return web.NewBodyTemplate(name, text)
+	}
+	return web.NewTemplate(name, text)
+}
+
+// validSeparator reports whether sep, trimmed of spaces like the CSV
+// reader does, is usable as a CSV separator. Empty means ",".
+func validSeparator(sep string) bool {
+	sep = strings.Trim(sep, " ")
+	if sep == "" {
+		return true
+	}
+	return len(sep) == 1 && sep != `"` && sep != "\r" && sep != "\n"
+}
+
+func matchAny(path string, patterns []string) bool {
+	return slices.ContainsFunc(patterns, func(p string) bool { return matchPath(path, p) })
+}
+
+// matchPath matches a dot-separated path against a pattern where "*"
+// matches any single key or index.
+func matchPath(path, pattern string) bool {
+	keys, patterns := strings.Split(path, "."), strings.Split(pattern, ".")
+	if len(keys) != len(patterns) {
+		return false
+	}
+	for i, p := range patterns {
+		if p != "*" && p != keys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// templateFields returns the names a template reads from the row:
+// {{.name}}, {{$.name}} and {{index . "name"}}, the form dotted keys
+// of JSON Lines files are read with, outside of range and with blocks,
+// which change what the dot is.
+func templateFields(tmpl *template.Template) []string {
+	var fields []string
+	add := func(name string) {
+		if !slices.Contains(fields, name) {
+			fields = append(fields, name)
+		}
+	}
+
+	var walk func(n parse.Node, dotIsRow bool)
+	walk = func(n parse.Node, dotIsRow bool) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c, dotIsRow)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, dotIsRow)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Cmds {
+				walk(c, dotIsRow)
+			}
+		case *parse.CommandNode:
+			if name, ok := indexedField(n, dotIsRow); ok {
+				add(name)
+			}
+			for _, a := range n.Args {
+				walk(a, dotIsRow)
+			}
+		case *parse.FieldNode:
+			if dotIsRow {
+				add(n.Ident[0])
+			}
+		case *parse.VariableNode:
+			if len(n.Ident) > 1 && n.Ident[0] == "$" {
+				add(n.Ident[1])
+			}
+		case *parse.IfNode:
+			walk(n.Pipe, dotIsRow)
+			walk(n.List, dotIsRow)
+			walk(n.ElseList, dotIsRow)
+		case *parse.RangeNode:
+			walk(n.Pipe, dotIsRow)
+			walk(n.List, false)
+			walk(n.ElseList, dotIsRow)
+		case *parse.WithNode:
+			walk(n.Pipe, dotIsRow)
+			walk(n.List, false)
+			walk(n.ElseList, dotIsRow)
+		}
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Tree.Root, true)
+		}
+	}
+	return fields
+}
+
+// FieldRef returns how a template reads the named field of the row:
+// {{.name}}, or {{index . "name"}} for a name that isn't an identifier,
+// such as the dotted keys of JSON Lines files.
+func FieldRef(name string) string {
+	if identPattern.MatchString(name) {
+		return "{{." + name + "}}"
+	}
+	return fmt.Sprintf("{{index . %q}}", name)
+}
+
+// indexedField returns the name a command reads from the row with
+// index: {{index . "name"}} or {{index $ "name"}}.
+func indexedField(n *parse.CommandNode, dotIsRow bool) (string, bool) {
+	if len(n.Args) != 3 {
+		return "", false
+	}
+	if fn, ok := n.Args[0].(*parse.IdentifierNode); !ok || fn.Ident != "index" {
+		return "", false
+	}
+	key, ok := n.Args[2].(*parse.StringNode)
+	if !ok {
+		return "", false
+	}
+	switch arg := n.Args[1].(type) {
+	case *parse.DotNode:
+		return key.Text, dotIsRow
+	case *parse.VariableNode:
+		return key.Text, len(arg.Ident) == 1 && arg.Ident[0] == "$"
+	}
+	return "", false
+}
+
+// syntaxProblem turns a YAML parse error of file into a problem at the
+// line it names.
+func syntaxProblem(file string, err error) Problem {
+	msg := err.Error()
+	if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return Problem{File: file, Line: line, Message: m[2]}
+	}
+	return Problem{File: file, Message: strings.TrimPrefix(msg, "yaml: ")}
+}
+
+// decodeProblems turns the errors of decoding file into problems at the
+// lines they name, e.g. a string where a number is expected.
+func decodeProblems(file string, err error) []Problem {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return []Problem{syntaxProblem(file, err)}
+	}
+	problems := make([]Problem, len(typeErr.Errors))
+	for i, e := range typeErr.Errors {
+		problems[i] = syntaxProblem(file, errors.New(e))
+	}
+	return problems
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
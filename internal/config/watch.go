@@ -0,0 +1,172 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// fileStamp identifies the version of a profile file on disk.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stampFile(path string) (fileStamp, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, false
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, true
+}
+
+func stampFiles(files []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(files))
+	for _, f := range files {
+		if stamp, ok := stampFile(f); ok {
+			stamps[f] = stamp
+		}
+	}
+	return stamps
+}
+
+// ReloadResult describes what a reload of the profiles directory picked
+// up from disk.
+type ReloadResult struct {
+	Added   []string
+	Removed []string
+	Changed []string
+	// ActiveChanged reports that the active profile changed on disk, or
+	// was removed and the first valid profile became active.
+	ActiveChanged bool
+	// Errors are the profiles that could not be reloaded. They are
+	// listed as broken; those that loaded before keep their previous
+	// configuration, e.g. while a file is half-written by an editor.
+	Errors []error
+}
+
+// Empty reports whether the reload found nothing to report.
+func (r ReloadResult) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0 && len(r.Errors) == 0
+}
+
+// reload re-reads the profiles directory when any of its files was
+// added, removed or modified since it was last read. Every profile is
+// reloaded, so a change to a base profile reaches the ones extending it.
+// The profiles are compared and replaced under the same lock, so an
+// update or a save made meanwhile isn't lost.
+func (pm *profileManagerImpl) reload() (ReloadResult, error) {
+	var result ReloadResult
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if pm.dir == "" {
+		return result, nil
+	}
+
+	files, err := profileFiles(pm.dir)
+	if err != nil {
+		return result, err
+	}
+	stamps := stampFiles(files)
+	if maps.Equal(stamps, pm.stamps) {
+		return result, nil
+	}
+
+	old := make(map[string]Profile, len(pm.profiles))
+	for _, p := range pm.profiles {
+		old[p.Name] = p
+	}
+
+	profiles := make([]Profile, 0, len(files))
+	extended := extendedProfiles(files)
+	for _, filePath := range files {
+		if strings.HasPrefix(filepath.Base(filePath), ".") {
+			continue
+		}
+		p, ok := pm.loadProfile(filePath, extended)
+		prev, known := old[p.Name]
+		if !ok && !known {
+			continue
+		}
+
+		if p.Err != nil {
+			if !known || prev.Err == nil || prev.Err.Error() != p.Err.Error() {
+				result.Errors = append(result.Errors, p.Err)
+			}
+			if known {
+				p.Config = prev.Config
+			}
+		}
+
+		switch {
+		case !known:
+			result.Added = append(result.Added, p.Name)
+		case !reflect.DeepEqual(prev.Config, p.Config):
+			result.Changed = append(result.Changed, p.Name)
+		}
+		profiles = append(profiles, p)
+	}
+
+	for name := range old {
+		if !slices.ContainsFunc(profiles, func(p Profile) bool { return p.Name == name }) {
+			result.Removed = append(result.Removed, name)
+		}
+	}
+	slices.Sort(result.Removed)
+
+	pm.stamps = stamps
+	if !slices.ContainsFunc(profiles, Profile.usable) {
+		// Keep the profiles in memory rather than leave none active.
+		return result, errors.New("no valid config files found")
+	}
+
+	active := ""
+	if pm.activeIndex >= 0 && pm.activeIndex < len(pm.profiles) {
+		active = pm.profiles[pm.activeIndex].Name
+	}
+	pm.profiles = profiles
+	pm.activeIndex = slices.IndexFunc(profiles, func(p Profile) bool { return p.Name == active && p.usable() })
+	if pm.activeIndex < 0 {
+		pm.activeIndex = slices.IndexFunc(profiles, Profile.usable)
+		result.ActiveChanged = true
+	}
+	result.ActiveChanged = result.ActiveChanged || slices.Contains(result.Changed, active)
+
+	return result, nil
+}
+
+// Reload picks up the profiles added, removed or modified on disk since
+// they were last read. When the active profile changed, the OnChange
+// listeners are notified with its new configuration.
+func (m *managerImpl) Reload() (ReloadResult, error) {
+	result, err := m.profileMgr.reload()
+	if result.ActiveChanged {
+		m.notify(m.Get())
+	}
+	return result, err
+}
+
+// Watch polls the profiles directory every interval until ctx is done,
+// reloading it on changes. onReload is called with every reload that
+// found something to report, or failed.
+func (m *managerImpl) Watch(ctx context.Context, interval time.Duration, onReload func(ReloadResult, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if result, err := m.Reload(); err != nil || !result.Empty() {
+				onReload(result, err)
+			}
+		}
+	}
+}
@@ -20,11 +20,13 @@ type Line interface {
 // Logger is the in-memory + on-disk log sink. Processors call
 // logger.Add(LogMessage) for every event they want to surface; the
 // TUI polls logger.Get() to render the list. WriteToFile streams
-// per-request records (RequestLine) to the on-disk log file.
+// per-request records (RequestLine) to the on-disk log file. Secrets
+// set with SetSecrets are masked in both.
 type logger struct {
 	file *os.File
 	sync.Mutex
 	messages []LogMessage
+	redactor Redactor
 }
 
 func NewLogger(filePath string) *logger {
@@ -39,8 +41,15 @@ func NewLogger(filePath string) *logger {
 	return &l
 }
 
+// SetSecrets replaces the values masked in the messages and lines
+// written from now on.
+func (l *logger) SetSecrets(secrets []string) {
+	l.redactor.SetSecrets(secrets)
+}
+
 // Add appends a log message to the in-memory buffer.
 func (l *logger) Add(log LogMessage) {
+	log = l.redactor.Message(log)
 	l.Lock()
 	defer l.Unlock()
 	l.messages = append(l.messages, log)
@@ -70,14 +79,14 @@ func (l *logger) Clear() {
 // in-memory buffer.
 func (l *logger) WriteToFile(line Line) {
 	if l.file != nil {
-		if err := write(l.file, line); err != nil {
+		if err := write(l.file, l.redactor.String(string(line.Bytes()))); err != nil {
 			l.Add(NewMessage("Error writing log to file", WithDetail(err.Error()), WithIcon(styles.IconWarning), AsWarning()))
 		}
 	}
 }
 
-func write(file *os.File, line Line) error {
-	if _, err := file.Write(append(line.Bytes(), '\n')); err != nil {
+func write(file *os.File, line string) error {
+	if _, err := file.WriteString(line + "\n"); err != nil {
 		return fmt.Errorf("error writing to file: %w", err)
 	}
 	return nil
@@ -132,3 +132,24 @@ func TestWriteToFile(t *testing.T) {
 	// Assert that the logged message matches the sent log message
 	assert.Equal(t, &line, &loggedMessage)
 }
+
+func TestSetSecrets(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test_output_*.txt")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	logger := logs.NewLogger(tmpFile.Name())
+	logger.SetSecrets([]string{"tok\"en", "s3cr3t"})
+
+	logger.Add(logs.NewMessage("Bearer s3cr3t", logs.WithDetail(`{"token": "tok\"en"}`)))
+	logger.WriteToFile(testLog{Message: `tok\"en`})
+
+	got := logger.Get()
+	require.Len(t, got, 1)
+	assert.Equal(t, "Bearer ****", got[0].Text)
+	assert.Equal(t, `{"token": "****"}`, got[0].Details)
+
+	written, err := os.ReadFile(tmpFile.Name())
+	require.NoError(t, err)
+	assert.Equal(t, `{"message":"****"}`+"\n", string(written))
+}
@@ -0,0 +1,64 @@
+package logs
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secret values in log messages and output lines.
+const Redacted = "****"
+
+// Redactor masks secret values, e.g. the tokens a profile resolves
+// from environment variables, before they reach the TUI, the terminal
+// or the output file. The zero value masks nothing.
+type Redactor struct {
+	mu       sync.RWMutex
+	replacer *strings.Replacer
+}
+
+// SetSecrets replaces the values to mask. Both the values and their
+// JSON-escaped forms are masked, so secrets inside output lines are
+// caught too.
+func (r *Redactor) SetSecrets(secrets []string) {
+	// Longer secrets first, so one containing another is masked whole.
+	secrets = slices.Clone(secrets)
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+
+	var pairs []string
+	for _, s := range secrets {
+		if s == "" {
+			continue
+		}
+		pairs = append(pairs, s, Redacted)
+		if escaped, _ := json.Marshal(s); string(escaped[1:len(escaped)-1]) != s {
+			pairs = append(pairs, string(escaped[1:len(escaped)-1]), Redacted)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(pairs) == 0 {
+		r.replacer = nil
+		return
+	}
+	r.replacer = strings.NewReplacer(pairs...)
+}
+
+// String returns s with every secret masked.
+func (r *Redactor) String(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.replacer == nil {
+		return s
+	}
+	return r.replacer.Replace(s)
+}
+
+// Message returns msg with every secret in its text and details masked.
+func (r *Redactor) Message(msg LogMessage) LogMessage {
+	msg.Text = r.String(msg.Text)
+	msg.Details = r.String(msg.Details)
+	return msg
+}
@@ -0,0 +1,184 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/web"
+)
+
+// assertions decide whether a response counts as successful. They are
+// built from the profile's request.assertions list by newAssertions;
+// without a status assertion any 2xx status is expected.
+type assertions struct {
+	status []int
+	checks []assertion
+}
+
+// assertion is a single compiled check. It returns why res failed it,
+// or "" when it passed.
+type assertion func(res web.Response) string
+
+func newAssertions(cfg []config.Assertion) assertions {
+	var a assertions
+	for _, c := range cfg {
+		switch {
+		case len(c.Status) > 0:
+			a.status = append(a.status, c.Status...)
+		case c.JSONPath != "":
+			a.checks = append(a.checks, jsonPathAssertion(c))
+		case c.Header != "":
+			a.checks = append(a.checks, headerAssertion(c))
+		case c.MaxLatency > 0:
+			a.checks = append(a.checks, latencyAssertion(c.MaxLatency))
+		}
+	}
+	return a
+}
+
+// failure returns the first assertion res fails, or "" when it passes
+// them all. The status is checked first, so a server error isn't
+// reported as a body that didn't match.
+func (a assertions) failure(res web.Response) string {
+	if len(a.status) > 0 {
+		if !slices.Contains(a.status, res.StatusCode) {
+			return fmt.Sprintf("status %d not in %v", res.StatusCode, a.status)
+		}
+	} else if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Sprintf("status %d is not 2xx", res.StatusCode)
+	}
+
+	for _, c := range a.checks {
+		if reason := c(res); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// configured reports whether the profile declares any assertion. When
+// it doesn't, failures are the plain non-2xx responses they always were.
+func (a assertions) configured() bool {
+	return len(a.status) > 0 || len(a.checks) > 0
+}
+
+func jsonPathAssertion(c config.Assertion) assertion {
+	re, reErr := compileMatches(c.Matches)
+	wantExists := c.Exists == nil || *c.Exists
+
+	var want any
+	if c.Equals != nil {
+		// Round-trip through JSON so YAML scalars compare like the
+		// decoded body: numbers as float64, maps with string keys.
+		b, _ := json.Marshal(c.Equals)
+		_ = json.Unmarshal(b, &want)
+	}
+
+	return func(res web.Response) string {
+		var body any
+		if err := json.Unmarshal(res.Body, &body); err != nil {
+			return fmt.Sprintf("json_path %q: body is not JSON", c.JSONPath)
+		}
+		got, found := lookupJSONPath(body, c.JSONPath)
+
+		switch {
+		case !wantExists && found:
+			return fmt.Sprintf("json_path %q: expected no value, got %s", c.JSONPath, jsonText(got))
+		case !wantExists:
+			return ""
+		case !found:
+			return fmt.Sprintf("json_path %q: not found", c.JSONPath)
+		case c.Equals != nil && !reflect.DeepEqual(got, want):
+			return fmt.Sprintf("json_path %q: got %s, want %s", c.JSONPath, jsonText(got), jsonText(want))
+		case reErr != nil:
+			return fmt.Sprintf("json_path %q: %v", c.JSONPath, reErr)
+		case re != nil && !re.MatchString(valueText(got)):
+			return fmt.Sprintf("json_path %q: %s does not match %q", c.JSONPath, jsonText(got), c.Matches)
+		}
+		return ""
+	}
+}
+
+func headerAssertion(c config.Assertion) assertion {
+	re, reErr := compileMatches(c.Matches)
+
+	return func(res web.Response) string {
+		values := res.Headers.Values(c.Header)
+		switch {
+		case len(values) == 0:
+			return fmt.Sprintf("header %q: missing", c.Header)
+		case reErr != nil:
+			return fmt.Sprintf("header %q: %v", c.Header, reErr)
+		case re != nil && !slices.ContainsFunc(values, re.MatchString):
+			return fmt.Sprintf("header %q: %q does not match %q", c.Header, values[0], c.Matches)
+		}
+		return ""
+	}
+}
+
+func latencyAssertion(limit time.Duration) assertion {
+	return func(res web.Response) string {
+		if res.Latency > limit {
+			return fmt.Sprintf("latency %s exceeds %s", res.Latency.Round(time.Millisecond), limit)
+		}
+		return ""
+	}
+}
+
+// compileMatches compiles an optional pattern. Profiles are validated
+// when loaded, so an error here only surfaces as a failing assertion.
+func compileMatches(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// lookupJSONPath walks a decoded JSON value along a dot-separated path.
+// Numeric segments index arrays and "#" is the length of an array.
+func lookupJSONPath(v any, path string) (any, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			if key == "#" {
+				v = float64(len(node))
+				continue
+			}
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// valueText is what Matches is applied to: strings as they are, any
+// other value as JSON.
+func valueText(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return jsonText(v)
+}
+
+func jsonText(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
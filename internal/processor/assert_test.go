@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/logs"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAssertions_Failure(t *testing.T) {
+	notExists := false
+	res := web.Response{
+		StatusCode: 200,
+		Headers:    http.Header{"Content-Type": {"application/json"}},
+		Body:       []byte(`{"ok":false,"data":{"id":7,"tags":["a","b"]},"code":"E100"}`),
+		Latency:    300 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name   string
+		cfg    []config.Assertion
+		reason string
+	}{
+		{name: "no assertions accept any 2xx", cfg: nil},
+		{name: "status set", cfg: []config.Assertion{{Status: []int{201, 204}}}, reason: "status 200 not in [201 204]"},
+		{name: "json path equals", cfg: []config.Assertion{{JSONPath: "ok", Equals: true}}, reason: `json_path "ok": got false, want true`},
+		{name: "json path number", cfg: []config.Assertion{{JSONPath: "data.id", Equals: 7}}},
+		{name: "json path array length", cfg: []config.Assertion{{JSONPath: "data.tags.#", Equals: 2}}},
+		{name: "json path index", cfg: []config.Assertion{{JSONPath: "data.tags.1", Equals: "b"}}},
+		{name: "json path missing", cfg: []config.Assertion{{JSONPath: "data.name"}}, reason: `json_path "data.name": not found`},
+		{name: "json path must not exist", cfg: []config.Assertion{{JSONPath: "code", Exists: &notExists}}, reason: `json_path "code": expected no value, got "E100"`},
+		{name: "json path matches", cfg: []config.Assertion{{JSONPath: "code", Matches: "^E2"}}, reason: `json_path "code": "E100" does not match "^E2"`},
+		{name: "header present", cfg: []config.Assertion{{Header: "X-Request-Id"}}, reason: `header "X-Request-Id": missing`},
+		{name: "header matches", cfg: []config.Assertion{{Header: "content-type", Matches: "^application/json"}}},
+		{name: "max latency", cfg: []config.Assertion{{MaxLatency: 200 * time.Millisecond}}, reason: "latency 300ms exceeds 200ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.reason, newAssertions(tt.cfg).failure(res))
+		})
+	}
+
+	t.Run("Should check the status before the body", func(t *testing.T) {
+		a := newAssertions([]config.Assertion{{JSONPath: "ok", Equals: true}})
+		assert.Equal(t, "status 500 is not 2xx", a.failure(web.Response{StatusCode: 500, Body: []byte("oops")}))
+	})
+
+	t.Run("Should accept the statuses listed instead of 2xx", func(t *testing.T) {
+		a := newAssertions([]config.Assertion{{Status: []int{404}}})
+		assert.Empty(t, a.failure(web.Response{StatusCode: 404}))
+	})
+}
+
+// TestProcessor_Do_AssertionFailures proves a 2xx response failing an
+// assertion is counted as an error, logged with the assertion and
+// written to the output file with the reason.
+func TestProcessor_Do_AssertionFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id\n1\n2\n"), 0o600))
+
+	p, gatewayMock, loggerMock := newTestProcessor(t, config.CSVConfig{Fields: []string{"id"}, Separator: ","}, 1)
+	p.UpdateRequestConfig(config.RequestConfig{
+		Assertions: []config.Assertion{{JSONPath: "ok", Equals: true}},
+	})
+
+	gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, row map[string]string) (web.Response, error) {
+			body := `{"ok":true}`
+			if row["id"] == "2" {
+				body = `{"ok":false}`
+			}
+			return web.Response{Method: "POST", URL: "https://api/" + row["id"], StatusCode: 200, Body: []byte(body)}, nil
+		}).Times(2)
+
+	var (
+		mu     sync.Mutex
+		errs   []logs.LogMessage
+		output []*RequestLine
+	)
+	loggerMock.EXPECT().Add(gomock.Any()).DoAndReturn(func(m logs.LogMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		if m.Type == logs.LogTypeError {
+			errs = append(errs, m)
+		}
+	}).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).DoAndReturn(func(l logs.Line) {
+		mu.Lock()
+		defer mu.Unlock()
+		output = append(output, l.(*RequestLine))
+	}).Times(2)
+
+	p.Do(context.Background(), path)
+	summary := p.Wait()
+
+	assert.Equal(t, uint64(1), summary.ErrorRequests)
+	require.Len(t, errs, 1)
+	assert.Equal(t, `POST https://api/2 failed assertion: json_path "ok": got false, want true`, errs[0].Text)
+
+	reasons := map[string]string{}
+	for _, line := range output {
+		reasons[line.URL] = line.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"https://api/1": "",
+		"https://api/2": `json_path "ok": got false, want true`,
+	}, reasons)
+}
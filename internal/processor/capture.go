@@ -0,0 +1,105 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/web"
+)
+
+// captures extract values from responses, built from the profile's
+// request.capture map by newCaptures.
+type captures struct {
+	names    []string // sorted, the order of the results CSV columns
+	extracts map[string]extractor
+}
+
+// extractor returns the value it extracts from res, if res has one.
+type extractor func(res web.Response) (string, bool)
+
+func newCaptures(cfg map[string]config.Capture) captures {
+	c := captures{
+		names:    slices.Sorted(maps.Keys(cfg)),
+		extracts: make(map[string]extractor, len(cfg)),
+	}
+	for name, capture := range cfg {
+		switch {
+		case capture.JSONPath != "":
+			c.extracts[name] = jsonPathExtractor(capture.JSONPath)
+		case capture.Header != "":
+			c.extracts[name] = headerExtractor(capture.Header)
+		case capture.Regex != "":
+			c.extracts[name] = regexExtractor(capture.Regex)
+		}
+	}
+	return c
+}
+
+// extract returns the values res has for the captures, or nil when it
+// has none.
+func (c captures) extract(res web.Response) map[string]string {
+	var values map[string]string
+	for name, extract := range c.extracts {
+		if v, ok := extract(res); ok {
+			if values == nil {
+				values = make(map[string]string, len(c.extracts))
+			}
+			values[name] = v
+		}
+	}
+	return values
+}
+
+func jsonPathExtractor(path string) extractor {
+	return func(res web.Response) (string, bool) {
+		// Numbers are kept as written so long IDs don't lose digits
+		// on their way through float64.
+		var body any
+		dec := json.NewDecoder(bytes.NewReader(res.Body))
+		dec.UseNumber()
+		if err := dec.Decode(&body); err != nil {
+			return "", false
+		}
+		v, found := lookupJSONPath(body, path)
+		if !found {
+			return "", false
+		}
+		return valueText(v), true
+	}
+}
+
+func headerExtractor(name string) extractor {
+	return func(res web.Response) (string, bool) {
+		values := res.Headers.Values(name)
+		if len(values) == 0 {
+			return "", false
+		}
+		return values[0], true
+	}
+}
+
+// regexExtractor returns the first capture group of the first match,
+// or the whole match when the pattern has no group. Profiles are
+// validated when loaded, so a pattern that doesn't compile captures
+// nothing.
+func regexExtractor(pattern string) extractor {
+	re, err := regexp.Compile(pattern)
+	return func(res web.Response) (string, bool) {
+		if err != nil {
+			return "", false
+		}
+		m := re.FindSubmatch(res.Body)
+		switch {
+		case m == nil:
+			return "", false
+		case len(m) > 1:
+			return string(m[1]), true
+		default:
+			return string(m[0]), true
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package processor
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/logs"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCaptures_Extract(t *testing.T) {
+	c := newCaptures(map[string]config.Capture{
+		"id":       {JSONPath: "data.id"},
+		"tags":     {JSONPath: "data.tags"},
+		"location": {Header: "Location"},
+		"token":    {Regex: `"token":"(\w+)"`},
+		"missing":  {JSONPath: "data.name"},
+	})
+	res := web.Response{
+		Headers: http.Header{"Location": {"/users/9007199254740993"}},
+		Body:    []byte(`{"data":{"id":9007199254740993,"tags":["a"]},"token":"abc"}`),
+	}
+
+	assert.Equal(t, []string{"id", "location", "missing", "tags", "token"}, c.names)
+	assert.Equal(t, map[string]string{
+		"id":       "9007199254740993",
+		"tags":     `["a"]`,
+		"location": "/users/9007199254740993",
+		"token":    "abc",
+	}, c.extract(res))
+	assert.Nil(t, c.extract(web.Response{Body: []byte("not json")}))
+}
+
+// TestProcessor_Do_WritesResults proves the captured values reach both
+// the output file and the results CSV, which joins them with every
+// column of the source rows.
+func TestProcessor_Do_WritesResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(path, []byte("name,email\nana,a@x\nbob,b@x\n"), 0o600))
+
+	csvCfg := config.CSVConfig{
+		Fields:    []string{"name"},
+		Separator: ",",
+		Results:   config.ResultsConfig{Enabled: true, StatusColumn: true},
+	}
+	p, gatewayMock, loggerMock := newTestProcessor(t, csvCfg, 1)
+	p.UpdateRequestConfig(config.RequestConfig{
+		Capture: map[string]config.Capture{"id": {JSONPath: "id"}},
+	})
+
+	gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, row map[string]string) (web.Response, error) {
+			if row["name"] == "bob" {
+				return web.Response{URL: "https://api/bob", StatusCode: 409, Body: []byte(`{"error":"taken"}`)}, nil
+			}
+			return web.Response{URL: "https://api/ana", StatusCode: 201, Body: []byte(`{"id":41}`)}, nil
+		}).Times(2)
+	loggerMock.EXPECT().Add(gomock.Any()).AnyTimes()
+
+	var (
+		mu       sync.Mutex
+		captured = map[string]map[string]string{}
+	)
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).DoAndReturn(func(l logs.Line) {
+		mu.Lock()
+		defer mu.Unlock()
+		line := l.(*RequestLine)
+		captured[line.URL] = line.Captured
+	}).Times(2)
+
+	p.Do(context.Background(), path)
+	p.Wait()
+
+	assert.Equal(t, map[string]map[string]string{
+		"https://api/ana": {"id": "41"},
+		"https://api/bob": nil,
+	}, captured)
+
+	data, err := os.ReadFile(ResultsPath(path))
+	require.NoError(t, err)
+	assert.Equal(t, "name,email,id,_status\nana,a@x,41,201\nbob,b@x,,409\n", string(data))
+}
@@ -0,0 +1,145 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/web"
+)
+
+// chainStep is one of the requests sent for every row: the gateway
+// that renders and sends it, the assertions its response must pass and
+// the values it captures for the steps after it. A profile without
+// request.steps is a chain of a single unnamed step.
+type chainStep struct {
+	name       string
+	gateway    HttpGateway
+	assertions assertions
+	captures   captures
+}
+
+// chainResult is the outcome of sending a row through the chain: the
+// response of the last step sent and everything captured up to it.
+// failed is the step that stopped the chain, nil when every step
+// passed; reason is the assertion it failed, if it got a response.
+type chainResult struct {
+	res      web.Response
+	err      error
+	reason   string
+	failed   *chainStep
+	captured map[string]string
+}
+
+// newGatewayFunc builds the gateway of a step. It is a field of the
+// processor so tests can swap in mocks.
+type newGatewayFunc func(method, urlTemplate, bodyTemplate string, headers map[string]string) (HttpGateway, error)
+
+func newWebGateway(method, urlTemplate, bodyTemplate string, headers map[string]string) (HttpGateway, error) {
+	return web.NewHttpGateway(method, urlTemplate, bodyTemplate, headers)
+}
+
+// buildChain returns the steps cfg describes. Without request.steps the
+// chain is the processor's own gateway with the request-level
+// assertions and captures. Request-level headers are sent by every
+// step, unless the step sets the same header.
+func (p *processorImpl) buildChain(cfg config.RequestConfig) ([]chainStep, error) {
+	if len(cfg.Steps) == 0 {
+		return []chainStep{{
+			gateway:    p.gateway,
+			assertions: newAssertions(cfg.Assertions),
+			captures:   newCaptures(cfg.Capture),
+		}}, nil
+	}
+
+	steps := make([]chainStep, len(cfg.Steps))
+	for i, s := range cfg.Steps {
+		name := s.Name
+		if name == "" {
+			name = fmt.Sprintf("step %d", i+1)
+		}
+
+		headers := maps.Clone(cfg.Headers)
+		if headers == nil {
+			headers = make(map[string]string, len(s.Headers))
+		}
+		maps.Copy(headers, s.Headers)
+
+		gateway, err := p.newGateway(s.Method, s.URLTemplate, s.BodyTemplate, headers)
+		if err != nil {
+			return nil, fmt.Errorf("request.steps %q: %w", name, err)
+		}
+		steps[i] = chainStep{
+			name:       name,
+			gateway:    gateway,
+			assertions: newAssertions(s.Assertions),
+			captures:   newCaptures(s.Capture),
+		}
+	}
+	return steps, nil
+}
+
+// captureNames returns the names captured by every step of the chain,
+// sorted; they are the capture columns of the results CSV.
+func captureNames(steps []chainStep) []string {
+	var names []string
+	for _, s := range steps {
+		names = append(names, s.captures.names...)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// execChain sends the steps for row in order. Every step renders its
+// templates against the row plus the values captured by the steps
+// before it. The chain stops at the first step that fails to connect
+// or fails one of its assertions.
+func (p *processorImpl) execChain(ctx context.Context, steps []chainStep, row csvLineMap) chainResult {
+	var r chainResult
+	vars := row
+	for i := range steps {
+		step := &steps[i]
+		r.res, r.err = p.exec(ctx, step.gateway, vars)
+		if r.err != nil {
+			r.failed = step
+			return r
+		}
+
+		values := step.captures.extract(r.res)
+		if len(values) > 0 {
+			if r.captured == nil {
+				r.captured = make(map[string]string, len(values))
+			}
+			maps.Copy(r.captured, values)
+		}
+
+		if r.reason = step.assertions.failure(r.res); r.reason != "" {
+			r.failed = step
+			return r
+		}
+
+		if len(values) > 0 && i < len(steps)-1 {
+			vars = maps.Clone(vars)
+			maps.Copy(vars, values)
+		}
+	}
+	return r
+}
+
+// dryRunVars returns the variables the step at index renders with in a
+// dry run: the row plus a placeholder for every value captured by the
+// steps before it, since none of them is sent.
+func dryRunVars(steps []chainStep, index int, row csvLineMap) csvLineMap {
+	if index == 0 {
+		return row
+	}
+	vars := maps.Clone(row)
+	for _, s := range steps[:index] {
+		for _, name := range s.captures.names {
+			vars[name] = "<" + name + " from " + s.name + ">"
+		}
+	}
+	return vars
+}
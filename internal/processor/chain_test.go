@@ -0,0 +1,134 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/logs"
+	mock_processor "github.com/anibaldeboni/rapper/internal/processor/mock"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// newChainProcessor returns a processor whose request.steps are sent
+// through the given gateways, in order, and records the headers every
+// step gateway was built with.
+func newChainProcessor(t *testing.T, path string, steps []config.StepConfig, gateways ...*mock_processor.MockHttpGateway) (*processorImpl, *mock_processor.MockRequestLogger, []map[string]string) {
+	t.Helper()
+	p, _, loggerMock := newTestProcessor(t, config.CSVConfig{Fields: []string{"email"}, Separator: ","}, 1)
+
+	var headers []map[string]string
+	p.newGateway = func(_, _, _ string, h map[string]string) (HttpGateway, error) {
+		headers = append(headers, h)
+		return gateways[len(headers)-1], nil
+	}
+	require.NoError(t, p.UpdateRequestConfig(config.RequestConfig{
+		Headers: map[string]string{"Authorization": "Bearer t", "Accept": "*/*"},
+		Steps:   steps,
+	}))
+	require.NoError(t, os.WriteFile(path, []byte("email\nana@x\n"), 0o600))
+	return p, loggerMock, headers
+}
+
+func TestProcessor_Do_Chain(t *testing.T) {
+	steps := []config.StepConfig{
+		{
+			Name:        "lookup",
+			Method:      "GET",
+			URLTemplate: "https://api/users?email={{.email}}",
+			Headers:     map[string]string{"Accept": "application/json"},
+			Capture:     map[string]config.Capture{"id": {JSONPath: "id"}},
+		},
+		{Method: "PUT", URLTemplate: "https://api/users/{{.id}}"},
+	}
+
+	t.Run("Should pass the values captured by a step to the next one", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		lookup, update := mock_processor.NewMockHttpGateway(ctrl), mock_processor.NewMockHttpGateway(ctrl)
+		path := filepath.Join(t.TempDir(), "users.csv")
+		p, loggerMock, headers := newChainProcessor(t, path, steps, lookup, update)
+
+		assert.Equal(t, []map[string]string{
+			{"Authorization": "Bearer t", "Accept": "application/json"},
+			{"Authorization": "Bearer t", "Accept": "*/*"},
+		}, headers)
+
+		lookup.EXPECT().Exec(gomock.Any(), map[string]string{"email": "ana@x"}).
+			Return(web.Response{StatusCode: 200, Body: []byte(`{"id":42}`)}, nil)
+		update.EXPECT().Exec(gomock.Any(), map[string]string{"email": "ana@x", "id": "42"}).
+			Return(web.Response{Method: "PUT", URL: "https://api/users/42", StatusCode: 204}, nil)
+		loggerMock.EXPECT().Add(gomock.Any()).AnyTimes()
+		loggerMock.EXPECT().WriteToFile(&RequestLine{
+			Method:   "PUT",
+			URL:      "https://api/users/42",
+			Status:   204,
+			Captured: map[string]string{"id": "42"},
+		})
+
+		p.Do(context.Background(), path)
+		summary := p.Wait()
+		assert.Zero(t, summary.ErrorRequests)
+	})
+
+	t.Run("Should stop at the first failing step and name it", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		lookup, update := mock_processor.NewMockHttpGateway(ctrl), mock_processor.NewMockHttpGateway(ctrl)
+		path := filepath.Join(t.TempDir(), "users.csv")
+		p, loggerMock, _ := newChainProcessor(t, path, steps, lookup, update)
+
+		lookup.EXPECT().Exec(gomock.Any(), gomock.Any()).
+			Return(web.Response{Method: "GET", URL: "https://api/users?email=ana@x", StatusCode: 404}, nil)
+
+		var (
+			mu   sync.Mutex
+			errs []logs.LogMessage
+		)
+		loggerMock.EXPECT().Add(gomock.Any()).DoAndReturn(func(m logs.LogMessage) {
+			mu.Lock()
+			defer mu.Unlock()
+			if m.Type == logs.LogTypeWarning {
+				errs = append(errs, m)
+			}
+		}).AnyTimes()
+		loggerMock.EXPECT().WriteToFile(&RequestLine{
+			Method: "GET",
+			URL:    "https://api/users?email=ana@x",
+			Status: 404,
+			Reason: "status 404 is not 2xx",
+			Step:   "lookup",
+		})
+
+		p.Do(context.Background(), path)
+		summary := p.Wait()
+
+		assert.Equal(t, uint64(1), summary.ErrorRequests)
+		require.Len(t, errs, 1)
+		assert.Equal(t, "[lookup] GET https://api/users?email=ana@x", errs[0].Text)
+	})
+}
+
+func TestProcessor_Do_ChainDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	lookup, update := mock_processor.NewMockHttpGateway(ctrl), mock_processor.NewMockHttpGateway(ctrl)
+	path := filepath.Join(t.TempDir(), "users.csv")
+	p, loggerMock, _ := newChainProcessor(t, path, []config.StepConfig{
+		{Name: "lookup", Method: "GET", URLTemplate: "x", Capture: map[string]config.Capture{"id": {JSONPath: "id"}}},
+		{Name: "update", Method: "PUT", URLTemplate: "y"},
+	}, lookup, update)
+	p.SetDryRun(true)
+
+	lookup.EXPECT().Render(map[string]string{"email": "ana@x"}).Return(web.Request{Method: "GET"})
+	update.EXPECT().Render(map[string]string{"email": "ana@x", "id": "<id from lookup>"}).Return(web.Request{Method: "PUT"})
+	loggerMock.EXPECT().Add(gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).Times(2)
+
+	p.Do(context.Background(), path)
+	summary := p.Wait()
+	assert.Equal(t, uint64(1), summary.TotalRequests)
+}
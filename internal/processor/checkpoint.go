@@ -0,0 +1,244 @@
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// CheckpointExt is appended to the CSV path to name its checkpoint.
+	CheckpointExt = ".checkpoint"
+
+	// checkpointInterval is how often a run in progress persists its
+	// checkpoint, bounding how much is re-sent after a crash.
+	checkpointInterval = time.Second
+
+	// fingerprintSize is how much of the CSV is hashed to recognise it.
+	// Together with the size it tells an edited file from the one the
+	// checkpoint was taken for without reading millions of lines.
+	fingerprintSize = 64 << 10
+)
+
+// Checkpoint records how far a run got through a CSV file. Lines are
+// data rows numbered from 1, the header excluded. Workers finish rows
+// out of order, so besides the last contiguous completed line it keeps
+// the highest line handed to a worker and the lines still in flight:
+// every line up to Dispatched that is not in InFlight was completed.
+type Checkpoint struct {
+	File       string    `json:"file"`
+	Size       int64     `json:"size"`
+	Hash       string    `json:"hash"`
+	Completed  uint64    `json:"completed"`
+	Dispatched uint64    `json:"dispatched"`
+	InFlight   []uint64  `json:"in_flight,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// done reports whether line was completed by the checkpointed run.
+func (c Checkpoint) done(line uint64) bool {
+	if line <= c.Completed {
+		return true
+	}
+	return line <= c.Dispatched && !slices.Contains(c.InFlight, line)
+}
+
+func checkpointPath(filePath string) string {
+	return filePath + CheckpointExt
+}
+
+// fingerprint returns the size and a hash of the head of the file.
+func fingerprint(filePath string) (int64, string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, fingerprintSize); err != nil && !errors.Is(err, io.EOF) {
+		return 0, "", err
+	}
+	return info.Size(), hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// LoadCheckpoint reads the checkpoint saved next to filePath. It fails
+// when there is none or when the file changed since it was taken.
+func LoadCheckpoint(filePath string) (Checkpoint, error) {
+	data, err := os.ReadFile(checkpointPath(filePath))
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return Checkpoint{}, fmt.Errorf("invalid checkpoint: %w", err)
+	}
+
+	size, hash, err := fingerprint(filePath)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	if size != cp.Size || hash != cp.Hash {
+		return Checkpoint{}, errors.New("file changed since the checkpoint was saved")
+	}
+	return cp, nil
+}
+
+// saveCheckpoint writes cp next to filePath through a temporary file,
+// so a crash while saving leaves the previous checkpoint rather than a
+// truncated one.
+func saveCheckpoint(filePath string, cp Checkpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := checkpointPath(filePath)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err = errors.Join(err, tmp.Close()); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// RemoveCheckpoint deletes the checkpoint saved next to filePath, if
+// any.
+func RemoveCheckpoint(filePath string) error {
+	err := os.Remove(checkpointPath(filePath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// progressTracker follows which lines of a run were completed. Lines
+// are started by the CSV reader as they are queued and finished by the
+// workers in any order; finished lines past the first gap are parked
+// until the gap closes so Completed only ever covers a contiguous
+// prefix.
+type progressTracker struct {
+	mu         sync.Mutex
+	base       Checkpoint
+	completed  uint64
+	dispatched uint64
+	finished   map[uint64]struct{}
+	inFlight   map[uint64]struct{}
+	eof        bool
+	dirty      bool
+}
+
+// newProgressTracker starts tracking from base. Lines base left in
+// flight stay in flight until the reader queues them again, so a
+// snapshot taken before then still lists them.
+func newProgressTracker(base Checkpoint) *progressTracker {
+	t := &progressTracker{
+		base:       base,
+		completed:  base.Completed,
+		dispatched: base.Dispatched,
+		finished:   make(map[uint64]struct{}),
+		inFlight:   make(map[uint64]struct{}, len(base.InFlight)),
+	}
+	for _, line := range base.InFlight {
+		t.inFlight[line] = struct{}{}
+	}
+	return t
+}
+
+// start marks line as queued for a worker.
+func (t *progressTracker) start(line uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inFlight[line] = struct{}{}
+	t.dispatched = max(t.dispatched, line)
+	t.dirty = true
+}
+
+// finish marks line as completed. Lines that are never sent (already
+// done in a resumed checkpoint, unreadable records) are finished
+// without being started. Lines the completed prefix already covers,
+// e.g. those skipped on resume, aren't parked.
+func (t *progressTracker) finish(line uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.inFlight, line)
+	t.dirty = true
+	if line <= t.completed {
+		return
+	}
+	if line != t.completed+1 {
+		t.finished[line] = struct{}{}
+		return
+	}
+	t.completed++
+	for {
+		if _, ok := t.finished[t.completed+1]; !ok {
+			break
+		}
+		delete(t.finished, t.completed+1)
+		t.completed++
+	}
+}
+
+// reachedEOF records that the reader queued every line of the file.
+func (t *progressTracker) reachedEOF() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.eof = true
+}
+
+// complete reports whether every line of the file was completed.
+func (t *progressTracker) complete() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.eof && len(t.inFlight) == 0
+}
+
+// snapshot returns the checkpoint for the current progress and whether
+// it changed since the previous snapshot.
+func (t *progressTracker) snapshot() (Checkpoint, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cp := t.base
+	cp.Completed = t.completed
+	cp.Dispatched = t.dispatched
+	cp.InFlight = make([]uint64, 0, len(t.inFlight))
+	for line := range t.inFlight {
+		cp.InFlight = append(cp.InFlight, line)
+	}
+	slices.Sort(cp.InFlight)
+	cp.UpdatedAt = time.Now()
+
+	changed := t.dirty
+	t.dirty = false
+	return cp, changed
+}
@@ -0,0 +1,214 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProgressTracker(t *testing.T) {
+	t.Run("Should only advance over contiguous completed lines", func(t *testing.T) {
+		tr := newProgressTracker(Checkpoint{})
+		for line := uint64(1); line <= 4; line++ {
+			tr.start(line)
+		}
+		tr.finish(2)
+		tr.finish(4)
+
+		cp, changed := tr.snapshot()
+		assert.True(t, changed)
+		assert.Equal(t, uint64(0), cp.Completed)
+		assert.Equal(t, uint64(4), cp.Dispatched)
+		assert.Equal(t, []uint64{1, 3}, cp.InFlight)
+
+		tr.finish(1)
+		cp, _ = tr.snapshot()
+		assert.Equal(t, uint64(2), cp.Completed)
+
+		tr.finish(3)
+		cp, _ = tr.snapshot()
+		assert.Equal(t, uint64(4), cp.Completed)
+		assert.Empty(t, cp.InFlight)
+	})
+
+	t.Run("Should report unchanged snapshots", func(t *testing.T) {
+		tr := newProgressTracker(Checkpoint{})
+		tr.start(1)
+		_, changed := tr.snapshot()
+		assert.True(t, changed)
+		_, changed = tr.snapshot()
+		assert.False(t, changed)
+	})
+
+	t.Run("Should keep the lines a resumed checkpoint left in flight", func(t *testing.T) {
+		tr := newProgressTracker(Checkpoint{Completed: 2, Dispatched: 5, InFlight: []uint64{3, 5}})
+		cp, _ := tr.snapshot()
+		assert.Equal(t, []uint64{3, 5}, cp.InFlight)
+
+		tr.start(3)
+		tr.finish(3)
+		tr.finish(4)
+		cp, _ = tr.snapshot()
+		assert.Equal(t, uint64(4), cp.Completed)
+		assert.Equal(t, []uint64{5}, cp.InFlight)
+	})
+
+	t.Run("Should not park the lines a resumed checkpoint completed", func(t *testing.T) {
+		tr := newProgressTracker(Checkpoint{Completed: 1000, Dispatched: 1000})
+		for line := uint64(1); line <= 1000; line++ {
+			tr.finish(line)
+		}
+		assert.Empty(t, tr.finished)
+
+		tr.start(1001)
+		tr.finish(1001)
+		cp, _ := tr.snapshot()
+		assert.Equal(t, uint64(1001), cp.Completed)
+		assert.Empty(t, tr.finished)
+	})
+
+	t.Run("Should be complete once the file was read and nothing is in flight", func(t *testing.T) {
+		tr := newProgressTracker(Checkpoint{})
+		tr.start(1)
+		tr.reachedEOF()
+		assert.False(t, tr.complete())
+		tr.finish(1)
+		assert.True(t, tr.complete())
+	})
+}
+
+func TestCheckpoint_Done(t *testing.T) {
+	cp := Checkpoint{Completed: 2, Dispatched: 5, InFlight: []uint64{3, 5}}
+	for line, want := range map[uint64]bool{1: true, 2: true, 3: false, 4: true, 5: false, 6: false} {
+		assert.Equal(t, want, cp.done(line), "line %d", line)
+	}
+}
+
+func TestLoadCheckpoint(t *testing.T) {
+	tempFile := createCsvFile(t, "header1\nvalue1\n")
+	defer os.Remove(tempFile.Name())
+
+	size, hash, err := fingerprint(tempFile.Name())
+	require.NoError(t, err)
+	saved := Checkpoint{File: tempFile.Name(), Size: size, Hash: hash, Completed: 1, Dispatched: 1}
+	require.NoError(t, saveCheckpoint(tempFile.Name(), saved))
+
+	t.Run("Should leave no temporary file behind", func(t *testing.T) {
+		tmps, err := filepath.Glob(checkpointPath(tempFile.Name()) + ".*.tmp")
+		require.NoError(t, err)
+		assert.Empty(t, tmps)
+	})
+
+	t.Run("Should load the checkpoint of an unchanged file", func(t *testing.T) {
+		cp, err := LoadCheckpoint(tempFile.Name())
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), cp.Completed)
+	})
+
+	t.Run("Should reject the checkpoint once the file changed", func(t *testing.T) {
+		_, err := tempFile.WriteString("value2\n")
+		require.NoError(t, err)
+		_, err = LoadCheckpoint(tempFile.Name())
+		assert.ErrorContains(t, err, "file changed")
+	})
+
+	t.Run("Should fail when there is no checkpoint", func(t *testing.T) {
+		require.NoError(t, RemoveCheckpoint(tempFile.Name()))
+		_, err := LoadCheckpoint(tempFile.Name())
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		assert.NoError(t, RemoveCheckpoint(tempFile.Name()), "removing a missing checkpoint is not an error")
+	})
+}
+
+// TestProcessor_Do_Checkpoints proves a cancelled run leaves a
+// checkpoint that Resume uses to send only the remaining lines, and
+// that a run that gets through the whole file removes it.
+func TestProcessor_Do_Checkpoints(t *testing.T) {
+	tempFile := createCsvFile(t, "id\n1\n2\n3\n4\n")
+	defer os.Remove(tempFile.Name())
+
+	csvCfg := config.CSVConfig{Fields: []string{"id"}, Separator: ","}
+	p, gatewayMock, loggerMock := newTestProcessor(t, csvCfg, 1)
+	loggerMock.EXPECT().Add(gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).AnyTimes()
+
+	// First run: cancel while line 3 is being sent.
+	ctx, cancel := context.WithCancel(context.Background())
+	gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, row map[string]string) (web.Response, error) {
+			if row["id"] == "3" {
+				cancel()
+				return web.Response{}, ctx.Err()
+			}
+			return web.Response{StatusCode: 200}, nil
+		}).Times(3)
+
+	p.Do(ctx, tempFile.Name())
+	p.Wait()
+
+	cp, ok := p.Checkpoint(tempFile.Name())
+	require.True(t, ok, "a cancelled run must leave a checkpoint")
+	assert.Equal(t, uint64(2), cp.Completed)
+	assert.Contains(t, cp.InFlight, uint64(3))
+
+	// Second run: only lines 3 and 4 are sent.
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, row map[string]string) (web.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, row["id"])
+			return web.Response{StatusCode: 200}, nil
+		}).Times(2)
+
+	p.Resume(context.Background(), tempFile.Name(), cp)
+	summary := p.Wait()
+
+	assert.Equal(t, []string{"3", "4"}, sent)
+	assert.Equal(t, uint64(2), summary.LinesProcessed)
+	_, ok = p.Checkpoint(tempFile.Name())
+	assert.False(t, ok, "a run that reaches the end removes the checkpoint")
+}
+
+// TestProcessor_KeepCheckpoint_FlushesRows proves the failed rows of
+// the lines a checkpoint marks done are on disk before it is saved, so
+// a crash can't lose them.
+func TestProcessor_KeepCheckpoint_FlushesRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id\n1\n2\n"), 0o600))
+
+	p, _, _ := newTestProcessor(t, config.CSVConfig{Separator: ","}, 1)
+	failed, err := newRowsFile(FailedRowsPath(path), []string{"id"}, ',', nil, false)
+	require.NoError(t, err)
+	defer failed.close()
+	base := Checkpoint{File: path}
+	base.Size, base.Hash, err = fingerprint(path)
+	require.NoError(t, err)
+	st := &runState{tracker: newProgressTracker(base), failed: failed}
+
+	st.tracker.start(1)
+	require.NoError(t, failed.add([]string{"1"}, nil))
+	st.tracker.finish(1)
+	st.tracker.start(2)
+
+	stop := p.keepCheckpoint(path, st)
+	stop()
+
+	cp, err := LoadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), cp.Completed)
+	data, err := os.ReadFile(FailedRowsPath(path))
+	require.NoError(t, err)
+	assert.Equal(t, "id\n1\n", string(data), "the row is flushed before the checkpoint marks it done")
+}
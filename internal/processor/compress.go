@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressionExts are the extensions of compressed input files, e.g.
+// "users.csv.gz". The extension before them tells the format.
+var compressionExts = []string{".gz", ".zst", ".zstd"}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// openInput opens filePath for reading, decompressing it as it is read
+// when it is gzip or zstd compressed, so a file of several gigabytes is
+// never decompressed to disk. The compression is told by the first
+// bytes of the file, whatever its extension. Unless read is nil, it
+// counts the bytes read from the file itself, before decompression.
+func openInput(filePath string, read *atomic.Int64) (io.ReadCloser, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	var r io.Reader = file
+	if read != nil {
+		r = &countingReader{r: file, n: read}
+	}
+	in, err := decompress(r, file.Close)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	return in, nil
+}
+
+// decompress returns the decompressed content of r, or r itself when
+// it isn't compressed. Closing the result closes the decompressor and
+// calls closeFn.
+func decompress(r io.Reader, closeFn func() error) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return &inputFile{Reader: gz, close: func() error {
+			return errors.Join(gz.Close(), closeFn())
+		}}, nil
+
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return &inputFile{Reader: zr, close: func() error {
+			zr.Close()
+			return closeFn()
+		}}, nil
+	}
+
+	return &inputFile{Reader: br, close: closeFn}, nil
+}
+
+// inputFile is an input file, decompressed or not, that closes the
+// file and its decompressor together.
+type inputFile struct {
+	io.Reader
+	close func() error
+}
+
+func (f *inputFile) Close() error { return f.close() }
+
+// trimCompressionExt returns filePath without its compression
+// extension: "users.csv.gz" becomes "users.csv".
+func trimCompressionExt(filePath string) string {
+	ext := filepath.Ext(filePath)
+	for _, c := range compressionExts {
+		if strings.EqualFold(ext, c) {
+			return strings.TrimSuffix(filePath, ext)
+		}
+	}
+	return filePath
+}
+
+// sourceError is an error reading an input file itself, such as a
+// corrupt or truncated compressed stream, rather than one of its
+// records. It ends the reading of the file.
+type sourceError struct {
+	err error
+}
+
+func (e *sourceError) Error() string { return e.err.Error() }
+func (e *sourceError) Unwrap() error { return e.err }
@@ -0,0 +1,123 @@
+package processor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zstded(t *testing.T, data string) []byte {
+	t.Helper()
+	w, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer w.Close()
+	return w.EncodeAll([]byte(data), nil)
+}
+
+func TestPreviewRows_Compressed(t *testing.T) {
+	const csvData = "id;name\n1;ana\n2;bob\n"
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"users.csv.gz":   gzipped(t, csvData),
+		"users.csv.zst":  zstded(t, csvData),
+		"misnamed.csv":   gzipped(t, csvData),
+		"users.jsonl.gz": gzipped(t, `{"id": "1", "name": "ana"}`+"\n"+`{"id": "2", "name": "bob"}`+"\n"),
+	}
+	for name, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+
+	for name := range files {
+		t.Run("Should decompress "+name, func(t *testing.T) {
+			rows, err := PreviewRows(filepath.Join(dir, name), config.CSVConfig{Separator: ";"}, 5)
+			require.NoError(t, err)
+			assert.Equal(t, []map[string]string{
+				{"id": "1", "name": "ana"},
+				{"id": "2", "name": "bob"},
+			}, rows)
+		})
+	}
+}
+
+func TestSiblingPath_Compressed(t *testing.T) {
+	assert.Equal(t, "/data/users.failed.csv", FailedRowsPath("/data/users.csv.gz"))
+	assert.Equal(t, "/data/users.results.jsonl", ResultsPath("/data/users.jsonl.zst"))
+}
+
+func TestCSVSource_TruncatedStream(t *testing.T) {
+	data := gzipped(t, "id\n1\n2\n3\n")
+	path := filepath.Join(t.TempDir(), "users.csv.gz")
+	require.NoError(t, os.WriteFile(path, data[:len(data)-8], 0o600))
+
+	source, err := openRowSource(path, config.CSVConfig{}, nil)
+	require.NoError(t, err)
+	defer source.Close()
+
+	var srcErr *sourceError
+	for {
+		_, err := source.next()
+		if err != nil {
+			assert.ErrorAs(t, err, &srcErr, "a corrupt stream ends the source")
+			break
+		}
+	}
+}
+
+// TestProcessor_Do_Compressed proves a compressed file is processed
+// line by line like a plain one, counting its lines as it goes.
+func TestProcessor_Do_Compressed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv.gz")
+	require.NoError(t, os.WriteFile(path, gzipped(t, "id\n1\n2\n3\n"), 0o600))
+
+	p, gatewayMock, loggerMock := newTestProcessor(t, config.CSVConfig{Separator: ","}, 1)
+	gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(web.Response{StatusCode: 200}, nil).Times(3)
+	loggerMock.EXPECT().Add(gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).Times(3)
+
+	p.Do(context.Background(), path)
+	metrics := p.Wait()
+
+	assert.Equal(t, uint64(3), metrics.LinesProcessed)
+	assert.NoFileExists(t, checkpointPath(path), "a completed run leaves no checkpoint")
+}
+
+func TestNewStreamSource(t *testing.T) {
+	tests := map[string][]byte{
+		"CSV":                 []byte("id,name\n1,ana\n"),
+		"JSON Lines":          []byte("\n  {\"id\": \"1\", \"name\": \"ana\"}\n"),
+		"gzipped JSON Lines":  gzipped(t, `{"id": "1", "name": "ana"}`+"\n"),
+		"zstd compressed CSV": zstded(t, "id,name\n1,ana\n"),
+	}
+	for name, data := range tests {
+		t.Run("Should read "+name, func(t *testing.T) {
+			source, err := newStreamSource(bytes.NewReader(data), config.CSVConfig{})
+			require.NoError(t, err)
+			defer source.Close()
+
+			assert.Equal(t, []string{"id", "name"}, source.headers())
+			record, err := source.next()
+			require.NoError(t, err)
+			assert.Equal(t, []string{"1", "ana"}, record)
+		})
+	}
+}
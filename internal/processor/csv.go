@@ -1,12 +1,15 @@
 package processor
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
-	"os"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/anibaldeboni/rapper/internal/config"
 	"github.com/anibaldeboni/rapper/internal/utils"
@@ -24,10 +27,11 @@ func readCSVHeaders(reader *csv.Reader) ([]string, error) {
 	return headers, nil
 }
 
-func newCSVReader(filePath string, sep rune) (*csv.Reader, *os.File, error) {
-	file, err := os.Open(filePath)
+// newCSVReader opens filePath, decompressing it if it is compressed.
+func newCSVReader(filePath string, sep rune) (*csv.Reader, io.Closer, error) {
+	file, err := openInput(filePath, nil)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error opening file: %w", err)
+		return nil, nil, err
 	}
 
 	reader := csv.NewReader(file)
@@ -65,3 +69,175 @@ func csvSep(cfg config.CSVConfig) rune {
 	}
 	return rune(sep[0])
 }
+
+// PreviewRows returns the first n rows of filePath mapped exactly as a
+// run maps them for the templates, honouring the separator and fields
+// filter of cfg. A file with fewer rows returns all of them.
+func PreviewRows(filePath string, cfg config.CSVConfig, n int) ([]map[string]string, error) {
+	source, err := openRowSource(filePath, cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	headers := source.headers()
+	indexes := buildFilteredFieldIndex(headers, cfg.Fields)
+
+	rows := make([]map[string]string, 0, n)
+	for len(rows) < n {
+		record, err := source.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, fmt.Errorf("error reading row %d: %w", len(rows)+1, err)
+		}
+		rows = append(rows, mapRow(headers, indexes, record))
+	}
+	return rows, nil
+}
+
+// maxCSVProblems caps how many problems InspectCSV lists, so a file
+// with a problem on every row doesn't flood the output.
+const maxCSVProblems = 50
+
+// CSVReport is what InspectCSV found in a CSV or JSON Lines file.
+type CSVReport struct {
+	Headers  []string
+	Rows     int
+	Problems []config.Problem
+	// Omitted counts the problems found beyond the first
+	// maxCSVProblems, which aren't listed.
+	Omitted int
+}
+
+// add lists p, unless maxCSVProblems are listed already.
+func (r *CSVReport) add(p config.Problem) {
+	if len(r.Problems) == maxCSVProblems {
+		r.Omitted++
+		return
+	}
+	r.Problems = append(r.Problems, p)
+}
+
+// InspectCSV reads filePath the way a run does with cfg, and reports
+// its header, how many rows it has and the problems a run would trip
+// on: csv.fields columns missing from the header, rows whose number of
+// columns differs from the header's, malformed quoting and text that
+// isn't valid UTF-8. A malformed row stops the inspection, as the rows
+// after it can't be told apart. An error means the file couldn't be
+// read at all. JSON Lines files are inspected by inspectJSONL.
+func InspectCSV(filePath string, cfg config.CSVConfig) (CSVReport, error) {
+	if isJSONL(filePath) {
+		return inspectJSONL(filePath, cfg)
+	}
+
+	reader, file, err := newCSVReader(filePath, csvSep(cfg))
+	if err != nil {
+		return CSVReport{}, err
+	}
+	defer file.Close()
+	reader.FieldsPerRecord = -1
+
+	headers, err := readCSVHeaders(reader)
+	if err != nil {
+		return CSVReport{}, err
+	}
+
+	report := CSVReport{Headers: headers}
+	add := func(line, column int, format string, args ...any) {
+		report.add(config.Problem{File: filePath, Line: line, Column: column, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.HasPrefix(headers[0], "\uFEFF") {
+		add(1, 1, "the file starts with a UTF-8 byte order mark, which becomes part of the column name %q", headers[0])
+	}
+	for i, header := range headers {
+		if !utf8.ValidString(header) {
+			add(1, 0, "the name of column %d is not valid UTF-8", i+1)
+		}
+	}
+	for _, field := range cfg.Fields {
+		if !slices.Contains(headers, field) {
+			add(1, 0, "csv.fields column %q is not in the header", field)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			add(parseErr.Line, parseErr.Column, "%v", parseErr.Err)
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("error reading row %d: %w", report.Rows+1, err)
+		}
+
+		report.Rows++
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(headers) {
+			add(line, 0, "the row has %d columns, the header has %d", len(record), len(headers))
+		}
+		for i, value := range record {
+			if !utf8.ValidString(value) {
+				_, column := reader.FieldPos(i)
+				add(line, column, "column %d is not valid UTF-8", i+1)
+				break
+			}
+		}
+	}
+	return report, nil
+}
+
+// inspectJSONL reads the JSON Lines file at filePath and reports its
+// columns, every key found in its objects, how many objects it has and
+// the problems a run would trip on: lines that aren't a JSON object or
+// aren't valid UTF-8, and csv.fields keys no object has.
+func inspectJSONL(filePath string, cfg config.CSVConfig) (CSVReport, error) {
+	file, err := openInput(filePath, nil)
+	if err != nil {
+		return CSVReport{}, err
+	}
+	defer file.Close()
+
+	report := CSVReport{}
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return report, fmt.Errorf("error reading line %d: %w", line, err)
+		}
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			report.Rows++
+			keys, _, jsonErr := flattenJSON(data)
+			switch {
+			case !utf8.Valid(data):
+				report.add(config.Problem{File: filePath, Line: line, Message: "the line is not valid UTF-8"})
+			case jsonErr != nil:
+				report.add(config.Problem{File: filePath, Line: line, Message: jsonErr.Error()})
+			}
+			for _, key := range keys {
+				if !slices.Contains(report.Headers, key) {
+					report.Headers = append(report.Headers, key)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if report.Rows == 0 {
+		return report, errors.New("error reading headers: no records found in the file")
+	}
+	for _, field := range cfg.Fields {
+		if !slices.Contains(report.Headers, field) {
+			report.add(config.Problem{File: filePath, Message: fmt.Sprintf("csv.fields key %q is in none of the objects", field)})
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,77 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id;name;email\n1;ana;a@x\n2;bob;b@x\n3;cid;c@x\n"), 0o600))
+
+	t.Run("Should map the first rows with the fields filter", func(t *testing.T) {
+		rows, err := PreviewRows(path, config.CSVConfig{Separator: ";", Fields: []string{"id", "name"}}, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []map[string]string{
+			{"id": "1", "name": "ana"},
+			{"id": "2", "name": "bob"},
+		}, rows)
+	})
+
+	t.Run("Should return every row of a shorter file", func(t *testing.T) {
+		rows, err := PreviewRows(path, config.CSVConfig{Separator: ";"}, 10)
+		require.NoError(t, err)
+		assert.Len(t, rows, 3)
+		assert.Equal(t, map[string]string{"id": "3", "name": "cid", "email": "c@x"}, rows[2])
+	})
+
+	t.Run("Should fail when the file cannot be read", func(t *testing.T) {
+		_, err := PreviewRows(filepath.Join(t.TempDir(), "missing.csv"), config.CSVConfig{}, 1)
+		assert.ErrorContains(t, err, "error opening file")
+	})
+}
+
+func TestInspectCSV(t *testing.T) {
+	write := func(t *testing.T, data string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "users.csv")
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		return path
+	}
+
+	t.Run("Should count the rows of a well-formed file", func(t *testing.T) {
+		report, err := InspectCSV(write(t, "id;name\n1;ana\n2;bob\n"), config.CSVConfig{Separator: ";", Fields: []string{"id"}})
+		require.NoError(t, err)
+		assert.Equal(t, CSVReport{Headers: []string{"id", "name"}, Rows: 2}, report)
+	})
+
+	t.Run("Should report a byte order mark", func(t *testing.T) {
+		report, err := InspectCSV(write(t, "\ufeffid,name\n1,ana\n"), config.CSVConfig{Fields: []string{"id"}})
+		require.NoError(t, err)
+		require.Len(t, report.Problems, 2)
+		assert.Contains(t, report.Problems[0].Message, "byte order mark")
+		assert.Equal(t, `csv.fields column "id" is not in the header`, report.Problems[1].Message)
+	})
+
+	t.Run("Should cap the listed problems", func(t *testing.T) {
+		data := "id,name\n"
+		for range maxCSVProblems + 5 {
+			data += "1\n"
+		}
+		report, err := InspectCSV(write(t, data), config.CSVConfig{})
+		require.NoError(t, err)
+		assert.Len(t, report.Problems, maxCSVProblems)
+		assert.Equal(t, 5, report.Omitted)
+		assert.Equal(t, maxCSVProblems+5, report.Rows)
+	})
+
+	t.Run("Should fail on an empty file", func(t *testing.T) {
+		_, err := InspectCSV(write(t, ""), config.CSVConfig{})
+		assert.ErrorContains(t, err, "no records found")
+	})
+}
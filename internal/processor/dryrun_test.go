@@ -0,0 +1,77 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/logs"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// TestProcessor_Do_DryRun proves a dry run renders every row without
+// sending it, writes the rendered request to the output file, flags
+// rows with missing values as errors and leaves no checkpoint behind.
+func TestProcessor_Do_DryRun(t *testing.T) {
+	tempFile := createCsvFile(t, "id\n1\n\n2\n")
+	defer os.Remove(tempFile.Name())
+
+	csvCfg := config.CSVConfig{Fields: []string{"id"}, Separator: ","}
+	p, gatewayMock, loggerMock := newTestProcessor(t, csvCfg, 1)
+	p.SetDryRun(true)
+
+	gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).Times(0)
+	gatewayMock.EXPECT().Render(gomock.Any()).DoAndReturn(func(row map[string]string) web.Request {
+		if row["id"] == "2" {
+			return web.Request{Method: "POST", URL: "https://api/2", Body: []byte(`{"name":"<no value>"}`)}
+		}
+		return web.Request{Method: "POST", URL: "https://api/" + row["id"], Body: []byte(`{"name":"ana"}`)}
+	}).Times(2)
+
+	var (
+		mu       sync.Mutex
+		messages []logs.LogMessage
+		lines    []*DryRunLine
+	)
+	loggerMock.EXPECT().Add(gomock.Any()).DoAndReturn(func(m logs.LogMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, m)
+	}).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).DoAndReturn(func(l logs.Line) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, l.(*DryRunLine))
+	}).Times(2)
+
+	p.Do(context.Background(), tempFile.Name())
+	summary := p.Wait()
+
+	assert.Equal(t, uint64(2), summary.TotalRequests)
+	assert.Equal(t, uint64(1), summary.ErrorRequests)
+	_, ok := p.Checkpoint(tempFile.Name())
+	assert.False(t, ok, "a dry run must not checkpoint")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, `{"name":"ana"}`, lines[0].Body)
+		assert.Empty(t, lines[0].Missing)
+		assert.Equal(t, []string{"body"}, lines[1].Missing)
+	}
+
+	var flagged []logs.LogMessage
+	for _, m := range messages {
+		if m.Type == logs.LogTypeWarning {
+			flagged = append(flagged, m)
+		}
+	}
+	if assert.Len(t, flagged, 1) {
+		assert.Equal(t, "POST https://api/2", flagged[0].Text)
+		assert.Contains(t, flagged[0].Details, "<no value> in body")
+	}
+}
@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"strconv"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+)
+
+const (
+	statusColumn = "_status"
+	errorColumn  = "_error"
+)
+
+// FailedRowsPath returns where the failed rows of filePath are written:
+// "users.csv" becomes "users.failed.csv", next to the original.
+func FailedRowsPath(filePath string) string {
+	return siblingPath(filePath, "failed")
+}
+
+// newFailedRows prepares the file that keeps the source records of the
+// rows of filePath whose request failed, so they can be processed
+// again. Re-running a failed rows file reuses its _status/_error
+// columns.
+func newFailedRows(filePath string, headers []string, sep rune, cfg config.FailedRowsConfig, resume bool) (*rowsFile, error) {
+	var columns []string
+	if cfg.StatusColumn {
+		columns = append(columns, statusColumn)
+	}
+	if cfg.ErrorColumn {
+		columns = append(columns, errorColumn)
+	}
+	return newRowsFile(FailedRowsPath(filePath), headers, sep, columns, resume)
+}
+
+// outcomeValues returns the _status and _error columns of a row: the
+// response status code, if there was a response, and the error.
+func outcomeValues(status int, err error) map[string]string {
+	values := make(map[string]string, 2)
+	if status > 0 {
+		values[statusColumn] = strconv.Itoa(status)
+	}
+	if err != nil {
+		values[errorColumn] = err.Error()
+	}
+	return values
+}
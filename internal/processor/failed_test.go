@@ -0,0 +1,108 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFailedRowsPath(t *testing.T) {
+	assert.Equal(t, "/data/users.failed.csv", FailedRowsPath("/data/users.csv"))
+	assert.Equal(t, "users.failed.failed.csv", FailedRowsPath("users.failed.csv"))
+}
+
+func TestFailedRows(t *testing.T) {
+	cfg := config.FailedRowsConfig{Enabled: true, StatusColumn: true, ErrorColumn: true}
+
+	t.Run("Should only create the file once a row fails", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "users.csv")
+		f, err := newFailedRows(path, []string{"id"}, ',', cfg, false)
+		require.NoError(t, err)
+
+		n, err := f.close()
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+		assert.NoFileExists(t, FailedRowsPath(path))
+	})
+
+	t.Run("Should reuse the status and error columns of a failed rows file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "users.failed.csv")
+		f, err := newFailedRows(path, []string{"id", "_status", "_error"}, ',', cfg, false)
+		require.NoError(t, err)
+		require.NoError(t, f.add([]string{"1", "500", ""}, outcomeValues(0, errors.New("refused"))))
+		_, err = f.close()
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(FailedRowsPath(path))
+		require.NoError(t, err)
+		assert.Equal(t, "id,_status,_error\n1,,refused\n", string(data))
+	})
+
+	t.Run("Should append when resuming and start over otherwise", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "users.csv")
+		write := func(resume bool, id string) {
+			f, err := newFailedRows(path, []string{"id"}, ';', config.FailedRowsConfig{Enabled: true}, resume)
+			require.NoError(t, err)
+			require.NoError(t, f.add([]string{id}, outcomeValues(503, nil)))
+			_, err = f.close()
+			require.NoError(t, err)
+		}
+
+		write(false, "1")
+		write(true, "2")
+		data, err := os.ReadFile(FailedRowsPath(path))
+		require.NoError(t, err)
+		assert.Equal(t, "id\n1\n2\n", string(data))
+
+		write(false, "3")
+		data, err = os.ReadFile(FailedRowsPath(path))
+		require.NoError(t, err)
+		assert.Equal(t, "id\n3\n", string(data))
+	})
+}
+
+// TestProcessor_Do_WritesFailedRows proves the failed rows file keeps
+// every column of the source record, not just csv.fields, and records
+// the outcome of each failed request.
+func TestProcessor_Do_WritesFailedRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id,name\n1,ana\n2,bob\n3,cid\n"), 0o600))
+
+	csvCfg := config.CSVConfig{
+		Fields:     []string{"id"},
+		Separator:  ",",
+		FailedRows: config.FailedRowsConfig{Enabled: true, StatusColumn: true, ErrorColumn: true},
+	}
+	p, gatewayMock, loggerMock := newTestProcessor(t, csvCfg, 1)
+
+	gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, row map[string]string) (web.Response, error) {
+			switch row["id"] {
+			case "2":
+				return web.Response{StatusCode: 422}, nil
+			case "3":
+				return web.Response{}, &url.Error{Op: "Post", URL: "http://api/3", Err: errors.New("connection refused")}
+			}
+			return web.Response{StatusCode: 200}, nil
+		}).Times(3)
+	loggerMock.EXPECT().Add(gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).Times(3)
+
+	p.Do(context.Background(), path)
+	p.Wait()
+
+	data, err := os.ReadFile(FailedRowsPath(path))
+	require.NoError(t, err)
+	assert.Equal(t, "id,name,_status,_error\n"+
+		"2,bob,422,\n"+
+		"3,cid,,\"Post \"\"http://api/3\"\": connection refused\"\n", string(data))
+}
@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// subBucketBits sets the precision of the latency histogram: every
+// power of two is split in 1<<subBucketBits buckets, so a percentile is
+// within about 3% of the actual latency.
+const subBucketBits = 5
+
+const (
+	subBuckets = 1 << subBucketBits
+	// maxExponent covers latencies up to about 19 hours, in
+	// microseconds; longer ones fall in the last bucket.
+	maxExponent = 36
+)
+
+// latencyHistogram counts request latencies in buckets of the same
+// relative width, as an HDR histogram does, so its percentiles are as
+// precise at a millisecond as at a minute while its size is fixed. It
+// is safe for concurrent use.
+type latencyHistogram struct {
+	counts [(maxExponent + 1) * subBuckets]atomic.Uint64
+	total  atomic.Uint64
+	max    atomic.Int64
+}
+
+// latencies holds the latencies of the current run, like the request
+// counters.
+var latencies latencyHistogram
+
+// record counts a request that took d.
+func (h *latencyHistogram) record(d time.Duration) {
+	h.counts[bucketOf(d)].Add(1)
+	h.total.Add(1)
+	for {
+		m := h.max.Load()
+		if int64(d) <= m || h.max.CompareAndSwap(m, int64(d)) {
+			return
+		}
+	}
+}
+
+// quantile returns the latency q of the requests took at most, q going
+// from 0 to 1, or 0 without requests.
+func (h *latencyHistogram) quantile(q float64) time.Duration {
+	total := h.total.Load()
+	if total == 0 {
+		return 0
+	}
+	rank := max(uint64(q*float64(total)+0.5), 1)
+	var seen uint64
+	for i := range h.counts {
+		if seen += h.counts[i].Load(); seen >= rank {
+			// The middle of the bucket, but never more than the
+			// slowest request.
+			return min(bucketValue(i), h.maxLatency())
+		}
+	}
+	return h.maxLatency()
+}
+
+// maxLatency returns the latency of the slowest request.
+func (h *latencyHistogram) maxLatency() time.Duration {
+	return time.Duration(h.max.Load())
+}
+
+func (h *latencyHistogram) reset() {
+	for i := range h.counts {
+		h.counts[i].Store(0)
+	}
+	h.total.Store(0)
+	h.max.Store(0)
+}
+
+// bucketOf returns the bucket d falls in. Below subBuckets microseconds
+// every microsecond has its own bucket; above, the buckets of each
+// power of two are as wide as its first microsecond bits allow.
+func bucketOf(d time.Duration) int {
+	us := uint64(max(d.Microseconds(), 0))
+	if us < subBuckets {
+		return int(us)
+	}
+	exp := bits.Len64(us) - subBucketBits - 1
+	if exp >= maxExponent {
+		return len(latencies.counts) - 1
+	}
+	return (exp+1)*subBuckets + int(us>>exp) - subBuckets
+}
+
+// bucketValue returns the middle of bucket i.
+func bucketValue(i int) time.Duration {
+	if i < subBuckets {
+		return time.Duration(i) * time.Microsecond
+	}
+	exp := i/subBuckets - 1
+	low := uint64(subBuckets+i%subBuckets) << exp
+	return time.Duration(low+(uint64(1)<<exp)/2) * time.Microsecond
+}
@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBucketOf(t *testing.T) {
+	for _, d := range []time.Duration{
+		0,
+		7 * time.Microsecond,
+		999 * time.Microsecond,
+		12 * time.Millisecond,
+		1500 * time.Millisecond,
+		3 * time.Minute,
+	} {
+		t.Run("Should keep "+d.String()+" within 3%", func(t *testing.T) {
+			got := bucketValue(bucketOf(d))
+			assert.InDelta(t, float64(d), float64(got), 0.03*float64(d)+float64(time.Microsecond))
+		})
+	}
+
+	t.Run("Should order the buckets like the latencies", func(t *testing.T) {
+		prev := -1
+		for us := 0; us < 100_000; us += 7 {
+			b := bucketOf(time.Duration(us) * time.Microsecond)
+			require.GreaterOrEqual(t, b, prev)
+			prev = b
+		}
+	})
+
+	t.Run("Should keep the longest latencies in the last bucket", func(t *testing.T) {
+		assert.Equal(t, len(latencies.counts)-1, bucketOf(1000*time.Hour))
+	})
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	var h latencyHistogram
+	for i := 1; i <= 100; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+
+	assert.InDelta(t, float64(50*time.Millisecond), float64(h.quantile(0.5)), float64(2*time.Millisecond))
+	assert.InDelta(t, float64(90*time.Millisecond), float64(h.quantile(0.9)), float64(3*time.Millisecond))
+	assert.InDelta(t, float64(99*time.Millisecond), float64(h.quantile(0.99)), float64(3*time.Millisecond))
+	assert.Equal(t, 100*time.Millisecond, h.maxLatency())
+	assert.LessOrEqual(t, h.quantile(1), h.maxLatency(), "no percentile exceeds the slowest request")
+
+	h.reset()
+	assert.Zero(t, h.quantile(0.5))
+	assert.Zero(t, h.maxLatency())
+}
+
+// TestProcessor_Do_Latency proves every attempt of a request counts
+// towards the latency percentiles and each request line carries its
+// duration.
+func TestProcessor_Do_Latency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id\n1\n2\n"), 0o600))
+
+	p, gatewayMock, loggerMock := newTestProcessor(t, config.CSVConfig{Separator: ","}, 1)
+	gomock.InOrder(
+		gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(web.Response{StatusCode: 200, Latency: 20 * time.Millisecond}, nil),
+		gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(web.Response{StatusCode: 200, Latency: 400 * time.Millisecond}, nil),
+	)
+	loggerMock.EXPECT().Add(gomock.Any()).AnyTimes()
+	var durations []int64
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).Do(func(line any) {
+		durations = append(durations, line.(*RequestLine).Duration)
+	}).Times(2)
+
+	p.Do(context.Background(), path)
+	metrics := p.Wait()
+
+	assert.InDelta(t, float64(20*time.Millisecond), float64(metrics.LatencyP50), float64(time.Millisecond))
+	assert.Equal(t, 400*time.Millisecond, metrics.LatencyMax)
+	assert.Equal(t, []int64{20, 400}, durations)
+	assert.Zero(t, p.GetMetrics().LatencyMax, "the histogram is reset for the next run")
+}
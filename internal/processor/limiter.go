@@ -0,0 +1,161 @@
+package processor
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+)
+
+// tokenBucket is a classic token bucket: it holds up to burst tokens,
+// refills at rate tokens per second and every request takes one. When
+// the bucket is empty the token is borrowed and the caller is told how
+// long to wait for it, so concurrent workers queue up fairly instead of
+// polling. A non-positive rate disables the bucket.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	b := &tokenBucket{}
+	b.set(rate, burst, time.Now())
+	b.tokens = b.burst
+	return b
+}
+
+// reserve takes a token and returns how long the caller must wait
+// before using it.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate <= 0 {
+		return 0
+	}
+
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// set changes the rate and burst, keeping the tokens accumulated so far
+// (up to the new burst). Tokens already borrowed by waiting workers are
+// kept as debt so a lower rate takes effect immediately.
+func (b *tokenBucket) set(rate float64, burst int, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	b.rate = rate
+	b.burst = float64(max(burst, 1))
+	b.tokens = min(b.tokens, b.burst)
+}
+
+// setRate changes the rate, keeping the burst.
+func (b *tokenBucket) setRate(rate float64, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	b.rate = rate
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if b.rate > 0 && !b.last.IsZero() {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+}
+
+func (b *tokenBucket) getRate() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.rate
+}
+
+// rateLimiter is the limiter shared by every worker: one global bucket
+// plus one bucket per host override from the rate_limit config.
+type rateLimiter struct {
+	mu     sync.RWMutex
+	global *tokenBucket
+	hosts  map[string]*tokenBucket
+}
+
+func newRateLimiter(cfg config.RateLimitConfig) *rateLimiter {
+	l := &rateLimiter{global: newTokenBucket(cfg.RequestsPerSecond, cfg.Burst)}
+	l.configure(cfg)
+	return l
+}
+
+// configure applies a rate_limit config. The global bucket is updated
+// in place so workers waiting on it keep their place in line.
+func (l *rateLimiter) configure(cfg config.RateLimitConfig) {
+	hosts := make(map[string]*tokenBucket, len(cfg.Hosts))
+	for host, limit := range cfg.Hosts {
+		hosts[host] = newTokenBucket(limit.RequestsPerSecond, limit.Burst)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.global.set(cfg.RequestsPerSecond, cfg.Burst, time.Now())
+	l.hosts = hosts
+}
+
+// setGlobalRate changes the global requests per second, e.g. from the
+// Settings view while a run is in progress. Host overrides are kept.
+func (l *rateLimiter) setGlobalRate(rate float64) {
+	l.global.setRate(max(rate, 0), time.Now())
+}
+
+// globalRate returns the global requests per second (0 = unlimited).
+func (l *rateLimiter) globalRate() float64 {
+	return l.global.getRate()
+}
+
+// hasHostLimits reports whether the host of each request must be
+// resolved before waiting.
+func (l *rateLimiter) hasHostLimits() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.hosts) > 0
+}
+
+// wait blocks until the bucket for rawURL's host (or the global bucket
+// when the host has no override) allows one more request. It returns
+// false when ctx is done first.
+func (l *rateLimiter) wait(ctx context.Context, rawURL string) bool {
+	d := l.bucketFor(rawURL).reserve(time.Now())
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	return sleep(ctx, d)
+}
+
+func (l *rateLimiter) bucketFor(rawURL string) *tokenBucket {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if len(l.hosts) > 0 && rawURL != "" {
+		if u, err := url.Parse(rawURL); err == nil {
+			if b, ok := l.hosts[u.Host]; ok {
+				return b
+			}
+			if b, ok := l.hosts[u.Hostname()]; ok {
+				return b
+			}
+		}
+	}
+	return l.global
+}
@@ -0,0 +1,148 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTokenBucket_Reserve(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Should let the burst through and then space requests by the rate", func(t *testing.T) {
+		b := newTokenBucket(10, 2)
+		b.last = now
+
+		assert.Zero(t, b.reserve(now))
+		assert.Zero(t, b.reserve(now))
+		assert.Equal(t, 100*time.Millisecond, b.reserve(now))
+		assert.Equal(t, 200*time.Millisecond, b.reserve(now))
+	})
+
+	t.Run("Should refill over time up to the burst", func(t *testing.T) {
+		b := newTokenBucket(10, 1)
+		b.last = now
+
+		assert.Zero(t, b.reserve(now))
+		assert.Zero(t, b.reserve(now.Add(100*time.Millisecond)))
+		assert.Zero(t, b.reserve(now.Add(time.Hour)))
+		assert.Equal(t, 100*time.Millisecond, b.reserve(now.Add(time.Hour)))
+	})
+
+	t.Run("Should never wait when unlimited", func(t *testing.T) {
+		b := newTokenBucket(0, 0)
+		for range 100 {
+			assert.Zero(t, b.reserve(now))
+		}
+	})
+
+	t.Run("Should apply a new rate to the following requests", func(t *testing.T) {
+		b := newTokenBucket(10, 1)
+		b.last = now
+
+		assert.Zero(t, b.reserve(now))
+		b.setRate(1, now)
+		assert.Equal(t, time.Second, b.reserve(now))
+		assert.Equal(t, 1.0, b.getRate())
+	})
+}
+
+func TestRateLimiter_BucketFor(t *testing.T) {
+	l := newRateLimiter(config.RateLimitConfig{
+		RequestsPerSecond: 5,
+		Hosts: map[string]config.HostRateLimit{
+			"api.example":      {RequestsPerSecond: 1},
+			"other.example:81": {RequestsPerSecond: 2},
+		},
+	})
+
+	t.Run("Should match the host with or without the port", func(t *testing.T) {
+		assert.Equal(t, 1.0, l.bucketFor("https://api.example/users/1").getRate())
+		assert.Equal(t, 1.0, l.bucketFor("https://api.example:8443/users/1").getRate())
+		assert.Equal(t, 2.0, l.bucketFor("http://other.example:81/x").getRate())
+	})
+
+	t.Run("Should fall back to the global bucket", func(t *testing.T) {
+		assert.Equal(t, 5.0, l.bucketFor("http://other.example/x").getRate())
+		assert.Equal(t, 5.0, l.bucketFor("://bad").getRate())
+	})
+
+	t.Run("Should keep host overrides when the global rate changes", func(t *testing.T) {
+		l.setGlobalRate(50)
+		assert.Equal(t, 50.0, l.globalRate())
+		assert.Equal(t, 1.0, l.bucketFor("https://api.example/").getRate())
+		assert.True(t, l.hasHostLimits())
+	})
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	t.Run("Should return false when the context is cancelled while waiting", func(t *testing.T) {
+		l := newRateLimiter(config.RateLimitConfig{RequestsPerSecond: 0.01, Burst: 1})
+		assert.True(t, l.wait(context.Background(), ""))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.False(t, l.wait(ctx, ""))
+	})
+}
+
+// TestProcessor_Do_ResolvesHostForHostLimits proves the worker renders
+// the request to learn its host only when host overrides are configured.
+func TestProcessor_Do_ResolvesHostForHostLimits(t *testing.T) {
+	tempFile := createCsvFile(t, "header1\nvalue1\n")
+	defer os.Remove(tempFile.Name())
+
+	csvCfg := config.CSVConfig{Fields: []string{"header1"}, Separator: ","}
+	p, gatewayMock, loggerMock := newTestProcessor(t, csvCfg, 1)
+	p.UpdateRateLimit(config.RateLimitConfig{
+		Hosts: map[string]config.HostRateLimit{"api.example": {RequestsPerSecond: 100}},
+	})
+
+	gatewayMock.EXPECT().Render(map[string]string{"header1": "value1"}).
+		Return(web.Request{URL: "https://api.example/value1"}).Times(1)
+	gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).
+		Return(web.Response{StatusCode: 200}, nil).Times(1)
+	loggerMock.EXPECT().Add(gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).Times(1)
+
+	p.Do(context.Background(), tempFile.Name())
+	summary := p.Wait()
+
+	assert.Equal(t, uint64(1), summary.SuccessRequests)
+}
+
+// TestProcessor_Exec_CancelledWait proves a request cancelled while
+// waiting on the rate limiter still reports the URL it was for.
+func TestProcessor_Exec_CancelledWait(t *testing.T) {
+	p, gatewayMock, _ := newTestProcessor(t, config.CSVConfig{}, 1)
+	p.UpdateRateLimit(config.RateLimitConfig{RequestsPerSecond: 0.01, Burst: 1})
+	assert.True(t, p.limiter.wait(context.Background(), ""))
+
+	row := map[string]string{"id": "1"}
+	gatewayMock.EXPECT().Render(row).Return(web.Request{URL: "https://api.example/users/1"}).Times(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	res, err := p.exec(ctx, gatewayMock, row)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, "https://api.example/users/1", res.URL)
+}
+
+func TestProcessor_SetRateLimit(t *testing.T) {
+	p, _, _ := newTestProcessor(t, config.CSVConfig{}, 1)
+	p.UpdateRateLimit(config.RateLimitConfig{RequestsPerSecond: 3})
+	assert.Equal(t, 3.0, p.GetRateLimit())
+
+	p.SetRateLimit(7.5)
+	assert.Equal(t, 7.5, p.GetRateLimit())
+
+	p.SetRateLimit(-1)
+	assert.Equal(t, 0.0, p.GetRateLimit())
+}
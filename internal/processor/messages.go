@@ -3,17 +3,37 @@ package processor
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/anibaldeboni/rapper/internal/logs"
 	"github.com/anibaldeboni/rapper/internal/styles"
+	"github.com/anibaldeboni/rapper/internal/web"
 )
 
 func csvError(message string) logs.LogMessage {
 	return logs.NewMessage("CSV error", logs.WithDetail(message), logs.WithIcon(styles.IconSkull), logs.AsError())
 }
 
-func doneMessage(errs uint64) logs.LogMessage {
+func checkpointError(err error) logs.LogMessage {
+	return logs.NewMessage("Could not save checkpoint", logs.WithDetail(err.Error()), logs.WithIcon(styles.IconWarning), logs.AsWarning())
+}
+
+func resumeMessage(cp Checkpoint) logs.LogMessage {
+	return logs.NewMessage(
+		fmt.Sprintf("Resuming from line %d (%d in flight when interrupted)", cp.Completed+1, len(cp.InFlight)),
+		logs.WithIcon(styles.IconInformation),
+		logs.AsGeneral(),
+	)
+}
+
+// doneMessage announces the end of a run. The detail breaks its
+// requests down by status code and error class.
+func doneMessage(errs uint64, statuses map[int]uint64, classes map[ErrorClass]uint64) logs.LogMessage {
 	errMsg := "no errors"
 	icon := styles.IconTrophy
 
@@ -22,7 +42,103 @@ func doneMessage(errs uint64) logs.LogMessage {
 		icon = styles.IconError
 	}
 
-	return logs.NewMessage("Finished with "+errMsg, logs.WithIcon(icon), logs.AsGeneral())
+	return logs.NewMessage(
+		"Finished with "+errMsg,
+		logs.WithDetail(formatOutcomes(statuses, classes)),
+		logs.WithIcon(icon),
+		logs.AsGeneral(),
+	)
+}
+
+// retryMessage announces that a request is about to be retried. The
+// badge carries the status code that triggered the retry, or the
+// skull icon when the attempt failed at the transport level.
+func retryMessage(nextAttempt, maxAttempts int, wait time.Duration, res web.Response, err error) logs.LogMessage {
+	title := fmt.Sprintf("Retrying %s %s in %s (attempt %d/%d)", res.Method, res.URL, wait.Round(time.Millisecond), nextAttempt, maxAttempts)
+	if err != nil {
+		return logs.NewMessage(title, logs.WithDetail(err.Error()), logs.WithIcon(styles.IconSkull), logs.AsWarning())
+	}
+	return logs.NewMessage(title, logs.WithDetail(string(res.Body)), logs.WithIcon(strconv.Itoa(res.StatusCode)), logs.AsWarning())
+}
+
+// assertionMessage reports a response that failed one of the profile's
+// assertions. The title names the assertion; the detail keeps the body.
+func assertionMessage(res web.Response, reason string) logs.LogMessage {
+	return logs.NewMessage(
+		fmt.Sprintf("%s %s failed assertion: %s", res.Method, res.URL, reason),
+		logs.WithDetail(string(res.Body)),
+		logs.WithIcon(strconv.Itoa(res.StatusCode)),
+		logs.AsError(),
+	)
+}
+
+func rowsFileError(path string, err error) logs.LogMessage {
+	return logs.NewMessage("Could not write "+filepath.Base(path), logs.WithDetail(err.Error()), logs.WithIcon(styles.IconWarning), logs.AsWarning())
+}
+
+// inStep names the step of a multi-step chain msg is about. Messages
+// of a single-step profile, whose step has no name, are left as they are.
+func inStep(step string, msg logs.LogMessage) logs.LogMessage {
+	if step != "" {
+		msg.Text = "[" + step + "] " + msg.Text
+	}
+	return msg
+}
+
+func failedRowsMessage(path string, count int) logs.LogMessage {
+	return logs.NewMessage(
+		fmt.Sprintf("Wrote %d failed rows to %s", count, styles.Green(filepath.Base(path))),
+		logs.WithIcon(styles.IconInformation),
+		logs.AsGeneral(),
+	)
+}
+
+func resultsMessage(path string, count int) logs.LogMessage {
+	return logs.NewMessage(
+		fmt.Sprintf("Wrote the results of %d rows to %s", count, styles.Green(filepath.Base(path))),
+		logs.WithIcon(styles.IconInformation),
+		logs.AsGeneral(),
+	)
+}
+
+func streamRowsFilesMessage() logs.LogMessage {
+	return logs.NewMessage("Failed rows and results are not written for a stream", logs.WithIcon(styles.IconWarning), logs.AsWarning())
+}
+
+// inputName names the input of a run in the processing message: the
+// base name of a file, or the name of a stream.
+func inputName(in runInput) string {
+	if in.stream != nil {
+		return in.name
+	}
+	return "file " + styles.Green(filepath.Base(in.name))
+}
+
+func dryRunStartMessage() logs.LogMessage {
+	return logs.NewMessage("Dry run: requests are rendered but not sent", logs.WithIcon(styles.IconInformation), logs.AsGeneral())
+}
+
+// dryRunMessage shows the request a row renders to. The detail lists
+// the headers and the body the way they would go over the wire; rows
+// that rendered a missing variable are raised as warnings naming the
+// parts affected.
+func dryRunMessage(req web.Request, missing []string) logs.LogMessage {
+	var detail strings.Builder
+	if len(missing) > 0 {
+		detail.WriteString(web.NoValue + " in " + strings.Join(missing, ", ") + "\n")
+	}
+	for _, name := range slices.Sorted(maps.Keys(req.Headers)) {
+		detail.WriteString(name + ": " + req.Headers[name] + "\n")
+	}
+	if len(req.Body) > 0 {
+		detail.WriteString("\n" + string(req.Body))
+	}
+
+	title := req.Method + " " + req.URL
+	if len(missing) > 0 {
+		return logs.NewMessage(title, logs.WithDetail(detail.String()), logs.WithIcon(styles.IconWarning), logs.AsWarning())
+	}
+	return logs.NewMessage(title, logs.WithDetail(detail.String()), logs.WithIcon("DRY"), logs.AsGeneral())
 }
 
 func workersMsg(workers int) string {
@@ -36,13 +152,40 @@ func workersMsg(workers int) string {
 // RequestLine is the per-request record streamed to the on-disk
 // output file. The body is included even on errors so the user can
 // inspect what the server actually said; the field is omitted from
-// the JSON when nil to keep success-only output compact.
+// the JSON when nil to keep success-only output compact. Reason names
+// the response assertion the request failed, if any, Step the step of a
+// multi-step chain that failed, Captured holds the values
+// request.capture extracted from the responses and Duration how many
+// milliseconds the last request took.
 type RequestLine struct {
-	Error  error  `json:"error"`
-	URL    string `json:"url"`
-	Method string `json:"method"`
-	Body   []byte `json:"body"`
-	Status int    `json:"status"`
+	Error    error             `json:"error"`
+	URL      string            `json:"url"`
+	Method   string            `json:"method"`
+	Body     []byte            `json:"body"`
+	Status   int               `json:"status"`
+	Reason   string            `json:"reason,omitempty"`
+	Step     string            `json:"step,omitempty"`
+	Captured map[string]string `json:"captured,omitempty"`
+	Duration int64             `json:"duration_ms"`
+}
+
+// DryRunLine is the record written to the output file for every row of
+// a dry run: the request that would have been sent, plus the parts
+// that rendered a variable missing from the row. Unlike RequestLine the
+// body is kept as text, since reading it is the point of a dry run.
+type DryRunLine struct {
+	Step    string            `json:"step,omitempty"`
+	Headers map[string]string `json:"headers"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Body    string            `json:"body"`
+	Missing []string          `json:"missing,omitempty"`
+}
+
+// Bytes serialises the dry-run line as JSON.
+func (r DryRunLine) Bytes() []byte {
+	m, _ := json.Marshal(r)
+	return m
 }
 
 // Bytes serialises the request line as JSON. Used by
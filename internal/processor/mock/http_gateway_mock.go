@@ -56,6 +56,20 @@ func (mr *MockHttpGatewayMockRecorder) Exec(ctx, data any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockHttpGateway)(nil).Exec), ctx, data)
 }
 
+// Render mocks base method.
+func (m *MockHttpGateway) Render(data map[string]string) web.Request {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Render", data)
+	ret0, _ := ret[0].(web.Request)
+	return ret0
+}
+
+// Render indicates an expected call of Render.
+func (mr *MockHttpGatewayMockRecorder) Render(data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Render", reflect.TypeOf((*MockHttpGateway)(nil).Render), data)
+}
+
 // UpdateConfig mocks base method.
 func (m *MockHttpGateway) UpdateConfig(method, urlTemplate, bodyTemplate string, headers map[string]string) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,158 @@
+package processor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+// ErrorClass is the kind of failure of a request that got no response.
+type ErrorClass string
+
+const (
+	ErrorClassTimeout           ErrorClass = "timeout"
+	ErrorClassConnectionRefused ErrorClass = "connection refused"
+	ErrorClassTLS               ErrorClass = "tls"
+	ErrorClassDNS               ErrorClass = "dns"
+	ErrorClassCancelled         ErrorClass = "cancelled"
+	ErrorClassOther             ErrorClass = "other"
+)
+
+// errorClasses are the error classes in the order they are reported.
+var errorClasses = [...]ErrorClass{
+	ErrorClassTimeout,
+	ErrorClassConnectionRefused,
+	ErrorClassTLS,
+	ErrorClassDNS,
+	ErrorClassCancelled,
+	ErrorClassOther,
+}
+
+// classifyError tells the class of err, the error of a request that
+// got no response. A DNS lookup that timed out is a DNS error.
+func classifyError(err error) ErrorClass {
+	var (
+		dnsErr       *net.DNSError
+		netErr       net.Error
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		certErr      *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCancelled
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorClassConnectionRefused
+	case errors.As(err, &recordErr),
+		errors.As(err, &alertErr),
+		errors.As(err, &certErr),
+		errors.As(err, &authorityErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr):
+		return ErrorClassTLS
+	}
+	return ErrorClassOther
+}
+
+// maxStatusCode bounds the status codes counted one by one; the few
+// servers sending larger ones have them counted as 999.
+const maxStatusCode = 999
+
+// outcomeCounts counts the final outcome of every request of a run: the
+// status code of its response or the class of its error. It is safe
+// for concurrent use.
+type outcomeCounts struct {
+	statuses [maxStatusCode + 1]atomic.Uint64
+	classes  [len(errorClasses)]atomic.Uint64 // indexed like errorClasses
+}
+
+// outcomes holds the outcomes of the current run, like the request
+// counters.
+var outcomes outcomeCounts
+
+// record counts a request that got the status code or, when err isn't
+// nil, failed with err.
+func (o *outcomeCounts) record(status int, err error) {
+	if err != nil {
+		o.classes[slices.Index(errorClasses[:], classifyError(err))].Add(1)
+		return
+	}
+	o.statuses[min(max(status, 0), maxStatusCode)].Add(1)
+}
+
+// statusCodes returns the count of every status code received, nil
+// without responses.
+func (o *outcomeCounts) statusCodes() map[int]uint64 {
+	var codes map[int]uint64
+	for code := range o.statuses {
+		if n := o.statuses[code].Load(); n > 0 {
+			if codes == nil {
+				codes = make(map[int]uint64)
+			}
+			codes[code] = n
+		}
+	}
+	return codes
+}
+
+// errorCounts returns the count of every error class that occurred, nil
+// without errors.
+func (o *outcomeCounts) errorCounts() map[ErrorClass]uint64 {
+	var classes map[ErrorClass]uint64
+	for i, class := range errorClasses {
+		if n := o.classes[i].Load(); n > 0 {
+			if classes == nil {
+				classes = make(map[ErrorClass]uint64)
+			}
+			classes[class] = n
+		}
+	}
+	return classes
+}
+
+func (o *outcomeCounts) reset() {
+	for i := range o.statuses {
+		o.statuses[i].Store(0)
+	}
+	for i := range o.classes {
+		o.classes[i].Store(0)
+	}
+}
+
+// formatOutcomes lists the counts of statuses, by code, then those of
+// errors, in the order of their classes: "200: 950, 404: 3, timeout: 2".
+func formatOutcomes(statuses map[int]uint64, errs map[ErrorClass]uint64) string {
+	var parts []string
+	for _, code := range slices.Sorted(maps.Keys(statuses)) {
+		parts = append(parts, strconv.Itoa(code)+": "+strconv.FormatUint(statuses[code], 10))
+	}
+	for _, class := range errorClasses {
+		if n := errs[class]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", class, n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ErrorClasses returns the error classes in the order they are
+// reported.
+func ErrorClasses() []ErrorClass {
+	return slices.Clone(errorClasses[:])
+}
@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/logs"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestClassifyError(t *testing.T) {
+	urlErr := func(err error) error { return &url.Error{Op: "Post", URL: "https://api.example.com", Err: err} }
+
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{name: "a cancelled request", err: urlErr(context.Canceled), want: ErrorClassCancelled},
+		{name: "an expired deadline", err: urlErr(context.DeadlineExceeded), want: ErrorClassTimeout},
+		{name: "a read timeout", err: urlErr(&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}), want: ErrorClassTimeout},
+		{name: "an unknown host", err: urlErr(&net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "api.example.com", IsNotFound: true}}), want: ErrorClassDNS},
+		{name: "a DNS timeout", err: urlErr(&net.DNSError{Err: "i/o timeout", IsTimeout: true}), want: ErrorClassDNS},
+		{name: "a refused connection", err: urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), want: ErrorClassConnectionRefused},
+		{name: "an unknown certificate authority", err: urlErr(&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}), want: ErrorClassTLS},
+		{name: "a server that doesn't speak TLS", err: urlErr(tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}), want: ErrorClassTLS},
+		{name: "anything else", err: urlErr(errors.New("unexpected EOF")), want: ErrorClassOther},
+	}
+	for _, tt := range tests {
+		t.Run("Should classify "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyError(tt.err))
+		})
+	}
+
+	t.Run("Should classify a refused connection from the client", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := l.Addr().String()
+		require.NoError(t, l.Close())
+
+		_, err = web.NewHttpClient().Get(context.Background(), "http://"+addr, nil)
+		require.Error(t, err)
+		assert.Equal(t, ErrorClassConnectionRefused, classifyError(err))
+	})
+}
+
+func TestOutcomeCounts(t *testing.T) {
+	var o outcomeCounts
+	assert.Nil(t, o.statusCodes())
+	assert.Nil(t, o.errorCounts())
+
+	o.record(200, nil)
+	o.record(200, nil)
+	o.record(404, nil)
+	o.record(0, context.DeadlineExceeded)
+	o.record(0, errors.New("boom"))
+
+	assert.Equal(t, map[int]uint64{200: 2, 404: 1}, o.statusCodes())
+	assert.Equal(t, map[ErrorClass]uint64{ErrorClassTimeout: 1, ErrorClassOther: 1}, o.errorCounts())
+	assert.Equal(t, "200: 2, 404: 1, timeout: 1, other: 1", formatOutcomes(o.statusCodes(), o.errorCounts()))
+
+	o.reset()
+	assert.Nil(t, o.statusCodes())
+	assert.Nil(t, o.errorCounts())
+}
+
+// TestProcessor_Do_Outcomes proves the final outcome of every request
+// is counted by status code or error class and listed when the run
+// ends.
+func TestProcessor_Do_Outcomes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id\n1\n2\n3\n"), 0o600))
+
+	p, gatewayMock, loggerMock := newTestProcessor(t, config.CSVConfig{Separator: ","}, 1)
+	gomock.InOrder(
+		gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(web.Response{StatusCode: 200}, nil),
+		gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(web.Response{StatusCode: 404}, nil),
+		gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(web.Response{}, &net.DNSError{Err: "no such host", IsNotFound: true}),
+	)
+	var done logs.LogMessage
+	loggerMock.EXPECT().Add(gomock.Any()).Do(func(msg logs.LogMessage) {
+		done = msg
+	}).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).Times(3)
+
+	p.Do(context.Background(), path)
+	metrics := p.Wait()
+
+	assert.Equal(t, map[int]uint64{200: 1, 404: 1}, metrics.StatusCodes)
+	assert.Equal(t, map[ErrorClass]uint64{ErrorClassDNS: 1}, metrics.TransportErrors)
+	assert.Equal(t, "Finished with 2 errors", done.Text)
+	assert.Equal(t, "200: 1, 404: 1, dns: 1", done.Details)
+	assert.Nil(t, p.GetMetrics().StatusCodes, "the counts are reset for the next run")
+}
@@ -15,6 +15,10 @@ type HttpGateway interface {
 	// Exec executes an HTTP request with the given data map
 	Exec(ctx context.Context, data map[string]string) (web.Response, error)
 
+	// Render renders the request Exec would send for the given data map
+	// without sending it
+	Render(data map[string]string) web.Request
+
 	// UpdateConfig updates the gateway configuration
 	UpdateConfig(method, urlTemplate, bodyTemplate string, headers map[string]string) error
 }
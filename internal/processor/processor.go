@@ -2,9 +2,9 @@ package processor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"path/filepath"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -14,11 +14,13 @@ import (
 	"github.com/anibaldeboni/rapper/internal/logs"
 	"github.com/anibaldeboni/rapper/internal/styles"
 	"github.com/anibaldeboni/rapper/internal/utils"
+	"github.com/anibaldeboni/rapper/internal/web"
 )
 
 var (
 	reqCount   atomic.Uint64
 	errCount   atomic.Uint64
+	retryCount atomic.Uint64
 	linesCount atomic.Uint64
 	MaxWorkers = runtime.NumCPU()
 )
@@ -28,23 +30,80 @@ type Metrics struct {
 	TotalRequests   uint64
 	SuccessRequests uint64
 	ErrorRequests   uint64
+	RetryRequests   uint64 // extra attempts made by the retry policy, not included in TotalRequests
 	LinesProcessed  uint64
 	ActiveWorkers   int
 	RequestsPerSec  float64
 	StartTime       time.Time
 	IsProcessing    bool
+
+	// TotalLines is the number of rows of the file, 0 while unknown and
+	// for a stream; TotalEstimated is set while it is extrapolated from
+	// the bytes read. PercentComplete goes from 0 to 100 and ETA is 0
+	// while unknown.
+	TotalLines      uint64
+	TotalEstimated  bool
+	PercentComplete float64
+	ETA             time.Duration
+
+	// LatencyP50, LatencyP90 and LatencyP99 are the latencies half,
+	// nine in ten and 99 in 100 requests took at most, and LatencyMax
+	// that of the slowest one. Every attempt of a retried request
+	// counts.
+	LatencyP50 time.Duration
+	LatencyP90 time.Duration
+	LatencyP99 time.Duration
+	LatencyMax time.Duration
+
+	// StatusCodes counts the requests by the status code of their
+	// response and TransportErrors those that got none by the class
+	// of their error. Both are nil while empty.
+	StatusCodes     map[int]uint64
+	TransportErrors map[ErrorClass]uint64
 }
 
 type csvLineMap map[string]string
 
+// csvRow is a row queued for the workers along with its line number,
+// which the progress tracker uses to checkpoint the run, and the source
+// record with every column, which is what the failed rows file keeps.
+type csvRow struct {
+	line   uint64
+	data   csvLineMap
+	record []string
+}
+
+// runState is what the workers of a single run share besides the rows.
+type runState struct {
+	tracker *progressTracker
+	failed  *rowsFile // nil unless csv.failed_rows is enabled
+	results *rowsFile // nil unless csv.results is enabled
+	dryRun  bool
+
+	// steps are the requests sent for every row.
+	steps []chainStep
+}
+
 type processorImpl struct {
 	gateway      HttpGateway
 	logger       RequestLogger
 	csvConfig    config.CSVConfig
+	retry        retryPolicy
+	steps        []chainStep
+	newGateway   newGatewayFunc
+	limiter      *rateLimiter
 	workers      int
+	dryRun       bool
 	mu           sync.Mutex
 	startTime    time.Time
 	isProcessing bool
+	progress     *runProgress
+
+	// runs tracks in-flight Do calls so Wait can block until the
+	// worker pool drains; summary is the metrics snapshot taken just
+	// before the package counters are reset at the end of a run.
+	runs    sync.WaitGroup
+	summary Metrics
 }
 
 // NewProcessor creates a new Processor.
@@ -55,10 +114,14 @@ type processorImpl struct {
 // - workers: The number of workers to be used.
 func NewProcessor(cfg config.CSVConfig, hg HttpGateway, logger RequestLogger, workers int) *processorImpl {
 	return &processorImpl{
-		csvConfig: cfg,
-		gateway:   hg,
-		logger:    logger,
-		workers:   utils.Clamp(workers, 1, MaxWorkers),
+		csvConfig:  cfg,
+		gateway:    hg,
+		logger:     logger,
+		retry:      newRetryPolicy(config.RetryConfig{}),
+		steps:      []chainStep{{gateway: hg}},
+		newGateway: newWebGateway,
+		limiter:    newRateLimiter(config.RateLimitConfig{}),
+		workers:    utils.Clamp(workers, 1, MaxWorkers),
 	}
 }
 
@@ -66,48 +129,171 @@ func NewProcessor(cfg config.CSVConfig, hg HttpGateway, logger RequestLogger, wo
 // It creates a channel to receive the output from the mapCSV function and spawns multiple worker goroutines to process the output concurrently.
 // Once all the workers have finished processing, it checks if there were any requests processed and logs a message if there were any errors.
 // Finally, it resets the request, error, and lines counters and cancels the context.
+// Progress is checkpointed next to the file while the run is in progress
+// and the checkpoint is removed once every line was processed.
 func (p *processorImpl) Do(ctx context.Context, filePath string) (context.Context, context.CancelFunc) {
+	return p.run(ctx, runInput{name: filePath}, Checkpoint{})
+}
+
+// DoStream works like Do but reads the rows from r, a stream such as
+// standard input, named name in the logs. The stream holds CSV or, if
+// it starts with "{", JSON Lines, compressed or not. Its length is
+// unknown until it ends, and as it can't be read again it is neither
+// checkpointed nor given failed rows and results files.
+func (p *processorImpl) DoStream(ctx context.Context, name string, r io.Reader) (context.Context, context.CancelFunc) {
+	return p.run(ctx, runInput{name: name, stream: r}, Checkpoint{})
+}
+
+// Resume works like Do but skips the lines cp records as completed, so
+// an interrupted run picks up where it stopped. Lines that were in
+// flight when cp was saved are sent again.
+func (p *processorImpl) Resume(ctx context.Context, filePath string, cp Checkpoint) (context.Context, context.CancelFunc) {
+	return p.run(ctx, runInput{name: filePath}, cp)
+}
+
+// Checkpoint returns the checkpoint saved for filePath, if there is one
+// and the file did not change since it was saved.
+func (p *processorImpl) Checkpoint(filePath string) (Checkpoint, bool) {
+	cp, err := LoadCheckpoint(filePath)
+	return cp, err == nil
+}
+
+// runInput is what a run reads its rows from: the file at name or, when
+// stream is set, a stream called name.
+type runInput struct {
+	name   string
+	stream io.Reader
+}
+
+func (in runInput) open(cfg config.CSVConfig, progress *runProgress) (rowSource, error) {
+	if in.stream != nil {
+		return newStreamSource(in.stream, cfg)
+	}
+	return openRowSource(in.name, cfg, &progress.read)
+}
+
+func (p *processorImpl) run(ctx context.Context, in runInput, resume Checkpoint) (context.Context, context.CancelFunc) {
+	filePath := in.name
 	ctx, cancel := context.WithCancel(ctx)
-	rows := p.mapCSV(ctx, filePath)
+
+	// Snapshot csvConfig, workers and the dry-run flag under lock so the
+	// channel buffer, separator, field filter, processing message and
+	// failed rows file all reflect the active configuration at the time
+	// the run started, even if UpdateConfig races with us later.
+	p.mu.Lock()
+	csvConfig := p.csvConfig
+	workers := p.workers
+	dryRun := p.dryRun
+	steps := p.steps
+	p.mu.Unlock()
+
+	base := resume
+	base.File = filePath
+	if in.stream == nil {
+		base.Size, base.Hash, _ = fingerprint(filePath)
+	}
+	st := &runState{tracker: newProgressTracker(base), dryRun: dryRun, steps: steps}
+
+	progress := newRunProgress(ctx, in)
+	rows, headers := p.mapCSV(ctx, in, csvConfig, workers, resume, st.tracker, progress)
 
 	if rows == nil {
 		cancel()
 		return nil, nil
 	}
 
+	resumed := resume.Completed > 0 || len(resume.InFlight) > 0
+	if resumed {
+		p.logger.Add(resumeMessage(resume))
+	}
+
+	// A dry run sends nothing, so it must neither leave a checkpoint
+	// nor remove the one a real run left, nor touch the failed rows or
+	// the results. A stream has nowhere to keep them.
+	stopCheckpoints := func() {}
+	switch {
+	case dryRun:
+		p.logger.Add(dryRunStartMessage())
+	case in.stream != nil:
+		if csvConfig.FailedRows.Enabled || csvConfig.Results.Enabled {
+			p.logger.Add(streamRowsFilesMessage())
+		}
+	default:
+		if csvConfig.FailedRows.Enabled {
+			failed, err := newFailedRows(filePath, headers, csvSep(csvConfig), csvConfig.FailedRows, resumed)
+			if err != nil {
+				p.logger.Add(rowsFileError(FailedRowsPath(filePath), err))
+			}
+			st.failed = failed
+		}
+		if csvConfig.Results.Enabled {
+			results, err := newResults(filePath, headers, csvSep(csvConfig), csvConfig.Results, captureNames(steps), resumed)
+			if err != nil {
+				p.logger.Add(rowsFileError(ResultsPath(filePath), err))
+			}
+			st.results = results
+		}
+		stopCheckpoints = p.keepCheckpoint(filePath, st)
+	}
+
 	// Mark processing as started
 	p.mu.Lock()
 	p.startTime = time.Now()
 	p.isProcessing = true
+	p.progress = progress
 	p.mu.Unlock()
+	p.runs.Add(1)
 
 	wg := &sync.WaitGroup{}
-	wg.Add(p.workers)
-	for i := 0; i < p.workers; i++ {
-		go p.worker(ctx, wg, rows)
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.worker(ctx, wg, rows, st)
 	}
 
 	go func() {
+		defer p.runs.Done()
 		wg.Wait()
-
-		// Mark processing as finished
+		stopCheckpoints()
+		failedCount, failedErr := st.failed.close()
+		resultsCount, resultsErr := st.results.close()
+
+		// Keep the final counters around for Wait before they are
+		// reset for the next run, then mark processing as finished.
+		summary := p.GetMetrics()
+		summary.IsProcessing = false
 		p.mu.Lock()
+		p.summary = summary
 		p.isProcessing = false
 		p.mu.Unlock()
 
 		if reqCount.Load() > 0 {
-			p.logger.Add(doneMessage(errCount.Load()))
+			p.logger.Add(doneMessage(errCount.Load(), outcomes.statusCodes(), outcomes.errorCounts()))
+		}
+		if failedErr != nil {
+			p.logger.Add(rowsFileError(st.failed.path, failedErr))
+		}
+		if failedCount > 0 {
+			p.logger.Add(failedRowsMessage(st.failed.path, failedCount))
+		}
+		if resultsErr != nil {
+			p.logger.Add(rowsFileError(st.results.path, resultsErr))
+		}
+		if resultsCount > 0 {
+			p.logger.Add(resultsMessage(st.results.path, resultsCount))
 		}
 		reqCount.Store(0)
 		errCount.Store(0)
+		retryCount.Store(0)
 		linesCount.Store(0)
+		latencies.reset()
+		outcomes.reset()
 		cancel()
 	}()
 
 	return ctx, cancel
 }
 
-func (p *processorImpl) worker(ctx context.Context, wg *sync.WaitGroup, rows <-chan csvLineMap) {
+func (p *processorImpl) worker(ctx context.Context, wg *sync.WaitGroup, rows <-chan csvRow, st *runState) {
 	defer wg.Done()
 
 requests:
@@ -123,91 +309,272 @@ requests:
 			)
 			break requests
 		default:
-			res, err := p.gateway.Exec(ctx, row)
+			if st.dryRun {
+				p.render(st.steps, row.data)
+				st.tracker.finish(row.line)
+				continue
+			}
+			r := p.execChain(ctx, st.steps, row.data)
+			res, err, reason, captured := r.res, r.err, r.reason, r.captured
 			reqCount.Add(1)
+			outcomes.record(res.StatusCode, err)
+			var step string
+			if r.failed != nil {
+				step = r.failed.name
+			}
 			switch {
 			case err != nil:
 				errCount.Add(1)
-				p.logger.Add(logs.NewMessage("Could not connect to "+res.URL, logs.WithDetail(err.Error()), logs.WithIcon(styles.IconSkull), logs.AsError()))
-			case res.StatusCode >= 200 && res.StatusCode < 300:
+				p.logger.Add(inStep(step, logs.NewMessage("Could not connect to "+res.URL, logs.WithDetail(err.Error()), logs.WithIcon(styles.IconSkull), logs.AsError())))
+				// A request cut short by cancellation didn't fail; it
+				// is sent again when the run is resumed.
+				if ctx.Err() == nil {
+					p.addRow(st.failed, row, outcomeValues(res.StatusCode, err))
+				}
+			case reason == "":
 				// Success path: surface the response in the in-memory
 				// log so the TUI shows every successful request, not
 				// just failures. The TUI renderer picks the row color
 				// from the LogType embedded in the message.
 				p.logger.Add(logs.NewHTTPMessage(res))
+			case !r.failed.assertions.configured():
+				// Without assertions a non-2xx status speaks for
+				// itself.
+				errCount.Add(1)
+				p.logger.Add(inStep(step, logs.NewHTTPMessage(res)))
+				p.addRow(st.failed, row, outcomeValues(res.StatusCode, err))
 			default:
 				errCount.Add(1)
-				p.logger.Add(logs.NewHTTPMessage(res))
+				p.logger.Add(inStep(step, assertionMessage(res, reason)))
+				p.addRow(st.failed, row, outcomeValues(res.StatusCode, errors.New(reason)))
 			}
 			p.logger.WriteToFile(&RequestLine{
-				URL:    res.URL,
-				Method: res.Method,
-				Status: res.StatusCode,
-				Body:   res.Body,
-				Error:  err,
+				URL:      res.URL,
+				Method:   res.Method,
+				Status:   res.StatusCode,
+				Body:     res.Body,
+				Error:    err,
+				Reason:   reason,
+				Step:     step,
+				Captured: captured,
+				Duration: res.Latency.Milliseconds(),
 			})
+			// A request cut short by cancellation stays in flight so
+			// resuming the run sends it again.
+			if err == nil || ctx.Err() == nil {
+				p.addRow(st.results, row, joinValues(captured, outcomeValues(res.StatusCode, nil)))
+				st.tracker.finish(row.line)
+			}
+		}
+	}
+}
+
+// addRow writes the source record of row with values to f, if f is
+// enabled. Only the first failure to write to f is logged.
+func (p *processorImpl) addRow(f *rowsFile, row csvRow, values map[string]string) {
+	if err := f.add(row.record, values); err != nil {
+		f.reported.Do(func() { p.logger.Add(rowsFileError(f.path, err)) })
+	}
+}
+
+// render logs and writes the requests row would produce instead of
+// sending them. Rows that render a variable missing from the CSV are
+// counted as errors so the summary flags them.
+func (p *processorImpl) render(steps []chainStep, row csvLineMap) {
+	reqCount.Add(1)
+	var failed bool
+	for i, step := range steps {
+		req := step.gateway.Render(dryRunVars(steps, i, row))
+		missing := req.MissingValues()
+		failed = failed || len(missing) > 0
+
+		p.logger.Add(inStep(step.name, dryRunMessage(req, missing)))
+		p.logger.WriteToFile(&DryRunLine{
+			Step:    step.name,
+			Method:  req.Method,
+			URL:     req.URL,
+			Headers: req.Headers,
+			Body:    string(req.Body),
+			Missing: missing,
+		})
+	}
+	if failed {
+		errCount.Add(1)
+	}
+}
+
+// keepCheckpoint saves the tracker's progress next to filePath every
+// checkpointInterval until the returned stop function is called. Stop
+// saves the final progress, or removes the checkpoint when the whole
+// file was processed. Only the first failure to save is logged. The
+// failed rows and results files are flushed before every save, since
+// the rows they hold are not sent again on resume; a checkpoint whose
+// rows could not be flushed is not saved.
+func (p *processorImpl) keepCheckpoint(filePath string, st *runState) (stop func()) {
+	tracker := st.tracker
+	var (
+		failed bool
+		done   = make(chan struct{})
+		exited = make(chan struct{})
+	)
+	save := func() {
+		cp, changed := tracker.snapshot()
+		if !changed {
+			return
+		}
+		// Rows are added to the files before their line is finished,
+		// so flushing after the snapshot covers every line it marks
+		// done.
+		for _, f := range []*rowsFile{st.failed, st.results} {
+			if err := f.flush(); err != nil {
+				f.reported.Do(func() { p.logger.Add(rowsFileError(f.path, err)) })
+				return
+			}
+		}
+		if err := saveCheckpoint(filePath, cp); err != nil && !failed {
+			failed = true
+			p.logger.Add(checkpointError(err))
+		}
+	}
+
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(checkpointInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				save()
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-exited
+		if !tracker.complete() {
+			save()
+			return
+		}
+		if err := RemoveCheckpoint(filePath); err != nil {
+			p.logger.Add(checkpointError(err))
 		}
 	}
 }
 
-func (p *processorImpl) mapCSV(ctx context.Context, filePath string) <-chan csvLineMap {
-	// Snapshot csvConfig and workers under lock so the channel buffer, separator,
-	// field filter, and processing message all reflect the active configuration
-	// at the time mapCSV was called, even if UpdateConfig races with us later.
+// exec sends the request gateway renders for row, repeating it while the retry policy
+// considers the outcome transient. Every attempt waits on the shared
+// rate limiter first. Every retry is logged and counted separately
+// from the row's final outcome, which is what is returned.
+func (p *processorImpl) exec(ctx context.Context, gateway HttpGateway, row csvLineMap) (web.Response, error) {
 	p.mu.Lock()
-	csvConfig := p.csvConfig
-	workers := p.workers
+	policy := p.retry
 	p.mu.Unlock()
 
-	rows := make(chan csvLineMap, workers)
+	// The URL is only rendered up front when a host override may apply.
+	var target string
+	if p.limiter.hasHostLimits() {
+		target = gateway.Render(row).URL
+	}
 
-	reader, file, err := newCSVReader(filePath, csvSep(csvConfig))
-	if err != nil {
-		p.logger.Add(csvError(err.Error()))
-		return nil
+	for attempt := 1; ; attempt++ {
+		if !p.limiter.wait(ctx, target) {
+			// The worker names the URL it didn't get to request.
+			if target == "" {
+				target = gateway.Render(row).URL
+			}
+			return web.Response{URL: target}, ctx.Err()
+		}
+
+		res, err := gateway.Exec(ctx, row)
+		// A request cut short by cancellation says nothing of the
+		// API's latency.
+		if res.Latency > 0 && ctx.Err() == nil {
+			latencies.record(res.Latency)
+		}
+		if !policy.shouldRetry(attempt, res, err) {
+			return res, err
+		}
+
+		wait := policy.delay(attempt, res)
+		retryCount.Add(1)
+		p.logger.Add(retryMessage(attempt+1, policy.maxAttempts, wait, res, err))
+		if !sleep(ctx, wait) {
+			return res, err
+		}
 	}
+}
 
-	headers, err := readCSVHeaders(reader)
+// mapCSV streams the rows of in, a CSV or JSON Lines file or stream,
+// to the workers, skipping the lines resume records as completed and
+// reporting every line to the tracker and to progress.
+func (p *processorImpl) mapCSV(ctx context.Context, in runInput, csvConfig config.CSVConfig, workers int, resume Checkpoint, tracker *progressTracker, progress *runProgress) (<-chan csvRow, []string) {
+	rows := make(chan csvRow, workers)
+
+	source, err := in.open(csvConfig, progress)
 	if err != nil {
 		p.logger.Add(csvError(err.Error()))
-		return nil
+		return nil, nil
 	}
+	headers := source.headers()
 
 	indexes := buildFilteredFieldIndex(headers, csvConfig.Fields)
 
 	p.logger.Add(
 		logs.NewMessage(
-			fmt.Sprintf("Processing file %s using %s", styles.Green(filepath.Base(filePath)), workersMsg(workers)),
+			fmt.Sprintf("Processing %s using %s", inputName(in), workersMsg(workers)),
 			logs.WithIcon(styles.IconWomanDancing),
 			logs.AsGeneral(),
 		),
 	)
 
 	go func() {
-		defer file.Close()
+		defer source.Close()
 		defer close(rows)
 
+		var line uint64
 	read:
 		for {
 			select {
 			case <-ctx.Done():
 				break read
 			default:
-				record, err := reader.Read()
+				record, err := source.next()
 				if err == io.EOF {
+					tracker.reachedEOF()
+					progress.eof.Store(true)
+					break read
+				}
+				var srcErr *sourceError
+				if errors.As(err, &srcErr) {
+					p.logger.Add(csvError(err.Error()))
 					break read
 				}
+				line++
+				progress.position.Store(line)
 				if err != nil {
 					p.logger.Add(csvError(err.Error()))
+					tracker.finish(line)
 					continue
 				}
+				if resume.done(line) {
+					tracker.finish(line)
+					continue
+				}
+				tracker.start(line)
 				linesCount.Add(1)
-				rows <- mapRow(headers, indexes, record)
+				select {
+				case rows <- csvRow{line: line, data: mapRow(headers, indexes, record), record: record}:
+				case <-ctx.Done():
+					break read
+				}
 			}
 		}
 	}()
 
-	return rows
+	return rows, headers
 }
 
 // GetMetrics returns current processing metrics
@@ -219,26 +586,56 @@ func (p *processorImpl) GetMetrics() Metrics {
 	errReq := errCount.Load()
 	successReq := totalReq - errReq
 
-	var reqPerSec float64
+	lines := linesCount.Load()
+	var (
+		reqPerSec float64
+		elapsed   time.Duration
+	)
 	if p.isProcessing && !p.startTime.IsZero() {
-		elapsed := time.Since(p.startTime).Seconds()
+		elapsed = time.Since(p.startTime)
 		if elapsed > 0 {
-			reqPerSec = float64(totalReq) / elapsed
+			reqPerSec = float64(totalReq) / elapsed.Seconds()
 		}
 	}
+	total, estimated, percent, eta := p.progress.estimate(lines, elapsed)
 
 	return Metrics{
 		TotalRequests:   totalReq,
 		SuccessRequests: successReq,
 		ErrorRequests:   errReq,
-		LinesProcessed:  linesCount.Load(),
+		RetryRequests:   retryCount.Load(),
+		LinesProcessed:  lines,
 		ActiveWorkers:   p.workers,
 		RequestsPerSec:  reqPerSec,
 		StartTime:       p.startTime,
 		IsProcessing:    p.isProcessing,
+		TotalLines:      total,
+		TotalEstimated:  estimated,
+		PercentComplete: percent,
+		ETA:             eta,
+		LatencyP50:      latencies.quantile(0.5),
+		LatencyP90:      latencies.quantile(0.9),
+		LatencyP99:      latencies.quantile(0.99),
+		LatencyMax:      latencies.maxLatency(),
+		StatusCodes:     outcomes.statusCodes(),
+		TransportErrors: outcomes.errorCounts(),
 	}
 }
 
+// Wait blocks until every run started by Do has finished and returns
+// the metrics of the last one. Unlike the context returned by Do,
+// which is cancelled as soon as the parent context is, Wait only
+// returns once the workers have drained. Used by the headless runner
+// to print the final summary and decide the exit code.
+func (p *processorImpl) Wait() Metrics {
+	p.runs.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.summary
+}
+
 // SetWorkers dynamically adjusts the number of workers
 // Note: This only affects the next processing run, not current processing
 func (p *processorImpl) SetWorkers(n int) {
@@ -258,6 +655,57 @@ func (p *processorImpl) UpdateConfig(cfg config.CSVConfig) {
 	p.csvConfig = cfg
 }
 
+// UpdateRequestConfig replaces the requests sent for every row and the
+// policies the processor applies around them: the retry policy, the
+// response assertions and captures, and the steps of a multi-step
+// chain. Called at startup and from the OnChange callback, like
+// UpdateConfig; rows already being retried finish with the policy they
+// started with. A step whose templates don't parse is an error and
+// leaves the previous configuration in place.
+func (p *processorImpl) UpdateRequestConfig(cfg config.RequestConfig) error {
+	steps, err := p.buildChain(cfg)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.retry = newRetryPolicy(cfg.Retry)
+	p.steps = steps
+	return nil
+}
+
+// UpdateRateLimit replaces the rate limits shared by the workers. Called
+// at startup and from the OnChange callback; it takes effect
+// immediately, including for a run in progress.
+func (p *processorImpl) UpdateRateLimit(cfg config.RateLimitConfig) {
+	p.limiter.configure(cfg)
+}
+
+// SetRateLimit changes the global requests per second limit (0 means
+// unlimited) while keeping the burst and the per-host overrides. Used
+// by the Settings view to throttle a run in progress.
+func (p *processorImpl) SetRateLimit(rps float64) {
+	p.limiter.setGlobalRate(rps)
+}
+
+// GetRateLimit returns the global requests per second limit (0 means
+// unlimited).
+func (p *processorImpl) GetRateLimit() float64 {
+	return p.limiter.globalRate()
+}
+
+// SetDryRun turns dry-run mode on or off for the next run. In dry-run
+// mode rows are rendered, logged and written to the output file but
+// never sent.
+func (p *processorImpl) SetDryRun(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.dryRun = enabled
+}
+
 // GetWorkerCount returns the current configured worker count
 func (p *processorImpl) GetWorkerCount() int {
 	p.mu.Lock()
@@ -36,6 +36,9 @@ func newTestProcessor(t *testing.T, csvCfg config.CSVConfig, workers int) (
 	gatewayMock := mock_processor.NewMockHttpGateway(ctrl)
 	loggerMock := mock_processor.NewMockRequestLogger(ctrl)
 	p := NewProcessor(csvCfg, gatewayMock, loggerMock, workers)
+	// The counters are package-level: a run still draining would reset
+	// them in the middle of the next test.
+	t.Cleanup(func() { p.Wait() })
 	return p, gatewayMock, loggerMock
 }
 
@@ -250,11 +253,47 @@ func TestProcessor_Do_LogsClientErrorOnFourXX(t *testing.T) {
 	assert.True(t, sawClientError, "worker must call logger.Add with LogTypeClientError for a 4xx response")
 }
 
+// TestProcessor_Wait proves that Wait blocks until the worker pool
+// drains and returns the counters of the finished run, even though
+// the package counters are reset once the run completes.
+func TestProcessor_Wait(t *testing.T) {
+	csvData := "header1\nvalue1\nvalue2\nvalue3\n"
+	tempFile := createCsvFile(t, csvData)
+	defer os.Remove(tempFile.Name())
+
+	csvCfg := config.CSVConfig{Fields: []string{"header1"}, Separator: ","}
+	p, gatewayMock, loggerMock := newTestProcessor(t, csvCfg, 1)
+
+	gatewayMock.EXPECT().
+		Exec(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, row map[string]string) (web.Response, error) {
+			if row["header1"] == "value2" {
+				return web.Response{StatusCode: 500}, nil
+			}
+			return web.Response{StatusCode: 200}, nil
+		}).Times(3)
+	loggerMock.EXPECT().Add(gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).Times(3)
+
+	ctx, _ := p.Do(context.Background(), tempFile.Name())
+	assert.NotNil(t, ctx)
+
+	summary := p.Wait()
+	assert.Equal(t, uint64(3), summary.LinesProcessed)
+	assert.Equal(t, uint64(3), summary.TotalRequests)
+	assert.Equal(t, uint64(2), summary.SuccessRequests)
+	assert.Equal(t, uint64(1), summary.ErrorRequests)
+	assert.False(t, summary.IsProcessing)
+	assert.Zero(t, p.GetMetrics().TotalRequests, "counters must be reset for the next run")
+}
+
 func createCsvFile(t *testing.T, csvData string) *os.File {
 	tempFile, err := os.CreateTemp("", "test-*.csv")
 	if err != nil {
 		t.Fatalf("Failed to create temporary file: %v", err)
 	}
+	// Runs cut short leave a checkpoint next to the file.
+	t.Cleanup(func() { _ = RemoveCheckpoint(tempFile.Name()) })
 	_, err = tempFile.WriteString(csvData)
 	if err != nil {
 		t.Fatalf("Failed to write to temporary file: %v", err)
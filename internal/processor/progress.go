@@ -0,0 +1,149 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// prescanLimit is the size of the largest file whose rows are counted
+// while it is processed. The total of larger files, and of compressed
+// ones, is estimated from how much of the file was read.
+const prescanLimit = 256 << 20
+
+// errCompressed stops the pre-scan of a compressed file, whose lines
+// can't be counted without decompressing it twice.
+var errCompressed = errors.New("compressed file")
+
+// runProgress follows how far a run got through its input, to tell the
+// total number of rows, how much of them is done and the time left.
+type runProgress struct {
+	size     int64         // of the file, 0 for a stream or an empty file
+	read     atomic.Int64  // bytes of the file read so far
+	position atomic.Uint64 // the last line read, skipped lines included
+	counted  atomic.Uint64 // rows counted by the pre-scan, once scanned
+	scanned  atomic.Bool
+	eof      atomic.Bool
+}
+
+// newRunProgress starts following a run of in. The rows of a file no
+// larger than prescanLimit are counted in the background until ctx is
+// done.
+func newRunProgress(ctx context.Context, in runInput) *runProgress {
+	r := &runProgress{}
+	if in.stream != nil {
+		return r
+	}
+	info, err := os.Stat(in.name)
+	if err != nil {
+		return r
+	}
+	r.size = info.Size()
+	if r.size <= prescanLimit {
+		go func() {
+			if rows, err := countRows(ctx, in.name, !isJSONL(in.name)); err == nil {
+				r.counted.Store(rows)
+				r.scanned.Store(true)
+			}
+		}()
+	}
+	return r
+}
+
+// estimate returns the total number of rows, whether it is an estimate,
+// the percentage of them read and the time left at the pace of the
+// processed rows, which exclude the lines a resumed run skipped. The
+// total is 0 when it is unknown, as for a stream.
+func (r *runProgress) estimate(processed uint64, elapsed time.Duration) (total uint64, estimated bool, percent float64, eta time.Duration) {
+	if r == nil || r.size == 0 {
+		return 0, false, 0, 0
+	}
+	position := r.position.Load()
+	switch read := r.read.Load(); {
+	case r.eof.Load():
+		total = position
+	case r.scanned.Load():
+		total = max(r.counted.Load(), position)
+	case read > 0:
+		total = max(uint64(float64(position)*float64(r.size)/float64(read)), position)
+		estimated = true
+	default:
+		return 0, false, 0, 0
+	}
+
+	if total == 0 {
+		return 0, estimated, 0, 0
+	}
+	percent = 100 * float64(position) / float64(total)
+	if processed > 0 && position < total {
+		eta = time.Duration(float64(elapsed) / float64(processed) * float64(total-position))
+	}
+	return total, estimated, percent, eta
+}
+
+// countRows counts the rows of the file at filePath: its lines that
+// aren't blank, less the header line if it has one. A quoted field
+// spanning lines counts once per line, so a CSV file with such fields
+// is overcounted.
+func countRows(ctx context.Context, filePath string, header bool) (uint64, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 64<<10)
+	var lines uint64
+	blank, first := true, true
+	for {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		n, err := f.Read(buf)
+		if first && (bytes.HasPrefix(buf[:n], gzipMagic) || bytes.HasPrefix(buf[:n], zstdMagic)) {
+			return 0, errCompressed
+		}
+		first = false
+		for _, c := range buf[:n] {
+			switch c {
+			case '\n':
+				if !blank {
+					lines++
+				}
+				blank = true
+			case ' ', '\t', '\r':
+			default:
+				blank = false
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if !blank {
+		lines++
+	}
+	if header && lines > 0 {
+		lines--
+	}
+	return lines, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
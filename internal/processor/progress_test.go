@@ -0,0 +1,128 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCountRows(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name   string
+		data   []byte
+		header bool
+		want   uint64
+	}{
+		{name: "CSV", data: []byte("id\n1\n2\n3\n"), header: true, want: 3},
+		{name: "CSV without a final line break", data: []byte("id\r\n1\r\n2"), header: true, want: 2},
+		{name: "blank lines", data: []byte("id\n\n1\n  \n2\n\n"), header: true, want: 2},
+		{name: "JSON Lines", data: []byte(`{"id": 1}` + "\n" + `{"id": 2}` + "\n"), want: 2},
+		{name: "header only", data: []byte("id\n"), header: true, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run("Should count the rows of "+tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "rows")
+			require.NoError(t, os.WriteFile(path, tt.data, 0o600))
+
+			rows, err := countRows(context.Background(), path, tt.header)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rows)
+		})
+	}
+
+	t.Run("Should not count the rows of a compressed file", func(t *testing.T) {
+		path := filepath.Join(dir, "rows.csv.gz")
+		require.NoError(t, os.WriteFile(path, gzipped(t, "id\n1\n"), 0o600))
+
+		_, err := countRows(context.Background(), path, true)
+		assert.ErrorIs(t, err, errCompressed)
+	})
+}
+
+func TestRunProgress_Estimate(t *testing.T) {
+	t.Run("Should be unknown for a stream", func(t *testing.T) {
+		r := &runProgress{}
+		r.position.Store(10)
+		r.eof.Store(true)
+
+		total, _, percent, eta := r.estimate(10, time.Second)
+		assert.Zero(t, total)
+		assert.Zero(t, percent)
+		assert.Zero(t, eta)
+	})
+
+	t.Run("Should use the counted rows", func(t *testing.T) {
+		r := &runProgress{size: 1000}
+		r.counted.Store(100)
+		r.scanned.Store(true)
+		r.position.Store(25)
+
+		total, estimated, percent, eta := r.estimate(25, 10*time.Second)
+		assert.Equal(t, uint64(100), total)
+		assert.False(t, estimated)
+		assert.Equal(t, 25.0, percent)
+		assert.Equal(t, 30*time.Second, eta)
+	})
+
+	t.Run("Should extrapolate the total from the bytes read", func(t *testing.T) {
+		r := &runProgress{size: 1000}
+		r.read.Store(250)
+		r.position.Store(50)
+
+		total, estimated, percent, _ := r.estimate(50, time.Second)
+		assert.Equal(t, uint64(200), total)
+		assert.True(t, estimated)
+		assert.Equal(t, 25.0, percent)
+	})
+
+	t.Run("Should time the rows left at the pace of a resumed run", func(t *testing.T) {
+		r := &runProgress{size: 1000}
+		r.counted.Store(100)
+		r.scanned.Store(true)
+		r.position.Store(60) // 50 skipped, 10 processed
+
+		_, _, percent, eta := r.estimate(10, 10*time.Second)
+		assert.Equal(t, 60.0, percent)
+		assert.Equal(t, 40*time.Second, eta)
+	})
+
+	t.Run("Should be complete at the end of the file", func(t *testing.T) {
+		r := &runProgress{size: 1000}
+		r.read.Store(1000)
+		r.position.Store(42)
+		r.eof.Store(true)
+
+		total, estimated, percent, eta := r.estimate(42, time.Second)
+		assert.Equal(t, uint64(42), total)
+		assert.False(t, estimated)
+		assert.Equal(t, 100.0, percent)
+		assert.Zero(t, eta)
+	})
+}
+
+func TestProcessor_Do_TotalLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id\n1\n2\n3\n"), 0o600))
+
+	p, gatewayMock, loggerMock := newTestProcessor(t, config.CSVConfig{Separator: ","}, 1)
+	gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(web.Response{StatusCode: 200}, nil).Times(3)
+	loggerMock.EXPECT().Add(gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).Times(3)
+
+	p.Do(context.Background(), path)
+	metrics := p.Wait()
+
+	assert.Equal(t, uint64(3), metrics.TotalLines)
+	assert.False(t, metrics.TotalEstimated)
+	assert.Equal(t, 100.0, metrics.PercentComplete)
+	assert.Zero(t, metrics.ETA)
+}
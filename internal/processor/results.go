@@ -0,0 +1,32 @@
+package processor
+
+import (
+	"maps"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+)
+
+// ResultsPath returns where the results of filePath are written:
+// "users.csv" becomes "users.results.csv", next to the original.
+func ResultsPath(filePath string) string {
+	return siblingPath(filePath, "results")
+}
+
+// newResults prepares the file that joins every row of filePath sent
+// with the values captured from its response, one column per capture
+// name.
+func newResults(filePath string, headers []string, sep rune, cfg config.ResultsConfig, names []string, resume bool) (*rowsFile, error) {
+	columns := names
+	if cfg.StatusColumn {
+		columns = append(columns[:len(columns):len(columns)], statusColumn)
+	}
+	return newRowsFile(ResultsPath(filePath), headers, sep, columns, resume)
+}
+
+// joinValues merges the column values of a results row.
+func joinValues(captured, outcome map[string]string) map[string]string {
+	values := make(map[string]string, len(captured)+len(outcome))
+	maps.Copy(values, captured)
+	maps.Copy(values, outcome)
+	return values
+}
@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/utils"
+	"github.com/anibaldeboni/rapper/internal/web"
+)
+
+// retryPolicy decides whether a request attempt should be repeated and
+// how long to wait before doing so. It is built from the profile's
+// request.retry block by newRetryPolicy, which fills in the defaults.
+type retryPolicy struct {
+	maxAttempts      int
+	baseDelay        time.Duration
+	maxDelay         time.Duration
+	jitter           float64
+	statusCodes      []int
+	ignoreRetryAfter bool
+}
+
+func newRetryPolicy(cfg config.RetryConfig) retryPolicy {
+	p := retryPolicy{
+		maxAttempts:      max(cfg.MaxAttempts, 1),
+		baseDelay:        cfg.BaseDelay,
+		maxDelay:         cfg.MaxDelay,
+		jitter:           utils.Clamp(cfg.Jitter, 0, 1),
+		statusCodes:      cfg.StatusCodes,
+		ignoreRetryAfter: cfg.IgnoreRetryAfter,
+	}
+	if p.baseDelay <= 0 {
+		p.baseDelay = config.DefaultRetryBaseDelay
+	}
+	if p.maxDelay <= 0 {
+		p.maxDelay = config.DefaultRetryMaxDelay
+	}
+	if len(p.statusCodes) == 0 {
+		p.statusCodes = config.DefaultRetryStatusCodes
+	}
+	return p
+}
+
+// shouldRetry reports whether the outcome of the given attempt
+// (1-based) is transient and there are attempts left. Only transport
+// errors are retried: malformed URLs, unsupported methods and
+// cancellations fail immediately.
+func (p retryPolicy) shouldRetry(attempt int, res web.Response, err error) bool {
+	if attempt >= p.maxAttempts {
+		return false
+	}
+	if err != nil {
+		var urlErr *url.Error
+		return errors.As(err, &urlErr) && urlErr.Op != "parse" &&
+			!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return slices.Contains(p.statusCodes, res.StatusCode)
+}
+
+// delay returns how long to wait after the given failed attempt
+// (1-based). The backoff doubles from baseDelay on every attempt and is
+// capped by maxDelay; a Retry-After header, when honored, replaces it.
+// Jitter then randomizes the configured fraction of the result.
+func (p retryPolicy) delay(attempt int, res web.Response) time.Duration {
+	d := p.maxDelay
+	if shift := attempt - 1; shift < 32 {
+		d = min(p.baseDelay<<shift, p.maxDelay)
+	}
+	if !p.ignoreRetryAfter {
+		if after, ok := retryAfter(res.Headers, time.Now()); ok {
+			d = min(after, p.maxDelay)
+		}
+	}
+	if p.jitter > 0 {
+		d -= time.Duration(p.jitter * utils.RandomFloat64() * float64(d))
+	}
+	return d
+}
+
+// retryAfter parses a Retry-After header expressed either in seconds or
+// as an HTTP date.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	value := h.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done, whichever comes first, and
+// reports whether the full delay elapsed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
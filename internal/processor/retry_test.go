@@ -0,0 +1,194 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/logs"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRetryPolicy_ShouldRetry(t *testing.T) {
+	policy := newRetryPolicy(config.RetryConfig{MaxAttempts: 3})
+	transportErr := &url.Error{Op: "Post", URL: "http://api", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name    string
+		attempt int
+		res     web.Response
+		err     error
+		want    bool
+	}{
+		{name: "When the status is a default transient status", attempt: 1, res: web.Response{StatusCode: 503}, want: true},
+		{name: "When the status is 429", attempt: 2, res: web.Response{StatusCode: 429}, want: true},
+		{name: "When the status is not retryable", attempt: 1, res: web.Response{StatusCode: 400}, want: false},
+		{name: "When the request succeeded", attempt: 1, res: web.Response{StatusCode: 200}, want: false},
+		{name: "When attempts are exhausted", attempt: 3, res: web.Response{StatusCode: 503}, want: false},
+		{name: "When the transport failed", attempt: 1, err: transportErr, want: true},
+		{name: "When the URL is malformed", attempt: 1, err: &url.Error{Op: "parse", Err: errors.New("bad")}, want: false},
+		{name: "When the method is not supported", attempt: 1, err: errors.New("method not supported: FOO"), want: false},
+		{name: "When the request was cancelled", attempt: 1, err: &url.Error{Op: "Post", Err: context.Canceled}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.shouldRetry(tt.attempt, tt.res, tt.err))
+		})
+	}
+
+	t.Run("Should use the configured status codes", func(t *testing.T) {
+		custom := newRetryPolicy(config.RetryConfig{MaxAttempts: 2, StatusCodes: []int{500}})
+		assert.True(t, custom.shouldRetry(1, web.Response{StatusCode: 500}, nil))
+		assert.False(t, custom.shouldRetry(1, web.Response{StatusCode: 503}, nil))
+	})
+
+	t.Run("Should never retry when retries are disabled", func(t *testing.T) {
+		disabled := newRetryPolicy(config.RetryConfig{})
+		assert.False(t, disabled.shouldRetry(1, web.Response{StatusCode: 503}, nil))
+	})
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := newRetryPolicy(config.RetryConfig{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second})
+
+	t.Run("Should back off exponentially up to the max delay", func(t *testing.T) {
+		assert.Equal(t, 100*time.Millisecond, policy.delay(1, web.Response{}))
+		assert.Equal(t, 200*time.Millisecond, policy.delay(2, web.Response{}))
+		assert.Equal(t, 400*time.Millisecond, policy.delay(3, web.Response{}))
+		assert.Equal(t, time.Second, policy.delay(5, web.Response{}))
+		assert.Equal(t, time.Second, policy.delay(64, web.Response{}))
+	})
+
+	t.Run("Should honor Retry-After capped by the max delay", func(t *testing.T) {
+		res := web.Response{Headers: http.Header{"Retry-After": []string{"0"}}}
+		assert.Equal(t, time.Duration(0), policy.delay(3, res))
+
+		res = web.Response{Headers: http.Header{"Retry-After": []string{"120"}}}
+		assert.Equal(t, time.Second, policy.delay(1, res))
+	})
+
+	t.Run("Should ignore Retry-After when configured to", func(t *testing.T) {
+		ignoring := newRetryPolicy(config.RetryConfig{MaxAttempts: 2, BaseDelay: 100 * time.Millisecond, IgnoreRetryAfter: true})
+		res := web.Response{Headers: http.Header{"Retry-After": []string{"0"}}}
+		assert.Equal(t, 100*time.Millisecond, ignoring.delay(1, res))
+	})
+
+	t.Run("Should randomize the jitter fraction of the delay", func(t *testing.T) {
+		jittered := newRetryPolicy(config.RetryConfig{MaxAttempts: 2, BaseDelay: time.Second, Jitter: 0.5})
+		for range 20 {
+			d := jittered.delay(1, web.Response{})
+			assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+			assert.LessOrEqual(t, d, time.Second)
+		}
+	})
+
+	t.Run("Should apply defaults", func(t *testing.T) {
+		defaults := newRetryPolicy(config.RetryConfig{MaxAttempts: 2})
+		assert.Equal(t, config.DefaultRetryBaseDelay, defaults.delay(1, web.Response{}))
+		assert.Equal(t, config.DefaultRetryMaxDelay, defaults.delay(32, web.Response{}))
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	d, ok := retryAfter(http.Header{"Retry-After": []string{"3"}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+
+	d, ok = retryAfter(http.Header{"Retry-After": []string{now.Add(5 * time.Second).Format(http.TimeFormat)}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, d)
+
+	_, ok = retryAfter(http.Header{"Retry-After": []string{"soon"}}, now)
+	assert.False(t, ok)
+
+	_, ok = retryAfter(http.Header{}, now)
+	assert.False(t, ok)
+}
+
+// TestProcessor_Do_RetriesTransientFailures proves the worker repeats a
+// request while the policy considers the response transient, logs each
+// retry as its own warning and counts retries apart from requests.
+func TestProcessor_Do_RetriesTransientFailures(t *testing.T) {
+	tempFile := createCsvFile(t, "header1\nvalue1\n")
+	defer os.Remove(tempFile.Name())
+
+	csvCfg := config.CSVConfig{Fields: []string{"header1"}, Separator: ","}
+	p, gatewayMock, loggerMock := newTestProcessor(t, csvCfg, 1)
+	p.UpdateRequestConfig(config.RequestConfig{
+		Retry: config.RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	})
+
+	gomock.InOrder(
+		gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).
+			Return(web.Response{Method: "POST", URL: "https://api/1", StatusCode: 503}, nil),
+		gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).
+			Return(web.Response{Method: "POST", URL: "https://api/1", StatusCode: 502}, nil),
+		gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).
+			Return(web.Response{Method: "POST", URL: "https://api/1", StatusCode: 200}, nil),
+	)
+
+	var (
+		mu      sync.Mutex
+		retries []logs.LogMessage
+	)
+	loggerMock.EXPECT().Add(gomock.Any()).DoAndReturn(func(m logs.LogMessage) {
+		mu.Lock()
+		defer mu.Unlock()
+		if m.Type == logs.LogTypeWarning {
+			retries = append(retries, m)
+		}
+	}).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).Times(1)
+
+	p.Do(context.Background(), tempFile.Name())
+	summary := p.Wait()
+
+	assert.Equal(t, uint64(1), summary.TotalRequests)
+	assert.Equal(t, uint64(1), summary.SuccessRequests)
+	assert.Equal(t, uint64(2), summary.RetryRequests)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, retries, 2) {
+		assert.Equal(t, "503", retries[0].BadgeIcon)
+		assert.Contains(t, retries[0].Text, "Retrying POST https://api/1")
+		assert.Contains(t, retries[0].Text, "(attempt 2/3)")
+		assert.Equal(t, "502", retries[1].BadgeIcon)
+		assert.Contains(t, retries[1].Text, "(attempt 3/3)")
+	}
+}
+
+// TestProcessor_Do_GivesUpAfterMaxAttempts proves the last transient
+// failure is counted as an error once the attempts are exhausted.
+func TestProcessor_Do_GivesUpAfterMaxAttempts(t *testing.T) {
+	tempFile := createCsvFile(t, "header1\nvalue1\n")
+	defer os.Remove(tempFile.Name())
+
+	csvCfg := config.CSVConfig{Fields: []string{"header1"}, Separator: ","}
+	p, gatewayMock, loggerMock := newTestProcessor(t, csvCfg, 1)
+	p.UpdateRequestConfig(config.RequestConfig{
+		Retry: config.RetryConfig{MaxAttempts: 2, BaseDelay: time.Millisecond},
+	})
+
+	gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).
+		Return(web.Response{StatusCode: 429}, nil).Times(2)
+	loggerMock.EXPECT().Add(gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).Times(1)
+
+	p.Do(context.Background(), tempFile.Name())
+	summary := p.Wait()
+
+	assert.Equal(t, uint64(1), summary.ErrorRequests)
+	assert.Equal(t, uint64(1), summary.RetryRequests)
+}
@@ -0,0 +1,212 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// siblingPath returns the path of a file rapper writes next to
+// filePath: "users.csv" with kind "failed" becomes "users.failed.csv".
+// The file is never compressed: "users.csv.gz" becomes
+// "users.failed.csv" too.
+func siblingPath(filePath, kind string) string {
+	filePath = trimCompressionExt(filePath)
+	ext := filepath.Ext(filePath)
+	return strings.TrimSuffix(filePath, ext) + "." + kind + ext
+}
+
+// rowsFile writes source records, with every column and the original
+// header, plus columns of its own to a file next to the processed one,
+// in its format: CSV, or JSON Lines with one object per record. The
+// file is only created once the first row is added, so a run that adds
+// nothing leaves nothing behind. Processing a file rapper wrote reuses
+// the columns its header already has instead of adding them again.
+//
+// A nil *rowsFile is a disabled file: adding to and closing it do
+// nothing.
+type rowsFile struct {
+	mu       sync.Mutex
+	path     string
+	sep      rune
+	headers  []string
+	columns  map[string]int
+	appendTo bool
+	file     *os.File
+	w        rowWriter
+	count    int
+
+	// reported makes sure only the first failure to write is logged.
+	reported sync.Once
+}
+
+// newRowsFile prepares the file at path. A fresh run discards the file
+// a previous run left; a resumed run appends to it.
+func newRowsFile(path string, headers []string, sep rune, columns []string, resume bool) (*rowsFile, error) {
+	f := &rowsFile{
+		path:     path,
+		sep:      sep,
+		headers:  slices.Clone(headers),
+		columns:  make(map[string]int, len(columns)),
+		appendTo: resume,
+	}
+	for _, name := range columns {
+		f.columns[name] = f.column(name)
+	}
+
+	if !resume {
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// column returns the index of the named header, adding it if missing.
+func (f *rowsFile) column(name string) int {
+	if i := slices.Index(f.headers, name); i >= 0 {
+		return i
+	}
+	f.headers = append(f.headers, name)
+	return len(f.headers) - 1
+}
+
+// add writes record with values in the columns of the file. Columns
+// missing from values are left empty.
+func (f *rowsFile) add(record []string, values map[string]string) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	row := make([]string, len(f.headers))
+	copy(row, record)
+	for name, i := range f.columns {
+		row[i] = values[name]
+	}
+
+	f.count++
+	return f.w.Write(row)
+}
+
+// open creates the file on the first row. The header is written unless
+// rows are appended to a file that already has one.
+func (f *rowsFile) open() error {
+	if f.w != nil {
+		return nil
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	writeHeader := true
+	if f.appendTo {
+		if info, err := os.Stat(f.path); err == nil && info.Size() > 0 {
+			flags = os.O_WRONLY | os.O_APPEND
+			writeHeader = false
+		}
+	}
+
+	file, err := os.OpenFile(f.path, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	f.file = file
+	if isJSONL(f.path) {
+		f.w = &jsonlWriter{w: bufio.NewWriter(file), headers: f.headers}
+		return nil
+	}
+	w := csv.NewWriter(file)
+	w.Comma = f.sep
+	f.w = w
+	if writeHeader {
+		return f.w.Write(f.headers)
+	}
+	return nil
+}
+
+// rowWriter writes the records of a rowsFile. *csv.Writer is one.
+type rowWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
+}
+
+// jsonlWriter writes records as JSON objects keyed by the headers, one
+// per line. Values are written as strings, under the dotted keys a
+// jsonlSource reads them from, so the file can be processed again.
+type jsonlWriter struct {
+	w       *bufio.Writer
+	headers []string
+	err     error
+}
+
+func (j *jsonlWriter) Write(record []string) error {
+	if j.err != nil {
+		return j.err
+	}
+	var line bytes.Buffer
+	line.WriteByte('{')
+	for i, key := range j.headers {
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		v, _ := json.Marshal(record[i])
+		line.Write(k)
+		line.WriteByte(':')
+		line.Write(v)
+	}
+	line.WriteString("}\n")
+	_, j.err = j.w.Write(line.Bytes())
+	return j.err
+}
+
+func (j *jsonlWriter) Flush() {
+	if err := j.w.Flush(); j.err == nil {
+		j.err = err
+	}
+}
+
+func (j *jsonlWriter) Error() error { return j.err }
+
+// flush writes the rows added so far to the file, so a checkpoint
+// that marks their lines done never gets ahead of it.
+func (f *rowsFile) flush() error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.w == nil {
+		return nil
+	}
+	f.w.Flush()
+	return f.w.Error()
+}
+
+// close flushes the file and returns how many rows were written to it.
+func (f *rowsFile) close() (int, error) {
+	if f == nil {
+		return 0, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.w == nil {
+		return 0, nil
+	}
+	f.w.Flush()
+	return f.count, errors.Join(f.w.Error(), f.file.Close())
+}
@@ -0,0 +1,275 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+)
+
+// InputPatterns are the file name patterns of the files rapper
+// processes: CSV and JSON Lines files, compressed or not.
+var InputPatterns = inputPatterns("*.csv", "*.jsonl", "*.ndjson")
+
+func inputPatterns(patterns ...string) []string {
+	all := slices.Clone(patterns)
+	for _, ext := range compressionExts {
+		for _, pattern := range patterns {
+			all = append(all, pattern+ext)
+		}
+	}
+	return all
+}
+
+// rowSource reads the records of an input file. Every record holds the
+// values of the columns returned by headers, in the same order.
+type rowSource interface {
+	headers() []string
+	// next returns the next record, or io.EOF after the last one. A
+	// record that can't be parsed is an error that doesn't stop the
+	// source; a *sourceError does.
+	next() ([]string, error)
+	Close() error
+}
+
+// openRowSource opens filePath as a JSON Lines file if its extension is
+// .jsonl or .ndjson, and as a CSV file otherwise. Compressed files are
+// decompressed as they are read. Unless read is nil, it counts the
+// bytes read from the file.
+func openRowSource(filePath string, cfg config.CSVConfig, read *atomic.Int64) (rowSource, error) {
+	in, err := openInput(filePath, read)
+	if err != nil {
+		return nil, err
+	}
+	if isJSONL(filePath) {
+		return newJSONLSource(in, cfg.Fields)
+	}
+	return newCSVSource(in, csvSep(cfg))
+}
+
+// newStreamSource reads the rows of a stream, such as standard input,
+// which has no file name to tell its format by: it holds JSON Lines if
+// it starts with "{", and CSV otherwise. Compressed streams are
+// decompressed. Closing the source doesn't close r.
+func newStreamSource(r io.Reader, cfg config.CSVConfig) (rowSource, error) {
+	in, err := decompress(r, func() error { return nil })
+	if err != nil {
+		return nil, fmt.Errorf("error reading input: %w", err)
+	}
+	br := bufio.NewReader(in)
+	stream := &inputFile{Reader: br, close: in.Close}
+	if startsWithObject(br) {
+		return newJSONLSource(stream, cfg.Fields)
+	}
+	return newCSVSource(stream, csvSep(cfg))
+}
+
+// startsWithObject reports whether the first character of br that
+// isn't a space is "{", without consuming it.
+func startsWithObject(br *bufio.Reader) bool {
+	for n := 1; n <= br.Size(); n++ {
+		peeked, _ := br.Peek(n)
+		if len(peeked) < n {
+			return false
+		}
+		switch c := peeked[n-1]; c {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return c == '{'
+		}
+	}
+	return false
+}
+
+func isJSONL(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(trimCompressionExt(filePath))) {
+	case ".jsonl", ".ndjson":
+		return true
+	}
+	return false
+}
+
+// csvSource reads a CSV file; its first record is the header.
+type csvSource struct {
+	reader *csv.Reader
+	file   io.Closer
+	header []string
+}
+
+func newCSVSource(file io.ReadCloser, sep rune) (*csvSource, error) {
+	reader := csv.NewReader(file)
+	reader.Comma = sep
+	headers, err := readCSVHeaders(reader)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &csvSource{reader: reader, file: file, header: headers}, nil
+}
+
+func (s *csvSource) headers() []string { return s.header }
+
+func (s *csvSource) next() ([]string, error) {
+	record, err := s.reader.Read()
+	var parseErr *csv.ParseError
+	if err != nil && err != io.EOF && !errors.As(err, &parseErr) {
+		return nil, &sourceError{err}
+	}
+	return record, err
+}
+
+func (s *csvSource) Close() error { return s.file.Close() }
+
+// jsonlSource reads a JSON Lines file: one JSON object per line, blank
+// lines aside. Nested objects are flattened to dotted keys, so
+// {"address": {"city": "Lisbon"}} has the column "address.city". The
+// columns are the keys of the first object followed by the fields not
+// among them; keys that only later objects have are left out, and the
+// columns an object lacks are empty.
+type jsonlSource struct {
+	reader  *bufio.Reader
+	file    io.Closer
+	header  []string
+	pending map[string]string // the first object, read to find the columns
+}
+
+func newJSONLSource(file io.ReadCloser, fields []string) (*jsonlSource, error) {
+	s := &jsonlSource{reader: bufio.NewReader(file), file: file}
+
+	line, err := s.readLine()
+	if err != nil {
+		file.Close()
+		if err == io.EOF {
+			err = errors.New("no records found in the file")
+		}
+		return nil, fmt.Errorf("error reading headers: %w", err)
+	}
+	keys, values, err := flattenJSON(line)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading headers: %w", err)
+	}
+	for _, field := range fields {
+		if !slices.Contains(keys, field) {
+			keys = append(keys, field)
+		}
+	}
+	s.header, s.pending = keys, values
+	return s, nil
+}
+
+func (s *jsonlSource) headers() []string { return s.header }
+
+func (s *jsonlSource) next() ([]string, error) {
+	values := s.pending
+	s.pending = nil
+	if values == nil {
+		line, err := s.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if _, values, err = flattenJSON(line); err != nil {
+			return nil, err
+		}
+	}
+
+	record := make([]string, len(s.header))
+	for i, key := range s.header {
+		record[i] = values[key]
+	}
+	return record, nil
+}
+
+func (s *jsonlSource) Close() error { return s.file.Close() }
+
+// readLine returns the next line that isn't blank, without its line
+// break.
+func (s *jsonlSource) readLine() ([]byte, error) {
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, &sourceError{err}
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// flattenJSON decodes a JSON object into its values by dotted key, and
+// returns the keys in the order they appear. Strings are kept as they
+// are, numbers and booleans as written, arrays as JSON text and null
+// as "".
+func flattenJSON(data []byte) ([]string, map[string]string, error) {
+	if len(data) == 0 || data[0] != '{' {
+		return nil, nil, errors.New("the line is not a JSON object")
+	}
+	var keys []string
+	values := make(map[string]string)
+	if err := flattenObject(data, "", &keys, values); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return keys, values, nil
+}
+
+func flattenObject(data []byte, prefix string, keys *[]string, values map[string]string) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil { // {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := prefix + tok.(string)
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		switch raw[0] {
+		case '{':
+			if err := flattenObject(raw, key+".", keys, values); err != nil {
+				return err
+			}
+			continue
+		case '"':
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return err
+			}
+			values[key] = s
+		case 'n':
+			values[key] = ""
+		default:
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, raw); err != nil {
+				return err
+			}
+			values[key] = compact.String()
+		}
+		if !slices.Contains(*keys, key) {
+			*keys = append(*keys, key)
+		}
+	}
+	if _, err := dec.Token(); err != nil { // }
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after the object")
+	}
+	return nil
+}
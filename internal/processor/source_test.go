@@ -0,0 +1,134 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFlattenJSON(t *testing.T) {
+	t.Run("Should flatten nested objects to dotted keys in order", func(t *testing.T) {
+		keys, values, err := flattenJSON([]byte(`{"id": 42, "address": {"city": "Lisbon", "geo": {"lat": 38.7}}, "tags": ["a", "b"], "active": true, "note": null}`))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id", "address.city", "address.geo.lat", "tags", "active", "note"}, keys)
+		assert.Equal(t, map[string]string{
+			"id":              "42",
+			"address.city":    "Lisbon",
+			"address.geo.lat": "38.7",
+			"tags":            `["a","b"]`,
+			"active":          "true",
+			"note":            "",
+		}, values)
+	})
+
+	t.Run("Should reject a line that is not a JSON object", func(t *testing.T) {
+		_, _, err := flattenJSON([]byte(`[1, 2]`))
+		assert.EqualError(t, err, "the line is not a JSON object")
+
+		_, _, err = flattenJSON([]byte(`{"id": 1`))
+		assert.ErrorContains(t, err, "invalid JSON")
+
+		_, _, err = flattenJSON([]byte(`{"id": 1} {"id": 2}`))
+		assert.ErrorContains(t, err, "unexpected data after the object")
+	})
+}
+
+func TestJSONLSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"id": 1, "address": {"city": "Lisbon"}}
+
+{"id": 2, "email": "b@x"}
+not json
+{"address": {"city": "Porto"}, "id": 3}
+`), 0o600))
+
+	source, err := openRowSource(path, config.CSVConfig{Fields: []string{"id", "email"}}, nil)
+	require.NoError(t, err)
+	defer source.Close()
+
+	assert.Equal(t, []string{"id", "address.city", "email"}, source.headers(),
+		"the keys of the first object, then the fields it lacks")
+
+	record, err := source.next()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "Lisbon", ""}, record)
+
+	record, err = source.next()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2", "", "b@x"}, record, "blank lines are skipped")
+
+	_, err = source.next()
+	assert.ErrorContains(t, err, "not a JSON object")
+
+	record, err = source.next()
+	require.NoError(t, err, "a bad line doesn't stop the source")
+	assert.Equal(t, []string{"3", "Porto", ""}, record)
+}
+
+func TestPreviewRows_JSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.ndjson")
+	require.NoError(t, os.WriteFile(path, []byte(`{"id": 1, "name": "ana", "address": {"city": "Lisbon"}}`+"\n"), 0o600))
+
+	rows, err := PreviewRows(path, config.CSVConfig{Fields: []string{"id", "address.city"}}, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"id": "1", "address.city": "Lisbon"}}, rows)
+}
+
+func TestInspectCSV_JSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"id\": 1}\n[1]\n{\"id\": 3, \"name\": \"cid\"}\n{\"name\": \"\xff\"}\n"), 0o600))
+
+	report, err := InspectCSV(path, config.CSVConfig{Fields: []string{"id", "email"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"id", "name"}, report.Headers)
+	assert.Equal(t, 4, report.Rows)
+	assert.Equal(t, []config.Problem{
+		{File: path, Line: 2, Message: "the line is not a JSON object"},
+		{File: path, Line: 4, Message: "the line is not valid UTF-8"},
+		{File: path, Message: `csv.fields key "email" is in none of the objects`},
+	}, report.Problems)
+}
+
+// TestProcessor_Do_JSONL proves a JSON Lines file is processed like a
+// CSV file and its failed rows are written as JSON Lines, so they can
+// be processed again.
+func TestProcessor_Do_JSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"id": 1, "address": {"city": "Lisbon"}}
+{"id": 2, "address": {"city": "Porto"}}
+`), 0o600))
+
+	csvCfg := config.CSVConfig{
+		Fields:     []string{"address.city"},
+		FailedRows: config.FailedRowsConfig{Enabled: true, StatusColumn: true},
+	}
+	p, gatewayMock, loggerMock := newTestProcessor(t, csvCfg, 1)
+
+	var cities []string
+	gatewayMock.EXPECT().Exec(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, row map[string]string) (web.Response, error) {
+			cities = append(cities, row["address.city"])
+			if row["address.city"] == "Porto" {
+				return web.Response{StatusCode: 500}, nil
+			}
+			return web.Response{StatusCode: 200}, nil
+		}).Times(2)
+	loggerMock.EXPECT().Add(gomock.Any()).AnyTimes()
+	loggerMock.EXPECT().WriteToFile(gomock.Any()).Times(2)
+
+	p.Do(context.Background(), path)
+	p.Wait()
+
+	assert.Equal(t, []string{"Lisbon", "Porto"}, cities)
+	data, err := os.ReadFile(FailedRowsPath(path))
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"2","address.city":"Porto","_status":"500"}`+"\n", string(data))
+}
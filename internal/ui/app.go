@@ -98,6 +98,14 @@ func normalizeVersion(version string) string {
 // pointer fields for `map[View]viewModel`.
 type viewModel = tea.Model
 
+// inputCapturer is implemented by views that can take free text input,
+// such as the Settings view's profile name prompt. While a view
+// captures input, keys reach it before the global bindings, so "q" is
+// typed rather than quitting.
+type inputCapturer interface {
+	CapturesInput() bool
+}
+
 // AppModel is the new multi-view model
 type AppModel struct {
 	// currentView is the active top-level view. Defaults to ViewFiles (the app boots into the Files view).
@@ -128,6 +136,23 @@ type AppModel struct {
 	// Context for cancellation
 	cancel   context.CancelFunc
 	cancelMu *sync.RWMutex
+
+	// dryRun mirrors the mode last pushed to the processor with
+	// SetDryRun; it drives the status bar badge and skips the resume
+	// offer, since a dry run ignores checkpoints.
+	dryRun bool
+
+	// resume is the pending offer to resume a file selected in the
+	// Files view that has a checkpoint. While set, the status bar shows
+	// the prompt and keys answer it instead of reaching the views.
+	resume *resumeOffer
+}
+
+// resumeOffer is a selected file waiting for the user to choose
+// between resuming from its checkpoint and starting over.
+type resumeOffer struct {
+	filePath   string
+	checkpoint ports.ProcessorCheckpoint
 }
 
 // NewApp creates a new AppModel with multi-view support
@@ -146,7 +171,7 @@ func NewApp(csvFiles []string, fileProcessor ports.ProcessorController, log port
 		views: map[View]viewModel{
 			ViewFiles:    views.NewFilesView(items),
 			ViewLogs:     views.NewLogsView(log, fileProcessor),
-			ViewSettings: views.NewSettingsView(configMgr, fileProcessor),
+			ViewSettings: views.NewSettingsView(configMgr, fileProcessor, csvFiles),
 		},
 		help:     createHelp(),
 		spinner:  createSpinner(),
@@ -2,12 +2,15 @@ package ui
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
+	"github.com/anibaldeboni/rapper/internal/processor"
 	"github.com/anibaldeboni/rapper/internal/ui/kbind"
 	"github.com/anibaldeboni/rapper/internal/ui/msgs"
+	"github.com/anibaldeboni/rapper/internal/ui/ports"
 )
 
 func tickCmd() tea.Cmd {
@@ -30,6 +33,13 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
+		if m.resume != nil {
+			return m.answerResume(msg)
+		}
+		if v, ok := m.views[m.currentView].(inputCapturer); ok && v.CapturesInput() {
+			return m.updateCurrentView(msg)
+		}
+
 		// Global navigation keys
 		switch {
 		case key.Matches(msg, kbind.Quit):
@@ -47,6 +57,25 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.currentView = ViewSettings
 			return m, m.routeToAllViews(msgs.MetricsVisibilityMsg{Visible: false})
 
+		case key.Matches(msg, kbind.DryRun):
+			// The run in progress keeps the mode it started with, so
+			// the badge would misrepresent it.
+			m.cancelMu.RLock()
+			running := m.cancel != nil
+			m.cancelMu.RUnlock()
+			if running {
+				m.toastMgr.Warning("Dry run can't be toggled while processing")
+				return m, nil
+			}
+			m.dryRun = !m.dryRun
+			m.processor.SetDryRun(m.dryRun)
+			if m.dryRun {
+				m.toastMgr.Info("Dry run on: requests will be rendered, not sent")
+			} else {
+				m.toastMgr.Info("Dry run off")
+			}
+			return m, nil
+
 		case key.Matches(msg, kbind.CancelOperation):
 			if m.cancel != nil {
 				m.cancelMu.Lock()
@@ -103,6 +132,7 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				TotalRequests:   metrics.TotalRequests,
 				SuccessRequests: metrics.SuccessRequests,
 				ErrorRequests:   metrics.ErrorRequests,
+				RetryRequests:   metrics.RetryRequests,
 				LinesProcessed:  metrics.LinesProcessed,
 				ActiveWorkers:   metrics.ActiveWorkers,
 				RequestsPerSec:  metrics.RequestsPerSec,
@@ -155,6 +185,15 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else if msg.Success {
 			m.toastMgr.Success("Processing completed")
 		}
+
+		// Offer the failed rows of the run in the Files view so fixing
+		// and retrying them is one keystroke away.
+		if failed := processor.FailedRowsPath(msg.FilePath); msg.FilePath != "" && fileExists(failed) {
+			return m, tea.Batch(logsCmd, m.routeToAllViews(msgs.FileAddedMsg{
+				FilePath: failed,
+				Title:    mapFileToOption(failed).Title,
+			}))
+		}
 		return m, logsCmd
 
 	case msgs.ConfigSavedMsg:
@@ -173,6 +212,25 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.toastMgr.Error("Failed to switch profile: " + msg.Err.Error())
 		return m, nil
 
+	case msgs.ProfileEditedMsg:
+		m.toastMgr.Success(msg.Text)
+		return m, nil
+
+	case msgs.ProfileEditErrorMsg:
+		m.toastMgr.Error(msg.Err.Error())
+		return m, nil
+
+	case msgs.ProfilesReloadedMsg:
+		switch {
+		case msg.Err != nil:
+			m.toastMgr.Error("Failed to reload profiles: " + msg.Err.Error())
+		case len(msg.Errors) > 0:
+			m.toastMgr.Warning("Profile not reloaded: " + msg.Errors[0].Error())
+		default:
+			m.toastMgr.Info("Profiles reloaded from disk: " + describeReload(msg))
+		}
+		return m, m.routeToAllViews(msg)
+
 	case msgs.ItemSelectedMsg:
 		// FilesView (Phase 4) emits ItemSelectedMsg on Select. The
 		// AppModel routes it to selectFile which starts processing
@@ -224,8 +282,46 @@ func (m AppModel) selectFile(filePath string) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Start processing
-	ctx, cancel := m.processor.Do(context.Background(), filePath)
+	// An interrupted run left a checkpoint: ask before starting.
+	if cp, ok := m.processor.Checkpoint(filePath); ok && !m.dryRun {
+		m.resume = &resumeOffer{filePath: filePath, checkpoint: cp}
+		return m, nil
+	}
+
+	return m.startProcessing(filePath, nil)
+}
+
+// answerResume handles a keypress while the resume prompt is shown.
+// Quit still quits; any key other than the prompt's own is ignored so
+// a stray keystroke can't start a run the user didn't pick.
+func (m AppModel) answerResume(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	offer := m.resume
+	switch {
+	case key.Matches(msg, kbind.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, kbind.Resume):
+		m.resume = nil
+		return m.startProcessing(offer.filePath, &offer.checkpoint)
+	case key.Matches(msg, kbind.Restart):
+		m.resume = nil
+		return m.startProcessing(offer.filePath, nil)
+	case key.Matches(msg, kbind.Cancel):
+		m.resume = nil
+	}
+	return m, nil
+}
+
+// startProcessing runs filePath from the start, or from cp when set.
+func (m AppModel) startProcessing(filePath string, cp *ports.ProcessorCheckpoint) (tea.Model, tea.Cmd) {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if cp != nil {
+		ctx, cancel = m.processor.Resume(context.Background(), filePath, *cp)
+	} else {
+		ctx, cancel = m.processor.Do(context.Background(), filePath)
+	}
 	if ctx != nil {
 		m.cancelMu.Lock()
 		m.cancel = cancel
@@ -245,7 +341,7 @@ func (m AppModel) selectFile(filePath string) (tea.Model, tea.Cmd) {
 				return msgs.ProcessingStartedMsg{FilePath: filePath}
 			},
 			emit(msgs.MetricsVisibilityMsg{Visible: true}),
-			m.waitCompletion(ctx),
+			m.waitCompletion(ctx, filePath),
 		)
 	}
 
@@ -253,15 +349,31 @@ func (m AppModel) selectFile(filePath string) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m *AppModel) waitCompletion(ctx context.Context) tea.Cmd {
+func (m *AppModel) waitCompletion(ctx context.Context, filePath string) tea.Cmd {
 	return func() tea.Msg {
 		<-ctx.Done()
 		// Check if it was cancelled or completed successfully
 		err := ctx.Err()
 		success := err == nil || err == context.Canceled
 		return msgs.ProcessingStoppedMsg{
-			Success: success,
-			Err:     nil,
+			FilePath: filePath,
+			Success:  success,
+			Err:      nil,
+		}
+	}
+}
+
+// describeReload summarizes the profiles a reload picked up, e.g.
+// "production changed, qa added".
+func describeReload(msg msgs.ProfilesReloadedMsg) string {
+	var parts []string
+	for _, group := range []struct {
+		names []string
+		verb  string
+	}{{msg.Changed, "changed"}, {msg.Added, "added"}, {msg.Removed, "removed"}} {
+		if len(group.names) > 0 {
+			parts = append(parts, strings.Join(group.names, ", ")+" "+group.verb)
 		}
 	}
+	return strings.Join(parts, "; ")
 }
@@ -2,6 +2,9 @@ package ui
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	tea "charm.land/bubbletea/v2"
@@ -16,6 +19,11 @@ import (
 
 func contextTODO() context.Context { return context.TODO() }
 
+// resumableCSV is the one file newTestApp doesn't stub a missing
+// checkpoint for; gomock matches expectations in declaration order, so
+// tests set up their own Checkpoint result for it.
+const resumableCSV = "resumable.csv"
+
 // newTestApp builds an AppModel with all mock dependencies ready and
 // returns the AppModel plus the mock handles for tests that need to set
 // up additional expectations (e.g. Do, Update, Save). Tests that don't
@@ -44,9 +52,12 @@ func newTestApp(t *testing.T, csvPaths ...string) (
 	configMgrMock.EXPECT().Get().Return(nil).AnyTimes()
 	configMgrMock.EXPECT().GetActiveProfile().Return("default").AnyTimes()
 	configMgrMock.EXPECT().ListProfiles().Return([]string{"default"}).AnyTimes()
+	configMgrMock.EXPECT().ProfileError(gomock.Any()).Return(nil).AnyTimes()
 	processorMock.EXPECT().GetWorkerCount().Return(1).AnyTimes()
 	processorMock.EXPECT().GetMaxWorkers().Return(1).AnyTimes()
+	processorMock.EXPECT().GetRateLimit().Return(0.0).AnyTimes()
 	processorMock.EXPECT().GetMetrics().Return(ports.ProcessorMetrics{}).AnyTimes()
+	processorMock.EXPECT().Checkpoint(gomock.Not(resumableCSV)).Return(ports.ProcessorCheckpoint{}, false).AnyTimes()
 
 	app := NewApp(csvPaths, processorMock, logManagerMock, configMgrMock)
 	return app, logManagerMock, configMgrMock, processorMock
@@ -291,3 +302,122 @@ func TestAppModel_SelectFile_StartsMetricsTick(t *testing.T) {
 		t.Errorf("MetricsTickMsg must return a non-nil reschedule cmd; got nil")
 	}
 }
+
+// TestAppModel_SelectFile_OffersToResume — selecting a file with a
+// checkpoint must not start processing; it shows the resume prompt and
+// the answer decides between Resume and Do.
+func TestAppModel_SelectFile_OffersToResume(t *testing.T) {
+	cp := ports.ProcessorCheckpoint{File: resumableCSV, Completed: 41}
+
+	t.Run("Should ask before starting", func(t *testing.T) {
+		app, _, _, procMock := newTestApp(t)
+		procMock.EXPECT().Checkpoint(resumableCSV).Return(cp, true)
+
+		updated, _ := app.Update(msgs.ItemSelectedMsg{FilePath: resumableCSV})
+		next := updated.(AppModel)
+
+		require.NotNil(t, next.resume)
+		require.Equal(t, ViewFiles, next.currentView)
+		next.width = 200
+		require.Contains(t, next.renderStatusBar(), "Resume resumable.csv from line 42?")
+	})
+
+	t.Run("Should resume from the checkpoint when accepted", func(t *testing.T) {
+		app, _, _, procMock := newTestApp(t)
+		app.resume = &resumeOffer{filePath: resumableCSV, checkpoint: cp}
+		procMock.EXPECT().Resume(gomock.Any(), resumableCSV, cp).Return(contextTODO(), func() {})
+
+		updated, _ := app.Update(tea.KeyPressMsg{Text: "y", Code: 'y'})
+		next := updated.(AppModel)
+
+		require.Nil(t, next.resume)
+		require.Equal(t, ViewLogs, next.currentView)
+	})
+
+	t.Run("Should start over when declined", func(t *testing.T) {
+		app, _, _, procMock := newTestApp(t)
+		app.resume = &resumeOffer{filePath: resumableCSV, checkpoint: cp}
+		procMock.EXPECT().Do(gomock.Any(), resumableCSV).Return(contextTODO(), func() {})
+
+		updated, _ := app.Update(tea.KeyPressMsg{Text: "n", Code: 'n'})
+		require.Nil(t, updated.(AppModel).resume)
+	})
+
+	t.Run("Should dismiss on esc and ignore other keys", func(t *testing.T) {
+		app, _, _, _ := newTestApp(t)
+		app.resume = &resumeOffer{filePath: resumableCSV, checkpoint: cp}
+
+		updated, _ := app.Update(tea.KeyPressMsg{Code: tea.KeyF2})
+		next := updated.(AppModel)
+		require.NotNil(t, next.resume, "keys other than the answers are swallowed")
+		require.Equal(t, ViewFiles, next.currentView)
+
+		updated, _ = next.Update(tea.KeyPressMsg{Code: tea.KeyEscape})
+		require.Nil(t, updated.(AppModel).resume)
+	})
+}
+
+// TestAppModel_DryRunToggle — ctrl+d flips dry-run mode on the
+// processor, badges the status bar and skips the resume offer, since a
+// dry run ignores checkpoints.
+func TestAppModel_DryRunToggle(t *testing.T) {
+	app, _, _, procMock := newTestApp(t)
+	procMock.EXPECT().SetDryRun(true)
+
+	updated, _ := app.Update(tea.KeyPressMsg{Code: 'd', Mod: tea.ModCtrl})
+	next := updated.(AppModel)
+	require.True(t, next.dryRun)
+	next.width = 200
+	require.Contains(t, next.renderStatusBar(), "DRY RUN")
+
+	procMock.EXPECT().Do(gomock.Any(), resumableCSV).Return(contextTODO(), func() {})
+	procMock.EXPECT().Checkpoint(resumableCSV).Return(ports.ProcessorCheckpoint{Completed: 3}, true).AnyTimes()
+	updated, _ = next.Update(msgs.ItemSelectedMsg{FilePath: resumableCSV})
+	require.Nil(t, updated.(AppModel).resume)
+
+	// The run started above is still in progress: it keeps its mode.
+	updated, _ = updated.(AppModel).Update(tea.KeyPressMsg{Code: 'd', Mod: tea.ModCtrl})
+	require.True(t, updated.(AppModel).dryRun, "the mode can't change during a run")
+
+	procMock.EXPECT().SetDryRun(false)
+	running := updated.(AppModel)
+	running.cancel = nil
+	updated, _ = running.Update(tea.KeyPressMsg{Code: 'd', Mod: tea.ModCtrl})
+	require.False(t, updated.(AppModel).dryRun)
+}
+
+// TestAppModel_ProcessingStopped_AddsFailedRowsFile — when the run left
+// a failed rows file next to the processed file, it is added to the
+// Files view and selected.
+func TestAppModel_ProcessingStopped_AddsFailedRowsFile(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "users.csv")
+	failedPath := filepath.Join(dir, "users.failed.csv")
+	require.NoError(t, os.WriteFile(failedPath, []byte("id\n2\n"), 0o600))
+
+	app, _, _, _ := newTestApp(t, csvPath)
+	updated, _ := app.Update(msgs.ProcessingStoppedMsg{FilePath: csvPath, Success: true})
+	next := updated.(AppModel)
+
+	files := next.views[ViewFiles].(views.FilesView)
+	require.Equal(t, []string{csvPath, failedPath}, files.FilePaths())
+	require.Equal(t, failedPath, files.SelectedFilePath())
+}
+
+// TestAppModel_ProfileDialog_CapturesGlobalKeys — while the Settings
+// view's profile name prompt is open, letters of global bindings such
+// as "q" are typed into it instead of quitting.
+func TestAppModel_ProfileDialog_CapturesGlobalKeys(t *testing.T) {
+	app, _, _, _ := newTestApp(t)
+	app.currentView = ViewSettings
+
+	updated, _ := app.Update(tea.KeyPressMsg{Text: "n", Code: 'n'})
+	next := updated.(AppModel)
+	require.True(t, next.views[ViewSettings].(views.SettingsView).CapturesInput())
+
+	_, cmd := next.Update(tea.KeyPressMsg{Text: "q", Code: 'q'})
+	if cmd != nil {
+		require.NotEqual(t, reflect.ValueOf(tea.Quit).Pointer(), reflect.ValueOf(cmd).Pointer(),
+			"q must not quit while the prompt is open")
+	}
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"path/filepath"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
@@ -89,10 +90,20 @@ func (m AppModel) renderStatusBar() string {
 
 	// Current view indicator
 	appName := LogoStyle(fmt.Sprintf("%s@%s", AppName, AppVersion))
+	if m.dryRun {
+		appName += dryRunBadgeStyle.Render("DRY RUN")
+	}
 
-	// Get view-specific commands
-	viewSpecificKeys := getViewSpecificKeyMap(m.currentView)
-	helpText := m.help.View(viewSpecificKeys)
+	// Get view-specific commands, or the resume prompt while it waits
+	// for an answer
+	var helpText string
+	if m.resume != nil {
+		helpText = resumePromptStyle.Render(fmt.Sprintf("Resume %s from line %d?",
+			filepath.Base(m.resume.filePath), m.resume.checkpoint.Completed+1)) +
+			" " + m.help.View(resumeKeyMap{})
+	} else {
+		helpText = m.help.View(getViewSpecificKeyMap(m.currentView))
+	}
 
 	// Truncate help text if needed
 	availableWidth := max(m.width-lipgloss.Width(appName)-10, 0)
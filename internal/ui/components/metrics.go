@@ -1,13 +1,17 @@
 package components
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/anibaldeboni/rapper/internal/processor"
 	"github.com/anibaldeboni/rapper/internal/ui/msgs"
 	"github.com/anibaldeboni/rapper/internal/ui/ports"
 )
@@ -17,6 +21,18 @@ import (
 // producing noticeable flicker on the host terminal.
 const tickInterval = 100 * time.Millisecond
 
+// maxStatusRows is the number of status codes the panel lists; the
+// others are added up in an "other" row.
+const maxStatusRows = 5
+
+// outcomeBarWidth is the number of cells of the longest bar of the
+// status code and error class chart.
+const outcomeBarWidth = 8
+
+// progressBarWidth is the number of cells of the progress bar, which
+// shares the value column with the percentage.
+const progressBarWidth = 10
+
 var (
 	metricsTitleStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
 	metricsLabelStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("86")).Bold(true).Width(20)
@@ -95,7 +111,7 @@ func (p MetricsPanel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return p, nil
 }
 
-// View renders the eight metric rows in a fixed order. The output is the
+// View renders the metric rows in a fixed order. The output is the
 // same regardless of visibility so the parent view can pre-render without
 // flickering on activation.
 func (p MetricsPanel) View() tea.View {
@@ -127,15 +143,40 @@ func (p MetricsPanel) view() string {
 		metricsLabelStyle.Render("Total Requests:") + " " + metricsValueStyle.Render(strconv.FormatUint(m.TotalRequests, 10)),
 		metricsLabelStyle.Render("✓ Success:") + " " + metricsValueOK.Render(strconv.FormatUint(m.SuccessRequests, 10)),
 		metricsLabelStyle.Render("✗ Errors:") + " " + errVal,
+		metricsLabelStyle.Render("↻ Retries:") + " " + metricsValueStyle.Render(strconv.FormatUint(m.RetryRequests, 10)),
 		metricsLabelStyle.Render("Lines Processed:") + " " + metricsValueStyle.Render(strconv.FormatUint(m.LinesProcessed, 10)),
 		metricsLabelStyle.Render("Throughput:") + " " + metricsValueStyle.Render(fmt.Sprintf("%.2f req/s", m.RequestsPerSec)),
 		metricsLabelStyle.Render("Active Workers:") + " " + metricsValueStyle.Render(strconv.Itoa(m.ActiveWorkers)),
 	}
 
+	if m.LatencyMax > 0 {
+		rows = append(rows,
+			metricsLabelStyle.Render("Latency p50/p90:")+" "+metricsValueStyle.Render(formatDuration(m.LatencyP50)+" / "+formatDuration(m.LatencyP90)),
+			metricsLabelStyle.Render("Latency p99/max:")+" "+metricsValueStyle.Render(formatDuration(m.LatencyP99)+" / "+formatDuration(m.LatencyMax)),
+		)
+	}
+
+	// A stream has no known total, so it gets no progress bar.
+	if m.IsProcessing && m.TotalLines > 0 {
+		rows = append(rows,
+			metricsLabelStyle.Render("Progress:")+" "+progressBar(m.PercentComplete, progressBarWidth)+" "+metricsValueStyle.Render(fmt.Sprintf("%.0f%%", m.PercentComplete)),
+			metricsLabelStyle.Render("Total Lines:")+" "+metricsValueStyle.Render(formatTotal(m.TotalLines, m.TotalEstimated)),
+		)
+	}
+
 	if m.IsProcessing && !m.StartTime.IsZero() {
 		rows = append(rows, metricsLabelStyle.Render("Elapsed Time:")+" "+metricsElapsedStyle.Render(formatDuration(time.Since(m.StartTime))))
 	}
 
+	if m.IsProcessing && m.ETA > 0 {
+		rows = append(rows, metricsLabelStyle.Render("ETA:")+" "+metricsElapsedStyle.Render(formatDuration(m.ETA)))
+	}
+
+	if outcomes := outcomeRows(m); len(outcomes) > 0 {
+		rows = append(rows, "", metricsTitleStyle.Render("Responses"))
+		rows = append(rows, outcomes...)
+	}
+
 	var b strings.Builder
 	for i, r := range rows {
 		if i > 0 {
@@ -166,3 +207,81 @@ func formatDuration(d time.Duration) string {
 	seconds := int(d.Seconds()) % 60
 	return fmt.Sprintf("%dm %ds", minutes, seconds)
 }
+
+// progressBar renders percent, from 0 to 100, as a bar width cells wide.
+func progressBar(percent float64, width int) string {
+	filled := int(min(max(percent, 0), 100) / 100 * float64(width))
+	return metricsValueOK.Render(strings.Repeat("█", filled)) +
+		metricsValueDim.Render(strings.Repeat("░", width-filled))
+}
+
+// formatTotal formats a total number of lines, marking an estimate with
+// a leading "~".
+func formatTotal(total uint64, estimated bool) string {
+	s := strconv.FormatUint(total, 10)
+	if estimated {
+		return "~" + s
+	}
+	return s
+}
+
+// outcome is a row of the status code and error class chart.
+type outcome struct {
+	label string
+	count uint64
+	style lipgloss.Style
+}
+
+// outcomeRows charts the requests by status code, the most frequent
+// ones in code order, then by error class.
+func outcomeRows(m ports.ProcessorMetrics) []string {
+	codes := slices.SortedFunc(maps.Keys(m.StatusCodes), func(a, b int) int {
+		return cmp.Or(cmp.Compare(m.StatusCodes[b], m.StatusCodes[a]), cmp.Compare(a, b))
+	})
+	var others uint64
+	if len(codes) > maxStatusRows {
+		for _, code := range codes[maxStatusRows-1:] {
+			others += m.StatusCodes[code]
+		}
+		codes = codes[:maxStatusRows-1]
+	}
+	slices.Sort(codes)
+
+	var chart []outcome
+	for _, code := range codes {
+		chart = append(chart, outcome{label: strconv.Itoa(code), count: m.StatusCodes[code], style: statusStyle(code)})
+	}
+	if others > 0 {
+		chart = append(chart, outcome{label: "other", count: others, style: metricsValueStyle})
+	}
+	for _, class := range processor.ErrorClasses() {
+		if n := m.TransportErrors[class]; n > 0 {
+			chart = append(chart, outcome{label: string(class), count: n, style: metricsValueError})
+		}
+	}
+
+	var top uint64
+	for _, o := range chart {
+		top = max(top, o.count)
+	}
+	rows := make([]string, 0, len(chart))
+	for _, o := range chart {
+		cells := max(int(o.count*outcomeBarWidth/top), 1)
+		rows = append(rows, metricsLabelStyle.Render("  "+o.label)+" "+
+			o.style.Render(strings.Repeat("█", cells))+strings.Repeat(" ", outcomeBarWidth-cells)+" "+
+			metricsValueStyle.Render(strconv.FormatUint(o.count, 10)))
+	}
+	return rows
+}
+
+// statusStyle colours a status code by its class: success, redirect or
+// error.
+func statusStyle(code int) lipgloss.Style {
+	switch {
+	case code >= 200 && code < 300:
+		return metricsValueOK
+	case code >= 300 && code < 400:
+		return metricsValueStyle
+	}
+	return metricsValueError
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/anibaldeboni/rapper/internal/processor"
 	mock_ui "github.com/anibaldeboni/rapper/internal/ui/mock"
 	"github.com/anibaldeboni/rapper/internal/ui/msgs"
 	"github.com/anibaldeboni/rapper/internal/ui/ports"
@@ -48,7 +49,7 @@ func TestMetricsPanel_View_RendersAllMetricRows(t *testing.T) {
 	out := p.View().Content
 
 	// Each metric label must appear in the rendered output
-	for _, label := range []string{"Status:", "Total Requests:", "✓ Success:", "✗ Errors:", "Lines Processed:", "Throughput:", "Active Workers:"} {
+	for _, label := range []string{"Status:", "Total Requests:", "✓ Success:", "✗ Errors:", "↻ Retries:", "Lines Processed:", "Throughput:", "Active Workers:"} {
 		assert.Contains(t, out, label, "metrics panel should show label %q", label)
 	}
 }
@@ -123,3 +124,117 @@ func TestMetricsPanel_View_ShowsIdleStatusWhenNotProcessing(t *testing.T) {
 	assert.Contains(t, out, "Idle", "idle status must be shown when not processing")
 	assert.True(t, !strings.Contains(out, "🟢 Processing"), "processing indicator must not appear when idle")
 }
+
+func TestMetricsPanel_View_ShowsProgressAndETA(t *testing.T) {
+	p := newTestMetricsPanel(t, ports.ProcessorMetrics{
+		LinesProcessed:  250,
+		IsProcessing:    true,
+		StartTime:       time.Now(),
+		TotalLines:      1000,
+		TotalEstimated:  true,
+		PercentComplete: 25,
+		ETA:             90 * time.Second,
+	})
+	p = p.SetVisible(true)
+	next, _ := p.Update(msgs.MetricsTickMsg(time.Now()))
+
+	out := next.(MetricsPanel).View().Content
+
+	assert.Contains(t, out, "Progress:")
+	assert.Contains(t, out, strings.Repeat("█", 2), "a quarter of the bar is filled")
+	assert.NotContains(t, out, strings.Repeat("█", 3), "a quarter of the bar is filled")
+	assert.Contains(t, out, strings.Repeat("░", 8))
+	assert.Contains(t, out, "25%")
+	assert.Contains(t, out, "Total Lines:")
+	assert.Contains(t, out, "~1000")
+	assert.Contains(t, out, "ETA:")
+	assert.Contains(t, out, "1m 30s")
+}
+
+func TestMetricsPanel_View_HidesProgressWithoutTotal(t *testing.T) {
+	p := newTestMetricsPanel(t, ports.ProcessorMetrics{
+		LinesProcessed: 250,
+		IsProcessing:   true,
+		StartTime:      time.Now(),
+	})
+	p = p.SetVisible(true)
+	next, _ := p.Update(msgs.MetricsTickMsg(time.Now()))
+
+	out := next.(MetricsPanel).View().Content
+
+	assert.NotContains(t, out, "Progress:", "a stream has no known total")
+	assert.NotContains(t, out, "ETA:")
+}
+
+func TestMetricsPanel_View_ShowsLatencyPercentiles(t *testing.T) {
+	p := newTestMetricsPanel(t, ports.ProcessorMetrics{
+		IsProcessing: true,
+		LatencyP50:   12 * time.Millisecond,
+		LatencyP90:   48 * time.Millisecond,
+		LatencyP99:   310 * time.Millisecond,
+		LatencyMax:   1500 * time.Millisecond,
+	})
+	p = p.SetVisible(true)
+	next, _ := p.Update(msgs.MetricsTickMsg(time.Now()))
+
+	out := next.(MetricsPanel).View().Content
+
+	assert.Contains(t, out, "Latency p50/p90:")
+	assert.Contains(t, out, "12ms / 48ms")
+	assert.Contains(t, out, "Latency p99/max:")
+	assert.Contains(t, out, "310ms / 1.5s")
+}
+
+func TestMetricsPanel_View_HidesLatencyWithoutRequests(t *testing.T) {
+	p := newTestMetricsPanel(t, ports.ProcessorMetrics{IsProcessing: true})
+	p = p.SetVisible(true)
+	next, _ := p.Update(msgs.MetricsTickMsg(time.Now()))
+
+	assert.NotContains(t, next.(MetricsPanel).View().Content, "Latency")
+}
+
+func TestMetricsPanel_View_ChartsStatusCodesAndErrorClasses(t *testing.T) {
+	p := newTestMetricsPanel(t, ports.ProcessorMetrics{
+		IsProcessing:    true,
+		StatusCodes:     map[int]uint64{200: 80, 404: 12},
+		TransportErrors: map[processor.ErrorClass]uint64{processor.ErrorClassTimeout: 3},
+	})
+	p = p.SetVisible(true)
+	next, _ := p.Update(msgs.MetricsTickMsg(time.Now()))
+
+	out := next.(MetricsPanel).View().Content
+
+	assert.Contains(t, out, "Responses")
+	assert.Contains(t, out, "  200")
+	assert.Contains(t, out, strings.Repeat("█", 8), "the most frequent outcome fills the bar")
+	assert.Contains(t, out, "80")
+	assert.Contains(t, out, "  404")
+	assert.Contains(t, out, "  timeout")
+	assert.Less(t, strings.Index(out, "404"), strings.Index(out, "timeout"), "error classes follow the status codes")
+}
+
+func TestMetricsPanel_View_GroupsInfrequentStatusCodes(t *testing.T) {
+	p := newTestMetricsPanel(t, ports.ProcessorMetrics{
+		IsProcessing: true,
+		StatusCodes:  map[int]uint64{200: 50, 201: 40, 400: 30, 404: 20, 409: 2, 500: 1},
+	})
+	p = p.SetVisible(true)
+	next, _ := p.Update(msgs.MetricsTickMsg(time.Now()))
+
+	out := next.(MetricsPanel).View().Content
+
+	for _, code := range []string{"200", "201", "400", "404"} {
+		assert.Contains(t, out, "  "+code)
+	}
+	assert.NotContains(t, out, "  409")
+	assert.NotContains(t, out, "  500")
+	assert.Contains(t, out, "  other")
+}
+
+func TestMetricsPanel_View_HidesResponsesWithoutRequests(t *testing.T) {
+	p := newTestMetricsPanel(t, ports.ProcessorMetrics{IsProcessing: true})
+	p = p.SetVisible(true)
+	next, _ := p.Update(msgs.MetricsTickMsg(time.Now()))
+
+	assert.NotContains(t, next.(MetricsPanel).View().Content, "Responses")
+}
@@ -0,0 +1,73 @@
+package components
+
+import (
+	"strconv"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/anibaldeboni/rapper/internal/ui/kbind"
+)
+
+// rateSteps is the ladder the rate control walks with [ and ]. Zero
+// (unlimited) sits at the bottom; values set in the profile that fall
+// between two steps snap to the neighbouring step on the first press.
+var rateSteps = []float64{0, 0.5, 1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+
+// RateControl is a compact control that shows the global requests per
+// second limit and steps it up or down with ] and [. It is rendered
+// next to the worker slider and shares its focus.
+type RateControl struct {
+	Value   float64
+	Label   string
+	Focused bool
+}
+
+// NewRateControl creates a rate control seeded with the current limit.
+func NewRateControl(label string, initial float64) RateControl {
+	return RateControl{Value: initial, Label: label}
+}
+
+// Update handles the RateInc/RateDec keys. Any other message leaves
+// the control untouched. The returned command is always nil.
+func (r RateControl) Update(msg tea.Msg) (RateControl, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return r, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, kbind.RateInc):
+		for _, step := range rateSteps {
+			if step > r.Value {
+				r.Value = step
+				break
+			}
+		}
+	case key.Matches(keyMsg, kbind.RateDec):
+		for i := len(rateSteps) - 1; i >= 0; i-- {
+			if rateSteps[i] < r.Value {
+				r.Value = rateSteps[i]
+				break
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// View renders the label and the current limit.
+func (r RateControl) View() string {
+	label := sliderLabelIdleStyle.Render(r.Label + ":")
+	if r.Focused {
+		label = sliderLabelFocusStyle.Render(r.Label + ":")
+	}
+	return label + " " + sliderFillStyle.Render(FormatRate(r.Value))
+}
+
+// FormatRate renders a requests per second limit, "unlimited" for zero.
+func FormatRate(rps float64) string {
+	if rps <= 0 {
+		return "unlimited"
+	}
+	return strconv.FormatFloat(rps, 'f', -1, 64) + " req/s"
+}
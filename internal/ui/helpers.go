@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"os"
 	"path/filepath"
 
 	"github.com/anibaldeboni/rapper/internal/ui/views"
@@ -15,6 +16,11 @@ func mapFileToOption(filePath string) views.Option[string] {
 	}
 }
 
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
 func trimFilename(filename string, length int) string {
 	f := filepath.Base(filename)
 	if len(f) < length {
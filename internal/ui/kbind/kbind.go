@@ -74,6 +74,17 @@ var (
 		key.WithKeys("-", "shift+-"),
 		key.WithHelp("-", "decrease workers"),
 	)
+	// RateInc/RateDec step the global rate limit shown next to the
+	// worker slider. Brackets are unshifted on common layouts, so they
+	// need no Kitty-protocol alias.
+	RateInc = key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "raise rate limit"),
+	)
+	RateDec = key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "lower rate limit"),
+	)
 	NextField = key.NewBinding(
 		key.WithKeys("tab"),
 		key.WithHelp("tab", "next field"),
@@ -91,6 +102,60 @@ var (
 		key.WithKeys("shift+tab"),
 		key.WithHelp("shift+tab", "next field"),
 	)
+	// PreviewRow/PreviewFile step the request preview of the Settings
+	// view through the rows of its CSV file and through the CSV files.
+	// They are the arrow keys, only handled while the preview is focused.
+	PreviewRow = key.NewBinding(
+		key.WithKeys("left", "right"),
+		key.WithHelp("←/→", "preview row"),
+	)
+	PreviewFile = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑/↓", "preview file"),
+	)
+	// NewProfile, CloneProfile, RenameProfile and DeleteProfile act on
+	// the profile highlighted in the Settings sidebar while it is
+	// focused. The letters aren't taken by the list's own keys.
+	NewProfile = key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "new profile"),
+	)
+	CloneProfile = key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "clone profile"),
+	)
+	RenameProfile = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "rename profile"),
+	)
+	DeleteProfile = key.NewBinding(
+		key.WithKeys("x", "delete"),
+		key.WithHelp("x", "delete profile"),
+	)
+	// Confirm/Deny answer the confirmation asked before deleting a
+	// profile.
+	Confirm = key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "confirm"),
+	)
+	Deny = key.NewBinding(
+		key.WithKeys("n", "esc"),
+		key.WithHelp("n", "cancel"),
+	)
+	DryRun = key.NewBinding(
+		key.WithKeys("ctrl+d"),
+		key.WithHelp("ctrl+d", "dry run"),
+	)
+	// Resume/Restart answer the prompt shown when the selected file has
+	// a checkpoint left by an interrupted run.
+	Resume = key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "resume"),
+	)
+	Restart = key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "start over"),
+	)
 	CancelOperation = key.NewBinding(
 		key.WithKeys("ctrl+c"),
 		key.WithHelp("ctrl+c", "kill operation"),
@@ -10,12 +10,12 @@ import (
 type globalKeyMap struct{}
 
 func (k globalKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{kbind.ViewFiles, kbind.ViewLogs, kbind.ViewSettings, kbind.CancelOperation, kbind.Quit}
+	return []key.Binding{kbind.ViewFiles, kbind.ViewLogs, kbind.ViewSettings, kbind.DryRun, kbind.CancelOperation, kbind.Quit}
 }
 
 func (k globalKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{kbind.ViewFiles, kbind.ViewLogs, kbind.ViewSettings, kbind.CancelOperation, kbind.Quit},
+		{kbind.ViewFiles, kbind.ViewLogs, kbind.ViewSettings, kbind.DryRun, kbind.CancelOperation, kbind.Quit},
 	}
 }
 
@@ -62,17 +62,31 @@ func (k logsViewKeyMap) FullHelp() [][]key.Binding {
 type settingsViewKeyMap struct{}
 
 func (k settingsViewKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{kbind.PaneToggle, kbind.PrevField, kbind.PageUp, kbind.PageDown, kbind.Save, kbind.SliderInc, kbind.SliderDec}
+	return []key.Binding{kbind.PaneToggle, kbind.PrevField, kbind.PageUp, kbind.PageDown, kbind.Save, kbind.SliderInc, kbind.SliderDec, kbind.RateInc, kbind.RateDec}
 }
 
 func (k settingsViewKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{kbind.PaneToggle, kbind.PrevField, kbind.Save},
-		{kbind.SliderInc, kbind.SliderDec},
+		{kbind.NewProfile, kbind.CloneProfile, kbind.RenameProfile, kbind.DeleteProfile},
+		{kbind.SliderInc, kbind.SliderDec, kbind.RateInc, kbind.RateDec},
+		{kbind.PreviewRow, kbind.PreviewFile},
 		{kbind.PageUp, kbind.PageDown, kbind.GotoTop, kbind.GotoBottom},
 	}
 }
 
+// resumeKeyMap shows the answers to the resume prompt, which replace
+// the view-specific keys in the status bar while it is shown.
+type resumeKeyMap struct{}
+
+func (k resumeKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{kbind.Resume, kbind.Restart, kbind.Cancel}
+}
+
+func (k resumeKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{{kbind.Resume, kbind.Restart, kbind.Cancel}}
+}
+
 // getViewSpecificKeyMap returns only view-specific keys (excluding global navigation)
 func getViewSpecificKeyMap(view View) help.KeyMap {
 	switch view {
@@ -40,6 +40,48 @@ func (m *MockConfigManager) EXPECT() *MockConfigManagerMockRecorder {
 	return m.recorder
 }
 
+// CloneProfile mocks base method.
+func (m *MockConfigManager) CloneProfile(source string, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloneProfile", source, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloneProfile indicates an expected call of CloneProfile.
+func (mr *MockConfigManagerMockRecorder) CloneProfile(source, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloneProfile", reflect.TypeOf((*MockConfigManager)(nil).CloneProfile), source, name)
+}
+
+// CreateProfile mocks base method.
+func (m *MockConfigManager) CreateProfile(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateProfile", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateProfile indicates an expected call of CreateProfile.
+func (mr *MockConfigManagerMockRecorder) CreateProfile(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProfile", reflect.TypeOf((*MockConfigManager)(nil).CreateProfile), name)
+}
+
+// DeleteProfile mocks base method.
+func (m *MockConfigManager) DeleteProfile(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProfile", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProfile indicates an expected call of DeleteProfile.
+func (mr *MockConfigManagerMockRecorder) DeleteProfile(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProfile", reflect.TypeOf((*MockConfigManager)(nil).DeleteProfile), name)
+}
+
 // Get mocks base method.
 func (m *MockConfigManager) Get() *config.Config {
 	m.ctrl.T.Helper()
@@ -110,6 +152,34 @@ func (mr *MockConfigManagerMockRecorder) Save() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockConfigManager)(nil).Save))
 }
 
+// ProfileError mocks base method.
+func (m *MockConfigManager) ProfileError(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProfileError", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProfileError indicates an expected call of ProfileError.
+func (mr *MockConfigManagerMockRecorder) ProfileError(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProfileError", reflect.TypeOf((*MockConfigManager)(nil).ProfileError), name)
+}
+
+// RenameProfile mocks base method.
+func (m *MockConfigManager) RenameProfile(oldName string, newName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameProfile", oldName, newName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameProfile indicates an expected call of RenameProfile.
+func (mr *MockConfigManagerMockRecorder) RenameProfile(oldName, newName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameProfile", reflect.TypeOf((*MockConfigManager)(nil).RenameProfile), oldName, newName)
+}
+
 // SetActiveProfile mocks base method.
 func (m *MockConfigManager) SetActiveProfile(name string) error {
 	m.ctrl.T.Helper()
@@ -41,6 +41,21 @@ func (m *MockProcessorController) EXPECT() *MockProcessorControllerMockRecorder
 	return m.recorder
 }
 
+// Checkpoint mocks base method.
+func (m *MockProcessorController) Checkpoint(filePath string) (ports.ProcessorCheckpoint, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Checkpoint", filePath)
+	ret0, _ := ret[0].(ports.ProcessorCheckpoint)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Checkpoint indicates an expected call of Checkpoint.
+func (mr *MockProcessorControllerMockRecorder) Checkpoint(filePath any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkpoint", reflect.TypeOf((*MockProcessorController)(nil).Checkpoint), filePath)
+}
+
 // Do mocks base method.
 func (m *MockProcessorController) Do(ctx context.Context, filePath string) (context.Context, context.CancelFunc) {
 	m.ctrl.T.Helper()
@@ -84,6 +99,20 @@ func (mr *MockProcessorControllerMockRecorder) GetMetrics() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetrics", reflect.TypeOf((*MockProcessorController)(nil).GetMetrics))
 }
 
+// GetRateLimit mocks base method.
+func (m *MockProcessorController) GetRateLimit() float64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRateLimit")
+	ret0, _ := ret[0].(float64)
+	return ret0
+}
+
+// GetRateLimit indicates an expected call of GetRateLimit.
+func (mr *MockProcessorControllerMockRecorder) GetRateLimit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRateLimit", reflect.TypeOf((*MockProcessorController)(nil).GetRateLimit))
+}
+
 // GetWorkerCount mocks base method.
 func (m *MockProcessorController) GetWorkerCount() int {
 	m.ctrl.T.Helper()
@@ -98,6 +127,45 @@ func (mr *MockProcessorControllerMockRecorder) GetWorkerCount() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkerCount", reflect.TypeOf((*MockProcessorController)(nil).GetWorkerCount))
 }
 
+// Resume mocks base method.
+func (m *MockProcessorController) Resume(ctx context.Context, filePath string, cp ports.ProcessorCheckpoint) (context.Context, context.CancelFunc) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resume", ctx, filePath, cp)
+	ret0, _ := ret[0].(context.Context)
+	ret1, _ := ret[1].(context.CancelFunc)
+	return ret0, ret1
+}
+
+// Resume indicates an expected call of Resume.
+func (mr *MockProcessorControllerMockRecorder) Resume(ctx, filePath, cp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockProcessorController)(nil).Resume), ctx, filePath, cp)
+}
+
+// SetDryRun mocks base method.
+func (m *MockProcessorController) SetDryRun(enabled bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetDryRun", enabled)
+}
+
+// SetDryRun indicates an expected call of SetDryRun.
+func (mr *MockProcessorControllerMockRecorder) SetDryRun(enabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDryRun", reflect.TypeOf((*MockProcessorController)(nil).SetDryRun), enabled)
+}
+
+// SetRateLimit mocks base method.
+func (m *MockProcessorController) SetRateLimit(rps float64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRateLimit", rps)
+}
+
+// SetRateLimit indicates an expected call of SetRateLimit.
+func (mr *MockProcessorControllerMockRecorder) SetRateLimit(rps any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRateLimit", reflect.TypeOf((*MockProcessorController)(nil).SetRateLimit), rps)
+}
+
 // SetWorkers mocks base method.
 func (m *MockProcessorController) SetWorkers(n int) {
 	m.ctrl.T.Helper()
@@ -62,6 +62,33 @@ type ProfileSwitchErrorMsg struct {
 	Err error
 }
 
+// ProfileEditedMsg is sent when a profile was created, cloned, renamed
+// or deleted from the Settings sidebar. Text describes the change.
+type ProfileEditedMsg struct {
+	Text string
+}
+
+// ProfileEditErrorMsg is sent when creating, cloning, renaming or
+// deleting a profile fails
+type ProfileEditErrorMsg struct {
+	Err error
+}
+
+// ProfilesReloadedMsg is sent when profile files changed on disk and
+// were reloaded. ActiveChanged reports that the active profile's
+// configuration changed (or it was removed), so the Settings view
+// reloads its form, or warns when the form has unsaved edits. Err is
+// set when the reload failed; Errors lists profiles that could not be
+// reloaded and kept their previous configuration.
+type ProfilesReloadedMsg struct {
+	Added         []string
+	Removed       []string
+	Changed       []string
+	ActiveChanged bool
+	Errors        []error
+	Err           error
+}
+
 // ProcessingStartedMsg is sent when file processing begins
 type ProcessingStartedMsg struct {
 	FilePath string
@@ -69,8 +96,18 @@ type ProcessingStartedMsg struct {
 
 // ProcessingStoppedMsg is sent when processing completes or is cancelled
 type ProcessingStoppedMsg struct {
-	Success bool
-	Err     error
+	FilePath string
+	Success  bool
+	Err      error
+}
+
+// FileAddedMsg is sent to the views when a run produced a new CSV file
+// (the failed rows of the processed file). FilesView adds it to the
+// list, if missing, and moves the cursor to it so it can be run again
+// with a single keystroke.
+type FileAddedMsg struct {
+	FilePath string
+	Title    string
 }
 
 // ProcessingProgressMsg is sent periodically during processing with metrics
@@ -78,6 +115,7 @@ type ProcessingProgressMsg struct {
 	TotalRequests   uint64
 	SuccessRequests uint64
 	ErrorRequests   uint64
+	RetryRequests   uint64
 	LinesProcessed  uint64
 	ActiveWorkers   int
 	RequestsPerSec  float64
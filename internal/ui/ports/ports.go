@@ -13,7 +13,7 @@ import (
 //
 //go:generate mockgen -destination ../mock/config_manager_mock.go -package mock_ui github.com/anibaldeboni/rapper/internal/ui/ports ConfigManager
 type ConfigManager interface {
-	// Get returns the active configuration
+	// Get returns a copy of the active configuration
 	Get() *config.Config
 
 	// Update updates the active configuration in memory
@@ -37,6 +37,22 @@ type ConfigManager interface {
 	// view to preview a non-active profile's config in the form
 	// fields before the user decides to activate it.
 	GetProfile(name string) *config.Config
+
+	// ProfileError returns why the named profile failed to load, or
+	// nil. Broken profiles are listed so their problems can be shown.
+	ProfileError(name string) error
+
+	// CreateProfile creates a profile from a blank template
+	CreateProfile(name string) error
+
+	// CloneProfile creates a profile as a copy of the source profile
+	CloneProfile(source, name string) error
+
+	// RenameProfile renames a profile and moves its file
+	RenameProfile(oldName, newName string) error
+
+	// DeleteProfile deletes a profile and its file
+	DeleteProfile(name string) error
 }
 
 // ConfigProvider defines a read-only configuration interface.
@@ -44,7 +60,7 @@ type ConfigManager interface {
 //
 //go:generate mockgen -destination ../mock/config_provider_mock.go -package mock_ui github.com/anibaldeboni/rapper/internal/ui/ports ConfigProvider
 type ConfigProvider interface {
-	// Get returns the active configuration
+	// Get returns a copy of the active configuration
 	Get() *config.Config
 
 	// OnChange registers a callback for configuration changes
@@ -60,6 +76,14 @@ type ProcessorController interface {
 	// Do starts processing a CSV file
 	Do(ctx context.Context, filePath string) (context.Context, context.CancelFunc)
 
+	// Resume starts processing a CSV file from the given checkpoint,
+	// skipping the lines it records as completed
+	Resume(ctx context.Context, filePath string, cp ProcessorCheckpoint) (context.Context, context.CancelFunc)
+
+	// Checkpoint returns the checkpoint left by an interrupted run of
+	// the file, if any
+	Checkpoint(filePath string) (ProcessorCheckpoint, bool)
+
 	// GetMetrics returns current processing metrics
 	GetMetrics() ProcessorMetrics
 
@@ -73,12 +97,27 @@ type ProcessorController interface {
 	// will accept (processor.MaxWorkers, derived from runtime.NumCPU()).
 	// Consumers (e.g. the Settings slider) use this to bound user input.
 	GetMaxWorkers() int
+
+	// SetRateLimit changes the global requests per second limit
+	// (0 means unlimited). Takes effect on a run in progress.
+	SetRateLimit(rps float64)
+
+	// GetRateLimit returns the global requests per second limit
+	GetRateLimit() float64
+
+	// SetDryRun turns dry-run mode on or off for the next run. In
+	// dry-run mode requests are rendered and logged but not sent.
+	SetDryRun(enabled bool)
 }
 
 // ProcessorMetrics holds real-time processing metrics.
 // This is an alias to processor.Metrics to avoid duplicating the type.
 type ProcessorMetrics = processor.Metrics
 
+// ProcessorCheckpoint records the progress of an interrupted run.
+// This is an alias to processor.Checkpoint to avoid duplicating the type.
+type ProcessorCheckpoint = processor.Checkpoint
+
 // LogProvider defines the interface for reading logs.
 // Used by LogsView to display execution logs.
 //
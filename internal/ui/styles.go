@@ -26,15 +26,19 @@ var (
 				Foreground(compat.AdaptiveColor{Light: lipgloss.Color("#8d8d8d"), Dark: lipgloss.Color("#8d8d8d")}).
 				SetString(inactiveDot).
 				Bold(true)
-	ProgressStyle = lipgloss.NewStyle().Padding(0, 2, 1, 3).Render
-	HelpStyle     = lipgloss.NewStyle().PaddingLeft(1).Render //.Foreground(lipgloss.Color("245"))
-	ViewPortStyle = lipgloss.NewStyle().PaddingTop(1).Render
-	LogoStyle     = lipgloss.NewStyle().
-			Background(lipgloss.Color("#F25D94")).
-			Foreground(lipgloss.Color("#ffffff")).
-			Bold(true).
-			Padding(0, 1).
-			Render
+	// dryRunBadgeStyle marks the status bar while dry-run mode is on.
+	dryRunBadgeStyle = lipgloss.NewStyle().Background(lipgloss.Color("214")).Foreground(lipgloss.Color("0")).Bold(true).Padding(0, 1)
+	// resumePromptStyle highlights the resume question in the status bar.
+	resumePromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true)
+	ProgressStyle     = lipgloss.NewStyle().Padding(0, 2, 1, 3).Render
+	HelpStyle         = lipgloss.NewStyle().PaddingLeft(1).Render //.Foreground(lipgloss.Color("245"))
+	ViewPortStyle     = lipgloss.NewStyle().PaddingTop(1).Render
+	LogoStyle         = lipgloss.NewStyle().
+				Background(lipgloss.Color("#F25D94")).
+				Foreground(lipgloss.Color("#ffffff")).
+				Bold(true).
+				Padding(0, 1).
+				Render
 	HelpKeyStyle = lipgloss.NewStyle().Foreground(compat.AdaptiveColor{
 		Light: lipgloss.Color("#d3d3d3"),
 		Dark:  lipgloss.Color("#d3d3d3"),
@@ -61,7 +61,7 @@ func NewFilesView(csvFiles []list.Item) FilesView {
 	l.KeyMap.CursorDown = kbind.Down
 	l.Styles.PaginationStyle = paginationStyle
 	l.Styles.TitleBar = titleStyle.Bold(true)
-	l.Title = "👀 Select a CSV file to process"
+	l.Title = "👀 Select a CSV or JSON Lines file to process"
 
 	v := FilesView{list: l}
 	v.setTheme(true)
@@ -96,6 +96,7 @@ func (v FilesView) Init() tea.Cmd { return nil }
 // Recognised messages:
 //   - msgs.ViewportSizeMsg: resize the underlying list.
 //   - msgs.ThemeAppliedMsg: re-apply the bullet styles.
+//   - msgs.FileAddedMsg: add the file (if missing) and select it.
 //   - tea.KeyPressMsg on kbind.Select: emit msgs.ItemSelectedMsg
 //     carrying the focused file's path.
 //
@@ -113,6 +114,9 @@ func (v FilesView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case msgs.ThemeAppliedMsg:
 		return v.setTheme(msg.IsDark), nil
 
+	case msgs.FileAddedMsg:
+		return v.addFile(msg)
+
 	case tea.KeyPressMsg:
 		// Only intercept Select; let other keys fall through to the
 		// list. The list handles Up/Down/PgUp/PgDn etc. on its own.
@@ -138,6 +142,22 @@ func (v FilesView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return v, cmd
 }
 
+// addFile moves the cursor to the file in msg, appending it to the list
+// first when it isn't there yet.
+func (v FilesView) addFile(msg msgs.FileAddedMsg) (FilesView, tea.Cmd) {
+	for i, item := range v.list.Items() {
+		if opt, ok := item.(Option[string]); ok && opt.Value == msg.FilePath {
+			v.list.Select(i)
+			return v, nil
+		}
+	}
+
+	index := len(v.list.Items())
+	cmd := v.list.InsertItem(index, Option[string]{Value: msg.FilePath, Title: msg.Title})
+	v.list.Select(index)
+	return v, cmd
+}
+
 // emitItemSelected returns a tea.Cmd that yields msgs.ItemSelectedMsg
 // when run. Extracted so Update stays readable.
 func emitItemSelected(filePath string) tea.Cmd {
@@ -160,6 +180,27 @@ func (v FilesView) ListWidth() int { return v.list.Width() }
 // for test assertions and AppModel state inspection.
 func (v FilesView) ListHeight() int { return v.list.Height() }
 
+// FilePaths returns the paths of the listed files, in order. Exposed
+// for test assertions and AppModel state inspection.
+func (v FilesView) FilePaths() []string {
+	paths := make([]string, 0, len(v.list.Items()))
+	for _, item := range v.list.Items() {
+		if opt, ok := item.(Option[string]); ok {
+			paths = append(paths, opt.Value)
+		}
+	}
+	return paths
+}
+
+// SelectedFilePath returns the path of the focused file, or "" when
+// the list is empty.
+func (v FilesView) SelectedFilePath() string {
+	if opt, ok := v.list.SelectedItem().(Option[string]); ok {
+		return opt.Value
+	}
+	return ""
+}
+
 // fileItemDelegate is the delegate for rendering file list items
 type fileItemDelegate struct{}
 
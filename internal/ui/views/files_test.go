@@ -138,3 +138,34 @@ func TestFilesView_ValueReceiver_PreservesState(t *testing.T) {
 
 	_ = original
 }
+
+// TestFilesView_Update_FileAddedMsg_SelectsFile — a file produced by a
+// run is appended to the list once and the cursor moves to it, so
+// Enter runs it next.
+func TestFilesView_Update_FileAddedMsg_SelectsFile(t *testing.T) {
+	items := []list.Item{
+		Option[string]{Value: "a.csv", Title: "a.csv"},
+		Option[string]{Value: "b.csv", Title: "b.csv"},
+	}
+	v := NewFilesView(items)
+
+	added := msgs.FileAddedMsg{FilePath: "a.failed.csv", Title: "a.failed.csv"}
+	next, _ := v.Update(added)
+	v = next.(FilesView)
+	if got := len(v.list.Items()); got != 3 {
+		t.Fatalf("list must hold 3 items after FileAddedMsg; got %d", got)
+	}
+	if got := v.list.SelectedItem().(Option[string]).Value; got != "a.failed.csv" {
+		t.Fatalf("cursor must move to the added file; got %q", got)
+	}
+
+	v.list.Select(0)
+	next, _ = v.Update(added)
+	v = next.(FilesView)
+	if got := len(v.list.Items()); got != 3 {
+		t.Fatalf("a file already listed must not be added twice; got %d items", got)
+	}
+	if got := v.list.Index(); got != 2 {
+		t.Fatalf("cursor must move back to the listed file; got index %d", got)
+	}
+}
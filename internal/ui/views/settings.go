@@ -1,8 +1,12 @@
 package views
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/key"
@@ -29,6 +33,7 @@ var (
 	headerStyle        = lipgloss.NewStyle().MarginTop(1)
 	inputStyle         = lipgloss.NewStyle().MarginBottom(1)
 	profileBadgeStyle  = lipgloss.NewStyle().Background(lipgloss.Color("99")).Foreground(lipgloss.Color("230")).Padding(0, 1).MarginLeft(2)
+	originStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Italic(true)
 )
 
 // focus panes — the two-pane layout introduced by the persistent
@@ -57,6 +62,7 @@ const (
 	bodyField
 	headersField
 	csvFieldsField
+	previewField
 	maxFields
 )
 
@@ -76,6 +82,10 @@ type SettingsView struct {
 	// pre-tea.Model design.
 	slider components.Slider
 
+	// Global rate limit, rendered next to the slider and sharing its
+	// focus: +/- drive the workers, [/] drive the rate.
+	rate components.RateControl
+
 	// Form fields
 	urlInput       textinput.Model
 	methodInput    textinput.Model
@@ -83,6 +93,14 @@ type SettingsView struct {
 	headersInput   textarea.Model
 	csvFieldsInput textarea.Model
 
+	// origins notes, for a profile that extends another, which fields
+	// are inherited from it and which override it. Keyed by field.
+	origins map[int]string
+
+	// Request preview below the form, rendered from the form's current
+	// values against a row of one of the CSV files.
+	preview requestPreview
+
 	// Persistent profile sidebar. Always visible; cursor drives
 	// preview/activation. The bubbles list is a value type — its
 	// pointer-receiver Update/Select return the modified copy that
@@ -96,6 +114,15 @@ type SettingsView struct {
 
 	// State
 	modified bool
+	// diskConflict is set when the active profile changed on disk
+	// while the form had unsaved changes, which saving overwrites.
+	diskConflict bool
+	// profileErr is why the highlighted profile failed to load. Its
+	// problems are shown in place of the form.
+	profileErr error
+	// dialog is the sidebar prompt for creating, cloning, renaming or
+	// deleting a profile, while open.
+	dialog profileDialog
 }
 
 // Compile-time guard: SettingsView must satisfy tea.Model with a value
@@ -106,8 +133,9 @@ var _ tea.Model = SettingsView{}
 
 // NewSettingsView creates a new SettingsView. The proc controller is required
 // because the worker-count slider at the top of the view mutates the runtime
-// processor immediately on change.
-func NewSettingsView(configMgr ports.ConfigManager, proc ports.ProcessorController) SettingsView {
+// processor immediately on change. csvFiles are the files the request
+// preview can render rows of.
+func NewSettingsView(configMgr ports.ConfigManager, proc ports.ProcessorController, csvFiles []string) SettingsView {
 	// Create URL input
 	urlInput := textinput.New()
 	urlInput.Placeholder = "http://localhost:8080/api/v1/users"
@@ -146,13 +174,14 @@ email`
 
 	initial := proc.GetWorkerCount()
 	slider := components.NewSlider("Worker Count", 1, proc.GetMaxWorkers(), initial)
+	rate := components.NewRateControl("Rate Limit", proc.GetRateLimit())
 
 	profileNames := configMgr.ListProfiles()
 	items := make([]list.Item, len(profileNames))
 	for i, name := range profileNames {
 		items[i] = Option[string]{Value: name, Title: name}
 	}
-	profileList := list.New(items, profileItemDelegate{active: configMgr.GetActiveProfile()}, 0, 0)
+	profileList := list.New(items, newProfileItemDelegate(configMgr, profileNames), 0, 0)
 	profileList.InfiniteScrolling = true
 	profileList.SetShowStatusBar(false)
 	profileList.SetShowPagination(false)
@@ -170,15 +199,17 @@ email`
 		configMgr:      configMgr,
 		proc:           proc,
 		slider:         *slider,
+		rate:           rate,
 		profileList:    profileList,
 		urlInput:       urlInput,
 		methodInput:    methodInput,
 		bodyInput:      bodyInput,
 		headersInput:   headersInput,
 		csvFieldsInput: csvFieldsInput,
+		preview:        newRequestPreview(csvFiles),
 		focused:        sliderField,
 		focusPane:      paneList,
-		focusable:      []int{sliderField, urlField, methodField, bodyField, headersField, csvFieldsField},
+		focusable:      []int{sliderField, urlField, methodField, bodyField, headersField, csvFieldsField, previewField},
 		viewport:       viewport.New(viewport.WithWidth(0), viewport.WithHeight(0)),
 	}
 
@@ -201,10 +232,24 @@ func indexOf(haystack []string, needle string) int {
 
 // profileItemDelegate renders a single row of the profile sidebar.
 // The cursor row gets a "▶ " prefix; the active profile row gets
-// a " ●" suffix. The two decorations are independent so the
-// active profile on the cursor row shows both ("▶ name ●").
+// a " ●" suffix and a profile that failed to load a " ✗" one. The
+// decorations are independent so the active profile on the cursor row
+// shows both ("▶ name ●").
 type profileItemDelegate struct {
-	active string // injected at construction; the name of the active profile
+	active string          // injected at construction; the name of the active profile
+	broken map[string]bool // profiles that failed to load
+}
+
+// newProfileItemDelegate returns the delegate for the given profiles,
+// marking the active one and those that failed to load.
+func newProfileItemDelegate(configMgr ports.ConfigManager, names []string) profileItemDelegate {
+	d := profileItemDelegate{active: configMgr.GetActiveProfile(), broken: make(map[string]bool)}
+	for _, name := range names {
+		if configMgr.ProfileError(name) != nil {
+			d.broken[name] = true
+		}
+	}
+	return d
 }
 
 func (d profileItemDelegate) Height() int                             { return 1 }
@@ -223,6 +268,9 @@ func (d profileItemDelegate) Render(w io.Writer, m list.Model, index int, item l
 	if opt.Value == d.active {
 		tag = " ●"
 	}
+	if d.broken[opt.Value] {
+		tag += " ✗"
+	}
 	fmt.Fprintf(w, "%s%s%s", prefix, opt.Value, tag)
 }
 
@@ -240,6 +288,7 @@ func (v SettingsView) Init() tea.Cmd { return nil }
 // MUST capture the returned value — the value receiver means the
 // original struct is never mutated.
 func (v SettingsView) loadConfig() SettingsView {
+	v.profileErr = v.configMgr.ProfileError(v.configMgr.GetActiveProfile())
 	return v.loadFromConfig(v.configMgr.Get())
 }
 
@@ -253,30 +302,76 @@ func (v SettingsView) loadFromConfig(cfg *config.Config) SettingsView {
 		return v
 	}
 
-	v.urlInput.SetValue(cfg.Request.URLTemplate)
+	// The form shows the profile's ${...} references, never the
+	// secrets they resolved to.
+	v.urlInput.SetValue(cfg.Raw("request.url_template", cfg.Request.URLTemplate))
 
 	// Set method (default to POST if empty)
-	method := cfg.Request.Method
+	method := cfg.Raw("request.method", cfg.Request.Method)
 	if method == "" {
 		method = "POST"
 	}
 	v.methodInput.SetValue(method)
 
-	v.bodyInput.SetValue(cfg.Request.BodyTemplate)
+	v.bodyInput.SetValue(cfg.Raw("request.body_template", cfg.Request.BodyTemplate))
 
 	// Convert headers map to string
 	var headerLines []string
 	for key, value := range cfg.Request.Headers {
-		headerLines = append(headerLines, fmt.Sprintf("%s: %s", key, value))
+		headerLines = append(headerLines, fmt.Sprintf("%s: %s", key, cfg.Raw("request.headers."+key, value)))
 	}
 	v.headersInput.SetValue(strings.Join(headerLines, "\n"))
 
 	// Convert CSV fields slice to string
 	v.csvFieldsInput.SetValue(strings.Join(cfg.CSV.Fields, "\n"))
 
+	v.origins = fieldOrigins(cfg)
+
+	v.preview = v.preview.load(cfg.CSV)
+
 	return v
 }
 
+// fieldOrigins describes, for a profile that extends another, whether
+// each form field is inherited from it or overrides it. Headers are
+// merged key by key, so they are described per header.
+func fieldOrigins(cfg *config.Config) map[int]string {
+	base := cfg.Extends()
+	if base == "" {
+		return nil
+	}
+	origin := func(path string) string {
+		if cfg.Inherited(path) {
+			return "inherited from " + base
+		}
+		return "overrides " + base
+	}
+
+	var inherited, overridden []string
+	for _, name := range slices.Sorted(maps.Keys(cfg.Request.Headers)) {
+		if cfg.Inherited("request.headers." + name) {
+			inherited = append(inherited, name)
+		} else {
+			overridden = append(overridden, name)
+		}
+	}
+	var headers []string
+	if len(overridden) > 0 {
+		headers = append(headers, "overrides "+strings.Join(overridden, ", "))
+	}
+	if len(inherited) > 0 {
+		headers = append(headers, "inherits "+strings.Join(inherited, ", ")+" from "+base)
+	}
+
+	return map[int]string{
+		urlField:       origin("request.url_template"),
+		methodField:    origin("request.method"),
+		bodyField:      origin("request.body_template"),
+		headersField:   strings.Join(headers, "; "),
+		csvFieldsField: origin("csv.fields"),
+	}
+}
+
 // parseHeaders converts headers string to map
 func parseHeaders(headersText string) map[string]string {
 	headers := make(map[string]string)
@@ -349,10 +444,12 @@ func (v SettingsView) updateFocus() SettingsView {
 	v.headersInput.Blur()
 	v.csvFieldsInput.Blur()
 	v.slider.Focused = false
+	v.rate.Focused = false
 
 	switch v.focused {
 	case sliderField:
 		v.slider.Focused = true
+		v.rate.Focused = true
 	case urlField:
 		v.urlInput.Focus()
 	case methodField:
@@ -378,6 +475,13 @@ func (v SettingsView) nextField() SettingsView {
 
 func (v SettingsView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case msgs.FileAddedMsg:
+		v.preview = v.preview.addFile(msg.FilePath)
+		return v, nil
+
+	case msgs.ProfilesReloadedMsg:
+		return v.reloadProfiles(msg), nil
+
 	case msgs.ViewportSizeMsg:
 		v.width = msg.Width
 		v.height = msg.Height
@@ -395,9 +499,13 @@ func (v SettingsView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return v, nil
 
 	case tea.KeyPressMsg:
+		if v.dialog.open() {
+			return v.updateDialog(msg)
+		}
+
 		switch {
 		case key.Matches(msg, kbind.Save):
-			return v, v.saveConfigCmd()
+			return v.save()
 
 		case key.Matches(msg, kbind.PaneToggle):
 			// Tab toggles the focus pane: paneList ↔ paneForm. It
@@ -427,8 +535,19 @@ func (v SettingsView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		if v.focusPane == paneList {
+			switch {
+			case key.Matches(msg, kbind.NewProfile):
+				return v.openDialog(createProfile)
+			case key.Matches(msg, kbind.CloneProfile):
+				return v.openDialog(cloneProfile)
+			case key.Matches(msg, kbind.RenameProfile):
+				return v.openDialog(renameProfile)
+			case key.Matches(msg, kbind.DeleteProfile):
+				return v.openDialog(deleteProfile)
+			}
+
 			oldIdx := v.profileList.Index()
-			v.modified = false
+			v.modified, v.diskConflict = false, false
 			var listCmd tea.Cmd
 			v.profileList, listCmd = v.profileList.Update(msg)
 			if v.profileList.Index() != oldIdx {
@@ -460,6 +579,11 @@ func (v SettingsView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			v.proc.SetWorkers(v.slider.Value)
 			v.modified = true
 		}
+		prevRate := v.rate.Value
+		v.rate, _ = v.rate.Update(msg)
+		if v.rate.Value != prevRate {
+			v.proc.SetRateLimit(v.rate.Value)
+		}
 		return v, nil
 	case urlField:
 		oldValue = v.urlInput.Value()
@@ -494,6 +618,25 @@ func (v SettingsView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		v.csvFieldsInput, cmd = v.csvFieldsInput.Update(msg)
 		if v.csvFieldsInput.Value() != oldValue {
 			v.modified = true
+			// The fields filter decides which columns the templates
+			// see, so the preview rows are mapped again.
+			csv := v.preview.csv
+			csv.Fields = parseCSVFields(v.csvFieldsInput.Value())
+			v.preview = v.preview.load(csv)
+		}
+
+	case previewField:
+		if msg, ok := msg.(tea.KeyPressMsg); ok {
+			switch {
+			case key.Matches(msg, kbind.Left):
+				v.preview = v.preview.stepRow(-1)
+			case key.Matches(msg, kbind.Right):
+				v.preview = v.preview.stepRow(1)
+			case key.Matches(msg, kbind.Up):
+				v.preview = v.preview.stepFile(-1)
+			case key.Matches(msg, kbind.Down):
+				v.preview = v.preview.stepFile(1)
+			}
 		}
 	}
 
@@ -506,29 +649,43 @@ func (v SettingsView) View() tea.View {
 
 	listBg := v.paneBg(v.focusPane == paneList)
 	listTitle := headerStyle.MarginLeft(1).Render(settingsTitleStyle.Render("📋 Profiles"))
+	profiles := v.profileList.View()
+	if v.dialog.open() {
+		// Shrink the list to make room for the dialog below it.
+		l := v.profileList
+		l.SetHeight(max(l.Height()-dialogLines, 1))
+		profiles = lipgloss.JoinVertical(lipgloss.Left, l.View(), "", v.dialog.view(listW-4))
+	}
 	listPane := lipgloss.JoinVertical(
 		lipgloss.Top,
 		listTitle,
 		listBg.
 			Width(listW).
 			Height(v.height-3).
-			Render(v.profileList.View()),
+			Render(profiles),
 	)
 
 	var help string
-	if v.modified {
+	switch {
+	case v.diskConflict:
+		help = "⚠️  Profile changed on disk: saving overwrites it with your unsaved changes"
+	case v.modified:
 		help = "⚠️  Unsaved changes"
 	}
 	formContent := lipgloss.JoinVertical(
 		lipgloss.Top,
-		inputStyle.Render(v.slider.View()),
+		inputStyle.Render(v.slider.View()+"   "+v.rate.View()),
 		v.renderInput(urlField, "URL template:", v.urlInput),
 		v.renderInput(methodField, "Method:", v.methodInput),
 		v.renderTextArea(bodyField, "Body template:", v.bodyInput),
 		v.renderTextArea(headersField, "Headers:", v.headersInput),
 		v.renderTextArea(csvFieldsField, "CSV Fields (one per line):", v.csvFieldsInput),
+		v.renderPreview(),
 		helpStyle.Render(help),
 	)
+	if v.profileErr != nil {
+		formContent = lipgloss.JoinVertical(lipgloss.Top, v.renderProblems(), helpStyle.Render(help))
+	}
 	v.viewport.SetContent(formContent)
 
 	formBg := v.paneBg(v.focusPane == paneForm)
@@ -570,12 +727,49 @@ func (v SettingsView) renderInput(fieldIdx int, text string, input textinput.Mod
 	return inputStyle.Render(lipgloss.JoinVertical(lipgloss.Left, label, input.View()))
 }
 
-// renderLabel renders a label with focus indication
+// renderProblems lists why the highlighted profile failed to load, one
+// problem per line with the file, line and column it is at.
+func (v SettingsView) renderProblems() string {
+	lines := []string{previewErrorStyle.Bold(true).Render("✗ This profile failed to load:")}
+	var validationErr *config.ValidationError
+	if errors.As(v.profileErr, &validationErr) {
+		for _, p := range validationErr.Problems {
+			p.File = filepath.Base(p.File)
+			if p.Warning {
+				p.Message = "warning: " + p.Message
+			}
+			lines = append(lines, previewErrorStyle.Render(p.String()))
+		}
+	} else {
+		lines = append(lines, previewErrorStyle.Render(v.profileErr.Error()))
+	}
+	return inputStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// renderPreview renders the request the form's current values produce
+// for the selected preview row.
+func (v SettingsView) renderPreview() string {
+	label := v.renderLabel("Preview (←/→ row, ↑/↓ file):", previewField)
+	body := v.preview.view(
+		v.methodInput.Value(),
+		v.urlInput.Value(),
+		v.bodyInput.Value(),
+		parseHeaders(v.headersInput.Value()),
+	)
+	return inputStyle.Render(lipgloss.JoinVertical(lipgloss.Left, label, body))
+}
+
+// renderLabel renders a label with focus indication, followed by where
+// the field's value comes from when the profile extends another.
 func (v SettingsView) renderLabel(text string, fieldIdx int) string {
+	label := labelStyle.Render(text)
 	if v.focused == fieldIdx {
-		return focusedStyle.Render("▶ " + text)
+		label = focusedStyle.Render("▶ " + text)
 	}
-	return labelStyle.Render(text)
+	if origin := v.origins[fieldIdx]; origin != "" {
+		label += " " + originStyle.Render(origin)
+	}
+	return label
 }
 
 // getActiveProfileName returns the name of the active profile
@@ -590,9 +784,11 @@ func (v SettingsView) getActiveProfileName() string {
 // previewProfile loads the named profile's config into the form
 // fields without making it active. The list cursor already moved
 // before this is called; this is just the form-repopulation step.
-// Returns the modified SettingsView. A nil config (unknown name)
-// is a no-op so the form keeps its current values.
+// Returns the modified SettingsView. A nil config (unknown name, or a
+// profile that failed to load) is a no-op so the form keeps its
+// current values; a broken profile shows its problems instead.
 func (v SettingsView) previewProfile(name string) SettingsView {
+	v.profileErr = v.configMgr.ProfileError(name)
 	return v.loadFromConfig(v.configMgr.GetProfile(name))
 }
 
@@ -618,15 +814,45 @@ func (v SettingsView) activateProfile(name string) (SettingsView, tea.Cmd) {
 	}
 }
 
-// saveConfigCmd saves the configuration and returns a command
-func (v SettingsView) saveConfigCmd() tea.Cmd {
+// save saves the configuration and returns the modified copy and a
+// command reporting the outcome. A successful save leaves no unsaved
+// changes nor a conflict with the file on disk.
+func (v SettingsView) save() (SettingsView, tea.Cmd) {
 	if err := v.saveConfig(); err != nil {
-		return func() tea.Msg {
+		return v, func() tea.Msg {
 			return msgs.ConfigSaveErrorMsg{Err: err}
 		}
 	}
 
-	return func() tea.Msg {
+	v.modified, v.diskConflict = false, false
+	return v, func() tea.Msg {
 		return msgs.ConfigSavedMsg{}
 	}
 }
+
+// reloadProfiles refreshes the profile list after profile files
+// changed on disk, keeping the cursor on the same profile when it still
+// exists. The form follows the changes unless it has unsaved edits: then
+// they are kept, and a change to the active profile is flagged since
+// saving would overwrite it.
+func (v SettingsView) reloadProfiles(msg msgs.ProfilesReloadedMsg) SettingsView {
+	selected := v.configMgr.GetActiveProfile()
+	if opt, ok := v.profileList.SelectedItem().(Option[string]); ok {
+		selected = opt.Value
+	}
+
+	v = v.refreshProfileList(selected)
+	if opt, ok := v.profileList.SelectedItem().(Option[string]); ok {
+		selected = opt.Value
+	}
+
+	v.profileErr = v.configMgr.ProfileError(selected)
+	if v.modified {
+		v.diskConflict = v.diskConflict || msg.ActiveChanged
+		return v
+	}
+	if msg.ActiveChanged || slices.Contains(msg.Changed, selected) {
+		return v.previewProfile(selected)
+	}
+	return v
+}
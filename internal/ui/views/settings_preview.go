@@ -0,0 +1,133 @@
+package views
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/anibaldeboni/rapper/internal/config"
+	"github.com/anibaldeboni/rapper/internal/processor"
+	"github.com/anibaldeboni/rapper/internal/web"
+)
+
+// previewRows is how many rows of the selected CSV file the preview
+// loads; the user steps through them with Left/Right.
+const previewRows = 20
+
+var (
+	previewMetaStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	previewErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+)
+
+// requestPreview renders the form's templates against one row of a
+// CSV file so the user sees the request a run would send while typing.
+// Like the views it lives in, it is a value type: every method returns
+// the modified copy.
+type requestPreview struct {
+	files []string
+	file  int
+
+	// csv is the CSV configuration the rows were loaded with: the
+	// separator of the loaded profile and the fields of the form.
+	csv     config.CSVConfig
+	rows    []map[string]string
+	row     int
+	loadErr error
+}
+
+func newRequestPreview(files []string) requestPreview {
+	return requestPreview{files: slices.Clone(files)}
+}
+
+// load re-reads the rows of the selected file with csv, keeping the
+// current row when the file still has it.
+func (p requestPreview) load(csv config.CSVConfig) requestPreview {
+	p.csv = csv
+	p.rows, p.loadErr = nil, nil
+	if len(p.files) == 0 {
+		return p
+	}
+	p.rows, p.loadErr = processor.PreviewRows(p.files[p.file], csv, previewRows)
+	p.row = min(p.row, max(len(p.rows)-1, 0))
+	return p
+}
+
+// addFile makes path available to the preview, loading it when it is
+// the first file.
+func (p requestPreview) addFile(path string) requestPreview {
+	if slices.Contains(p.files, path) {
+		return p
+	}
+	p.files = append(p.files, path)
+	if len(p.files) == 1 {
+		return p.load(p.csv)
+	}
+	return p
+}
+
+// stepRow moves to the previous (delta < 0) or next row, wrapping.
+func (p requestPreview) stepRow(delta int) requestPreview {
+	if len(p.rows) == 0 {
+		return p
+	}
+	p.row = (p.row + delta + len(p.rows)) % len(p.rows)
+	return p
+}
+
+// stepFile moves to the previous (delta < 0) or next file, wrapping,
+// and loads its rows from the first one.
+func (p requestPreview) stepFile(delta int) requestPreview {
+	if len(p.files) < 2 {
+		return p
+	}
+	p.file = (p.file + delta + len(p.files)) % len(p.files)
+	p.row = 0
+	return p.load(p.csv)
+}
+
+// view renders the request for the current row. Template errors are
+// shown below what did render instead of being silently dropped.
+func (p requestPreview) view(method, urlTemplate, bodyTemplate string, headers map[string]string) string {
+	if len(p.files) == 0 {
+		return previewMetaStyle.Render("No CSV file to preview")
+	}
+
+	source := fmt.Sprintf("%s · row %d of %d", filepath.Base(p.files[p.file]), p.row+1, len(p.rows))
+	if len(p.rows) == 0 {
+		source = filepath.Base(p.files[p.file]) + " · no rows"
+	}
+	lines := []string{previewMetaStyle.Render(source)}
+	if p.loadErr != nil {
+		lines = append(lines, previewErrorStyle.Render(p.loadErr.Error()))
+	}
+	if len(p.rows) == 0 {
+		return strings.Join(lines, "\n")
+	}
+
+	req, err := web.RenderRequest(method, urlTemplate, bodyTemplate, headers, p.rows[p.row])
+	lines = append(lines, req.Method+" "+req.URL)
+	for _, name := range slices.Sorted(maps.Keys(req.Headers)) {
+		lines = append(lines, name+": "+req.Headers[name])
+	}
+	if len(req.Body) > 0 {
+		lines = append(lines, "", prettyBody(req.Body))
+	}
+	if err != nil {
+		lines = append(lines, "", previewErrorStyle.Render(err.Error()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prettyBody indents a JSON body; anything else is shown as is.
+func prettyBody(body []byte) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, body, "", "  "); err != nil {
+		return string(body)
+	}
+	return buf.String()
+}
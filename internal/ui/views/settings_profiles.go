@@ -0,0 +1,182 @@
+package views
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/list"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/anibaldeboni/rapper/internal/ui/kbind"
+	"github.com/anibaldeboni/rapper/internal/ui/msgs"
+)
+
+// profileAction is what a profileDialog does once answered.
+type profileAction int
+
+const (
+	noProfileAction profileAction = iota
+	createProfile
+	cloneProfile
+	renameProfile
+	deleteProfile
+)
+
+// dialogLines is the height the profile dialog takes below the list.
+const dialogLines = 3
+
+var dialogStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Bold(true)
+
+// profileDialog is the prompt the profile sidebar shows while a profile
+// is created, cloned, renamed or deleted: a name input, or a yes/no
+// confirmation for deleting. The zero value is a closed dialog.
+type profileDialog struct {
+	action profileAction
+	target string          // highlighted profile: cloned, renamed or deleted
+	name   textinput.Model // new name; unused when deleting
+}
+
+func (d profileDialog) open() bool { return d.action != noProfileAction }
+
+func (d profileDialog) prompt() string {
+	switch d.action {
+	case createProfile:
+		return "New profile name:"
+	case cloneProfile:
+		return "Clone " + d.target + " as:"
+	case renameProfile:
+		return "Rename " + d.target + " to:"
+	case deleteProfile:
+		return "Delete " + d.target + "? (y/n)"
+	}
+	return ""
+}
+
+func (d profileDialog) view(width int) string {
+	prompt := dialogStyle.Width(width).Render(d.prompt())
+	if d.action == deleteProfile {
+		return prompt
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, prompt, d.name.View())
+}
+
+// CapturesInput reports whether the profile dialog is open, so AppModel
+// sends it every key, including the letters of global bindings.
+func (v SettingsView) CapturesInput() bool { return v.dialog.open() }
+
+// openDialog opens the profile dialog for action on the highlighted
+// profile, with the name input focused.
+func (v SettingsView) openDialog(action profileAction) (SettingsView, tea.Cmd) {
+	target := ""
+	if opt, ok := v.profileList.SelectedItem().(Option[string]); ok {
+		target = opt.Value
+	}
+	if target == "" && action != createProfile {
+		return v, nil
+	}
+
+	name := textinput.New()
+	name.Prompt = "> "
+	name.CharLimit = 64
+	name.SetWidth(max(v.profileList.Width()-4, 1))
+	if action == cloneProfile {
+		name.SetValue(target + "-copy")
+	}
+	if action == renameProfile {
+		name.SetValue(target)
+	}
+	v.dialog = profileDialog{action: action, target: target, name: name}
+	if action == deleteProfile {
+		return v, nil
+	}
+	return v, v.dialog.name.Focus()
+}
+
+// updateDialog handles a keypress while the profile dialog is open.
+// Enter (or y when deleting) applies it, Esc closes it, and other keys
+// edit the name.
+func (v SettingsView) updateDialog(msg tea.KeyPressMsg) (SettingsView, tea.Cmd) {
+	if v.dialog.action == deleteProfile {
+		switch {
+		case key.Matches(msg, kbind.Confirm):
+			return v.applyDialog()
+		case key.Matches(msg, kbind.Deny):
+			v.dialog = profileDialog{}
+		}
+		return v, nil
+	}
+
+	switch {
+	case key.Matches(msg, kbind.Cancel):
+		v.dialog = profileDialog{}
+		return v, nil
+	case key.Matches(msg, kbind.Select):
+		return v.applyDialog()
+	}
+	var cmd tea.Cmd
+	v.dialog.name, cmd = v.dialog.name.Update(msg)
+	return v, cmd
+}
+
+// applyDialog creates, clones, renames or deletes the profile, then
+// highlights the resulting profile and shows it in the form. A failed
+// name is kept in the dialog so it can be corrected.
+func (v SettingsView) applyDialog() (SettingsView, tea.Cmd) {
+	d := v.dialog
+	name := strings.TrimSpace(d.name.Value())
+
+	var (
+		err  error
+		text string
+	)
+	switch d.action {
+	case createProfile:
+		err = v.configMgr.CreateProfile(name)
+		text = "Created profile: " + name
+	case cloneProfile:
+		err = v.configMgr.CloneProfile(d.target, name)
+		text = "Cloned " + d.target + " as: " + name
+	case renameProfile:
+		err = v.configMgr.RenameProfile(d.target, name)
+		text = "Renamed " + d.target + " to: " + name
+	case deleteProfile:
+		err = v.configMgr.DeleteProfile(d.target)
+		text = "Deleted profile: " + d.target
+		name = v.configMgr.GetActiveProfile()
+	}
+	if err != nil {
+		if d.action == deleteProfile {
+			v.dialog = profileDialog{}
+		}
+		return v, func() tea.Msg { return msgs.ProfileEditErrorMsg{Err: err} }
+	}
+
+	v.dialog = profileDialog{}
+	v = v.refreshProfileList(name)
+	v.modified, v.diskConflict = false, false
+	if opt, ok := v.profileList.SelectedItem().(Option[string]); ok {
+		v = v.previewProfile(opt.Value)
+	}
+	return v, func() tea.Msg { return msgs.ProfileEditedMsg{Text: text} }
+}
+
+// refreshProfileList rebuilds the profile list from the config manager
+// and highlights selected, or the active profile when selected is gone.
+func (v SettingsView) refreshProfileList(selected string) SettingsView {
+	names := v.configMgr.ListProfiles()
+	active := v.configMgr.GetActiveProfile()
+	items := make([]list.Item, len(names))
+	for i, name := range names {
+		items[i] = Option[string]{Value: name, Title: name}
+	}
+	v.profileList.SetItems(items)
+	v.profileList.SetDelegate(newProfileItemDelegate(v.configMgr, names))
+
+	i := indexOf(names, selected)
+	if i < 0 {
+		i = max(indexOf(names, active), 0)
+	}
+	v.profileList.Select(i)
+	return v
+}
@@ -1,7 +1,10 @@
 package views
 
 import (
+	"errors"
 	"image/color"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -45,6 +48,8 @@ type settingsViewOpts struct {
 	maxWorkers  int
 	profiles    []string
 	activeName  string
+	files       []string
+	profileErrs map[string]error
 }
 
 func withConfig(c *config.Config) settingsViewOpt {
@@ -67,10 +72,19 @@ func withActiveProfile(name string) settingsViewOpt {
 	return func(o *settingsViewOpts) { o.activeName = name }
 }
 
+func withProfileErrors(errs map[string]error) settingsViewOpt {
+	return func(o *settingsViewOpts) { o.profileErrs = errs }
+}
+
+func withPreviewFiles(files ...string) settingsViewOpt {
+	return func(o *settingsViewOpts) { o.files = files }
+}
+
 // newTestSettingsView builds a SettingsView with gomock-backed
 // ConfigManager and ProcessorController and returns all three. Default
 // expectations: Get→nil, GetWorkerCount→1, GetMaxWorkers→1,
-// ListProfiles→["default"], GetActiveProfile→"default" — all .AnyTimes().
+// ListProfiles→["default"], GetActiveProfile→"default", ProfileError→nil
+// — all .AnyTimes().
 // Tests that need different values pass withConfig / withWorkerCount /
 // withMaxWorkers; tests that need stricter expectations on Update/Save
 // layer them on the returned mocks after the helper call.
@@ -97,11 +111,13 @@ func newTestSettingsView(t *testing.T, opts ...settingsViewOpt) (
 
 	configMgr.EXPECT().Get().Return(o.cfg).AnyTimes()
 	configMgr.EXPECT().ListProfiles().Return(o.profiles).AnyTimes()
+	configMgr.EXPECT().ProfileError(gomock.Any()).DoAndReturn(func(name string) error { return o.profileErrs[name] }).AnyTimes()
 	configMgr.EXPECT().GetActiveProfile().Return(o.activeName).AnyTimes()
 	proc.EXPECT().GetWorkerCount().Return(o.workerCount).AnyTimes()
 	proc.EXPECT().GetMaxWorkers().Return(o.maxWorkers).AnyTimes()
+	proc.EXPECT().GetRateLimit().Return(0.0).AnyTimes()
 
-	return NewSettingsView(configMgr, proc), configMgr, proc
+	return NewSettingsView(configMgr, proc, o.files), configMgr, proc
 }
 
 func TestSettingsView_SliderFocusedOnConstruction_PlusKeyIncrements(t *testing.T) {
@@ -386,7 +402,7 @@ func TestSettingsView_ShiftTabInFormCyclesBackward(t *testing.T) {
 // NOTE: source behavior as of 2026-07-10 — see decision #178.
 // SettingsView.Update's PrevField handler calls `v.nextField()`,
 // which advances and wraps forward: csvFieldsField (5) →
-// sliderField (0) → urlField (1). This test asserts the current
+// previewField (6) → sliderField (0) → urlField (1). This test asserts the current
 // source behavior so the regression guard reflects reality.
 func TestSettingsView_ShiftTabWrapsFromCsvToHeaders(t *testing.T) {
 	v, _, _ := newTestSettingsView(t)
@@ -394,13 +410,18 @@ func TestSettingsView_ShiftTabWrapsFromCsvToHeaders(t *testing.T) {
 	v.focused = csvFieldsField
 
 	var next tea.Model
+	next, _ = v.Update(settingsKeyMsg(kbind.PrevField.Keys()[0]))
+	v = next.(SettingsView)
+	assert.Equal(t, previewField, v.focused,
+		"Shift+Tab from csvFieldsField moves on to the request preview")
+
 	next, _ = v.Update(settingsKeyMsg(kbind.PrevField.Keys()[0]))
 	v = next.(SettingsView)
 	// NOTE: source behavior as of 2026-07-10 — see decision #178.
-	// PrevField calls nextField; from csvFieldsField (5) it wraps
+	// PrevField calls nextField; from previewField (6) it wraps
 	// to sliderField (0), not headersField.
 	assert.Equal(t, sliderField, v.focused,
-		"Shift+Tab from csvFieldsField wraps to sliderField (PrevField calls nextField)")
+		"Shift+Tab from previewField wraps to sliderField (PrevField calls nextField)")
 
 	next, _ = v.Update(settingsKeyMsg(kbind.PrevField.Keys()[0]))
 	v = next.(SettingsView)
@@ -976,7 +997,7 @@ func TestSettingsView_EnterOnActiveProfile(t *testing.T) {
 }
 
 // TestSettingsView_CtrlSInPaneListSaves is S-19.1. Ctrl+S
-// must trigger saveConfigCmd when focusPane == paneList. The
+// must trigger save when focusPane == paneList. The
 // global Save handler runs before the pane branch, so the
 // save fires regardless of which pane has focus.
 func TestSettingsView_CtrlSInPaneListSaves(t *testing.T) {
@@ -993,7 +1014,7 @@ func TestSettingsView_CtrlSInPaneListSaves(t *testing.T) {
 }
 
 // TestSettingsView_CtrlSInPaneFormSaves is S-19.2. Ctrl+S
-// must trigger saveConfigCmd when focusPane == paneForm.
+// must trigger save when focusPane == paneForm.
 // The save fires regardless of which form field is focused.
 func TestSettingsView_CtrlSInPaneFormSaves(t *testing.T) {
 	v, configMgr, _ := newTestSettingsView(t,
@@ -1111,7 +1132,7 @@ func TestSettingsView_SliderFocused_CtrlS_TriggersSave(t *testing.T) {
 	next, cmd = v.Update(settingsKeyMsg("ctrl+s"))
 	v = next.(SettingsView)
 	assert.NotNil(t, cmd,
-		"Ctrl+S must trigger saveConfigCmd when the slider is focused; "+
+		"Ctrl+S must trigger save when the slider is focused; "+
 			"the slider key-handling block must not swallow the Save global shortcut")
 }
 
@@ -1199,6 +1220,37 @@ func TestSettingsView_LoadConfig_PopulatesInputs(t *testing.T) {
 		"csvFieldsInput must reflect config.CSV.Fields joined by newline")
 }
 
+// TestSettingsView_LoadConfig_ShowsInheritedFields proves a profile
+// that extends another labels each field with where its value comes
+// from.
+func TestSettingsView_LoadConfig_ShowsInheritedFields(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.yml"), []byte(`request:
+    method: POST
+    url_template: https://staging.example
+    headers:
+        Authorization: Bearer staging
+        Content-Type: application/json
+csv:
+    fields: [id]
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "production.yml"), []byte(`extends: base
+request:
+    url_template: https://api.example
+    headers:
+        Authorization: Bearer prod
+`), 0o600))
+	cfg, err := config.NewLoader().Load(filepath.Join(dir, "production.yml"))
+	require.NoError(t, err)
+
+	v, _, _ := newTestSettingsView(t, withConfig(cfg))
+
+	assert.Equal(t, "overrides base", v.origins[urlField])
+	assert.Equal(t, "inherited from base", v.origins[methodField])
+	assert.Equal(t, "overrides Authorization; inherits Content-Type from base", v.origins[headersField])
+	assert.Contains(t, v.renderLabel("Method:", methodField), "inherited from base")
+}
+
 // TestSettingsView_LoadConfig_EmptyMethodDefaultsToPost is the
 // regression test for the "empty method defaults to POST" scenario
 // from the spec.
@@ -1215,3 +1267,245 @@ func TestSettingsView_LoadConfig_EmptyMethodDefaultsToPost(t *testing.T) {
 	assert.Equal(t, "POST", v.methodInput.Value(),
 		"empty Request.Method must default to POST in the form")
 }
+
+// TestSettingsView_RateKeysStepTheRateLimit proves [ and ] walk the
+// rate ladder while the slider row is focused and push each change to
+// the processor immediately, like the worker slider does.
+func TestSettingsView_RateKeysStepTheRateLimit(t *testing.T) {
+	v, _, proc := newTestSettingsView(t)
+	v.focusPane = paneForm
+	v.focused = sliderField
+
+	gomock.InOrder(
+		proc.EXPECT().SetRateLimit(0.5),
+		proc.EXPECT().SetRateLimit(1.0),
+		proc.EXPECT().SetRateLimit(0.5),
+		proc.EXPECT().SetRateLimit(0.0),
+	)
+
+	var next tea.Model
+	for _, k := range []string{"]", "]", "["} {
+		next, _ = v.Update(settingsKeyMsg(k))
+		v = next.(SettingsView)
+	}
+	assert.Equal(t, 0.5, v.rate.Value)
+
+	// Back down to unlimited; a further [ at the bottom is a no-op.
+	next, _ = v.Update(settingsKeyMsg("["))
+	v = next.(SettingsView)
+	next, _ = v.Update(settingsKeyMsg("["))
+	v = next.(SettingsView)
+	assert.Equal(t, 0.0, v.rate.Value)
+}
+
+// TestSettingsView_Preview_RendersFormAgainstRow — the preview renders
+// the form's current templates against the selected CSV row, follows
+// edits as they are typed and steps through rows with Left/Right.
+func TestSettingsView_Preview_RendersFormAgainstRow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id,name\n1,ana\n2,bob\n"), 0o600))
+
+	cfg := &config.Config{
+		Request: config.RequestConfig{
+			Method:       "PUT",
+			URLTemplate:  "https://api.example/{{.id}}",
+			BodyTemplate: `{"name":"{{.name}}"}`,
+			Headers:      map[string]string{"X-Id": "{{.id}}"},
+		},
+	}
+	v, _, _ := newTestSettingsView(t, withConfig(cfg), withPreviewFiles(path))
+	v.focusPane = paneForm
+
+	preview := v.renderPreview()
+	assert.Contains(t, preview, "users.csv · row 1 of 2")
+	assert.Contains(t, preview, "PUT https://api.example/1")
+	assert.Contains(t, preview, "X-Id: 1")
+	assert.Contains(t, preview, "\"name\": \"ana\"", "JSON bodies are pretty-printed")
+
+	v.focused = previewField
+	v = v.updateFocus()
+	next, _ := v.Update(tea.KeyPressMsg{Code: tea.KeyRight})
+	v = next.(SettingsView)
+	assert.Contains(t, v.renderPreview(), "PUT https://api.example/2")
+
+	v.focused = urlField
+	v = v.updateFocus()
+	next, _ = v.Update(settingsKeyMsg("/"))
+	v = next.(SettingsView)
+	assert.Contains(t, v.renderPreview(), "PUT https://api.example/2/")
+}
+
+// TestSettingsView_Preview_ShowsTemplateErrors — a template that does
+// not parse is reported in the preview instead of rendering nothing.
+func TestSettingsView_Preview_ShowsTemplateErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id\n1\n"), 0o600))
+
+	cfg := &config.Config{Request: config.RequestConfig{URLTemplate: "https://api.example/{{.id}}", BodyTemplate: "{{.id"}}
+	v, _, _ := newTestSettingsView(t, withConfig(cfg), withPreviewFiles(path))
+
+	preview := v.renderPreview()
+	assert.Contains(t, preview, "https://api.example/1")
+	assert.Contains(t, preview, "invalid body template")
+}
+
+// TestSettingsView_Preview_FileAddedMsg — files produced by a run
+// become available to the preview.
+func TestSettingsView_Preview_FileAddedMsg(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.failed.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id\n7\n"), 0o600))
+
+	cfg := &config.Config{Request: config.RequestConfig{Method: "GET", URLTemplate: "https://api.example/{{.id}}"}}
+	v, _, _ := newTestSettingsView(t, withConfig(cfg))
+	assert.Contains(t, v.renderPreview(), "No CSV file to preview")
+
+	next, _ := v.Update(msgs.FileAddedMsg{FilePath: path, Title: "users.failed.csv"})
+	v = next.(SettingsView)
+	assert.Contains(t, v.renderPreview(), "GET https://api.example/7")
+}
+
+// TestSettingsView_ProfilesReloaded_FollowsDiskChanges proves the form
+// reloads a profile edited on disk, and that with unsaved edits it keeps
+// them and warns until they are saved.
+func TestSettingsView_ProfilesReloaded_FollowsDiskChanges(t *testing.T) {
+	v, configMgr, _ := newTestSettingsView(t,
+		withConfig(&config.Config{Request: config.RequestConfig{URLTemplate: "https://v1.example"}}),
+		withProfiles([]string{"default", "production"}),
+		withActiveProfile("default"))
+	configMgr.EXPECT().GetProfile("default").Return(&config.Config{
+		Request: config.RequestConfig{URLTemplate: "https://v2.example"},
+	}).AnyTimes()
+
+	next, _ := v.Update(msgs.ProfilesReloadedMsg{Changed: []string{"default"}, ActiveChanged: true})
+	v = next.(SettingsView)
+	assert.Equal(t, "https://v2.example", v.urlInput.Value())
+	assert.Len(t, v.profileList.Items(), 2)
+	assert.False(t, v.diskConflict)
+
+	v.urlInput.SetValue("https://edited.example")
+	v.modified = true
+	next, _ = v.Update(msgs.ProfilesReloadedMsg{Changed: []string{"default"}, ActiveChanged: true})
+	v = next.(SettingsView)
+	assert.Equal(t, "https://edited.example", v.urlInput.Value(), "unsaved edits must be kept")
+	assert.True(t, v.diskConflict)
+
+	configMgr.EXPECT().Update(gomock.Any()).Return(nil).Times(1)
+	configMgr.EXPECT().Save().Return(nil).Times(1)
+	next, _ = v.Update(settingsKeyMsg(kbind.Save.Keys()[0]))
+	v = next.(SettingsView)
+	assert.False(t, v.diskConflict)
+	assert.False(t, v.modified)
+}
+
+// TestSettingsView_ProfileDialog_CreatesProfile proves n opens a name
+// prompt in the sidebar that takes letters of global keys as text, and
+// that Enter creates the profile and highlights it.
+func TestSettingsView_ProfileDialog_CreatesProfile(t *testing.T) {
+	v, _, _ := newTestSettingsView(t, withConfig(&config.Config{}))
+	v.focusPane = paneList
+
+	next, _ := v.Update(settingsKeyMsg("n"))
+	v = next.(SettingsView)
+	require.True(t, v.CapturesInput())
+
+	for _, r := range "qa" {
+		next, _ = v.Update(settingsKeyMsg(string(r)))
+		v = next.(SettingsView)
+	}
+	assert.Equal(t, "qa", v.dialog.name.Value())
+
+	// The profile list changes: swap in a manager that lists it.
+	configMgr := mock_ui.NewMockConfigManager(gomock.NewController(t))
+	v.configMgr = configMgr
+	configMgr.EXPECT().CreateProfile("qa").Return(nil)
+	configMgr.EXPECT().ListProfiles().Return([]string{"default", "qa"})
+	configMgr.EXPECT().ProfileError(gomock.Any()).Return(nil).AnyTimes()
+	configMgr.EXPECT().GetActiveProfile().Return("default").AnyTimes()
+	configMgr.EXPECT().GetProfile("qa").Return(&config.Config{})
+	next, cmd := v.Update(settingsKeyMsg("enter"))
+	v = next.(SettingsView)
+
+	assert.False(t, v.CapturesInput())
+	assert.Equal(t, msgs.ProfileEditedMsg{Text: "Created profile: qa"}, cmd())
+	opt, ok := v.profileList.SelectedItem().(Option[string])
+	require.True(t, ok)
+	assert.Equal(t, "qa", opt.Value)
+}
+
+// TestSettingsView_ProfileDialog_KeepsFailedName proves a rejected name
+// stays in the prompt so it can be corrected.
+func TestSettingsView_ProfileDialog_KeepsFailedName(t *testing.T) {
+	v, configMgr, _ := newTestSettingsView(t, withConfig(&config.Config{}))
+	v.focusPane = paneList
+
+	next, _ := v.Update(settingsKeyMsg("r"))
+	v = next.(SettingsView)
+	assert.Equal(t, "default", v.dialog.name.Value(), "rename starts from the current name")
+
+	renameErr := errors.New("profile staging already exists")
+	configMgr.EXPECT().RenameProfile("default", "default").Return(renameErr)
+	next, cmd := v.Update(settingsKeyMsg("enter"))
+	v = next.(SettingsView)
+
+	assert.True(t, v.CapturesInput())
+	assert.Equal(t, msgs.ProfileEditErrorMsg{Err: renameErr}, cmd())
+
+	next, _ = v.Update(settingsKeyMsg("esc"))
+	assert.False(t, next.(SettingsView).CapturesInput())
+}
+
+// TestSettingsView_ProfileDialog_DeleteAsksForConfirmation proves x
+// only deletes the highlighted profile once confirmed with y.
+func TestSettingsView_ProfileDialog_DeleteAsksForConfirmation(t *testing.T) {
+	v, configMgr, _ := newTestSettingsView(t,
+		withConfig(&config.Config{}),
+		withProfiles([]string{"default", "production"}))
+	v.focusPane = paneList
+
+	configMgr.EXPECT().DeleteProfile(gomock.Any()).Times(0)
+	next, _ := v.Update(settingsKeyMsg("x"))
+	next, _ = next.(SettingsView).Update(settingsKeyMsg("n"))
+	v = next.(SettingsView)
+	require.False(t, v.CapturesInput(), "n must cancel the deletion")
+
+	configMgr = mock_ui.NewMockConfigManager(gomock.NewController(t))
+	v.configMgr = configMgr
+	configMgr.EXPECT().DeleteProfile("default").Return(nil)
+	configMgr.EXPECT().GetActiveProfile().Return("production").AnyTimes()
+	configMgr.EXPECT().ListProfiles().Return([]string{"production"})
+	configMgr.EXPECT().ProfileError(gomock.Any()).Return(nil).AnyTimes()
+	configMgr.EXPECT().GetProfile("production").Return(&config.Config{})
+
+	next, _ = v.Update(settingsKeyMsg("x"))
+	v = next.(SettingsView)
+	assert.Contains(t, v.dialog.prompt(), "Delete default?")
+	next, cmd := v.Update(settingsKeyMsg("y"))
+	v = next.(SettingsView)
+
+	assert.Equal(t, msgs.ProfileEditedMsg{Text: "Deleted profile: default"}, cmd())
+	assert.Len(t, v.profileList.Items(), 1)
+}
+
+// TestSettingsView_BrokenProfile_ShowsBadgeAndProblems proves a profile
+// that failed to load is marked in the sidebar and, once highlighted,
+// its problems are listed with their line and column.
+func TestSettingsView_BrokenProfile_ShowsBadgeAndProblems(t *testing.T) {
+	problems := &config.ValidationError{File: "/profiles/broken.yml", Problems: []config.Problem{
+		{File: "/profiles/broken.yml", Line: 3, Column: 3, Message: `unknown key "metod" in request (did you mean "method"?)`},
+	}}
+	v, configMgr, _ := newTestSettingsView(t,
+		withConfig(&config.Config{}),
+		withProfiles([]string{"default", "broken"}),
+		withProfileErrors(map[string]error{"broken": problems}))
+	configMgr.EXPECT().GetProfile("broken").Return(nil)
+
+	var row strings.Builder
+	newProfileItemDelegate(configMgr, []string{"default", "broken"}).Render(&row, v.profileList, 1, v.profileList.Items()[1])
+	assert.Equal(t, "  broken ✗", row.String())
+	assert.Nil(t, v.profileErr)
+
+	next, _ := v.Update(settingsKeyMsg(kbind.Down.Keys()[0]))
+	v = next.(SettingsView)
+	require.Equal(t, problems, v.profileErr)
+	assert.Contains(t, v.renderProblems(), `broken.yml:3:3: unknown key "metod" in request (did you mean "method"?)`)
+}
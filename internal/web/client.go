@@ -5,18 +5,21 @@ import (
 	"io"
 	"maps"
 	"net/http"
+	"time"
 )
 
 // Response represents an HTTP response. Method is captured by the
 // client alongside URL so downstream consumers (logs.NewHTTPMessage)
 // can render "METHOD URL status" without re-deriving the verb from
-// the gateway config.
+// the gateway config. Latency is how long the request took, from
+// sending it to reading the whole body, or to failing to connect.
 type Response struct {
 	Headers    http.Header
 	Method     string
 	URL        string
 	Body       []byte
 	StatusCode int
+	Latency    time.Duration
 }
 
 // httpClientImpl handles raw HTTP operations.
@@ -77,10 +80,11 @@ func request(ctx context.Context, method string, url string, headers map[string]
 	}
 	addHeaders(headers, req)
 	client := &http.Client{}
+	start := time.Now()
 	res, err := client.Do(req)
 
 	if err != nil {
-		return Response{Method: method, URL: url}, err
+		return Response{Method: method, URL: url, Latency: time.Since(start)}, err
 	}
 	defer res.Body.Close()
 	resBody, _ := io.ReadAll(res.Body)
@@ -91,5 +95,6 @@ func request(ctx context.Context, method string, url string, headers map[string]
 		StatusCode: res.StatusCode,
 		Headers:    res.Header,
 		Body:       resBody,
+		Latency:    time.Since(start),
 	}, nil
 }
@@ -0,0 +1,308 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// templateFuncs are the functions available to URL, body and header
+// templates, on top of the text/template builtins.
+var templateFuncs = template.FuncMap{
+	// Escaping
+	"json":        toJSON,
+	"jsonEscape":  jsonEscape,
+	"quote":       strconv.Quote,
+	"raw":         func(v any) any { return v },
+	"queryEscape": url.QueryEscape,
+	"pathEscape":  url.PathEscape,
+
+	// Encoding and hashing
+	"base64":       func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"base64Decode": base64Decode,
+	"sha256":       func(s string) string { sum := sha256.Sum256([]byte(s)); return hex.EncodeToString(sum[:]) },
+	"hmacSHA256":   hmacSHA256,
+
+	// Strings
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"trim":     strings.TrimSpace,
+	"replace":  func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"default":  defaultValue,
+	"coalesce": coalesce,
+
+	// Time and identifiers
+	"now":  time.Now,
+	"date": formatDate,
+	"uuid": newUUID,
+
+	// Numbers
+	"int":   parseInt,
+	"float": parseFloat,
+	"add":   func(a, b any) (any, error) { return arith(a, b, "add") },
+	"sub":   func(a, b any) (any, error) { return arith(a, b, "sub") },
+	"mul":   func(a, b any) (any, error) { return arith(a, b, "mul") },
+	"div":   func(a, b any) (any, error) { return arith(a, b, "div") },
+	"mod":   func(a, b any) (any, error) { return arith(a, b, "mod") },
+}
+
+// literalFuncs produce a complete JSON value, so the output of an
+// action ending in one of them is not escaped again in a JSON body.
+var literalFuncs = map[string]bool{"json": true, "jsonEscape": true, "quote": true, "raw": true}
+
+// escapeJSONActions makes every action of a JSON body template escape
+// its output as the content of a JSON string, so a CSV cell with quotes,
+// backslashes or newlines can't break the document. Actions that end in
+// json, jsonEscape, quote or raw are left as they are.
+func escapeJSONActions(tmpl *template.Template) {
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			escapeJSONList(t.Tree, t.Tree.Root)
+		}
+	}
+}
+
+func escapeJSONList(tree *parse.Tree, list *parse.ListNode) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.ActionNode:
+			escapeJSONPipe(tree, n.Pipe)
+		case *parse.IfNode:
+			escapeJSONList(tree, n.List)
+			escapeJSONList(tree, n.ElseList)
+		case *parse.RangeNode:
+			escapeJSONList(tree, n.List)
+			escapeJSONList(tree, n.ElseList)
+		case *parse.WithNode:
+			escapeJSONList(tree, n.List)
+			escapeJSONList(tree, n.ElseList)
+		}
+	}
+}
+
+func escapeJSONPipe(tree *parse.Tree, pipe *parse.PipeNode) {
+	// Assignments like {{$id := .id}} print nothing.
+	if len(pipe.Decl) > 0 || len(pipe.Cmds) == 0 {
+		return
+	}
+	last := pipe.Cmds[len(pipe.Cmds)-1]
+	if ident, ok := last.Args[0].(*parse.IdentifierNode); ok && literalFuncs[ident.Ident] {
+		return
+	}
+	escape := parse.NewIdentifier("jsonEscape").SetTree(tree).SetPos(pipe.Pos)
+	pipe.Cmds = append(pipe.Cmds, &parse.CommandNode{
+		NodeType: parse.NodeCommand,
+		Pos:      pipe.Pos,
+		Args:     []parse.Node{escape},
+	})
+}
+
+// looksLikeJSON reports whether a body template is a JSON document,
+// which is when its values are escaped by default.
+func looksLikeJSON(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, "{") || strings.HasPrefix(text, "[")
+}
+
+// toJSON encodes v as JSON. Unlike json.Marshal it leaves <, > and &
+// alone, so bodies read the same in previews and server logs.
+func toJSON(v any) (string, error) {
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// jsonEscape returns v as the content of a JSON string, without the
+// surrounding quotes. A missing variable stays NoValue so it is still
+// reported by Request.MissingValues.
+func jsonEscape(v any) string {
+	if v == nil {
+		return NoValue
+	}
+	s, ok := v.(string)
+	if !ok {
+		s = fmt.Sprint(v)
+	}
+	quoted, _ := toJSON(s)
+	return quoted[1 : len(quoted)-1]
+}
+
+func base64Decode(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	return string(b), err
+}
+
+// hmacSHA256 returns the hex encoded HMAC-SHA256 of message with key.
+func hmacSHA256(key, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// defaultValue returns v, or def when v is missing or empty. Its
+// arguments follow the pipeline order: {{.name | default "unknown"}}.
+func defaultValue(def, v any) any {
+	if isEmpty(v) {
+		return def
+	}
+	return v
+}
+
+// coalesce returns the first of values that isn't missing or empty.
+func coalesce(values ...any) any {
+	for _, v := range values {
+		if !isEmpty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func isEmpty(v any) bool {
+	if v == nil {
+		return true
+	}
+	return reflect.ValueOf(v).IsZero()
+}
+
+// formatDate formats t with a Go time layout. t is a time.Time, as
+// returned by now, or an RFC 3339 string.
+func formatDate(layout string, t any) (string, error) {
+	switch v := t.(type) {
+	case time.Time:
+		return v.Format(layout), nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return "", err
+		}
+		return parsed.Format(layout), nil
+	default:
+		return "", fmt.Errorf("date: unsupported value %v", t)
+	}
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+func parseInt(v any) (int64, error) {
+	n, err := toNumber(v)
+	if err != nil {
+		return 0, err
+	}
+	if n.isInt {
+		return n.i, nil
+	}
+	return int64(n.f), nil
+}
+
+func parseFloat(v any) (float64, error) {
+	n, err := toNumber(v)
+	return n.f, err
+}
+
+// number is a template argument converted for arithmetic. Integers stay
+// integers so {{add .count 1}} doesn't render as a float.
+type number struct {
+	i     int64
+	f     float64
+	isInt bool
+}
+
+func toNumber(v any) (number, error) {
+	switch x := v.(type) {
+	case int:
+		return number{i: int64(x), f: float64(x), isInt: true}, nil
+	case int64:
+		return number{i: x, f: float64(x), isInt: true}, nil
+	case float64:
+		return number{f: x}, nil
+	case string:
+		s := strings.TrimSpace(x)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return number{i: i, f: float64(i), isInt: true}, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return number{}, fmt.Errorf("%q is not a number", x)
+		}
+		return number{f: f}, nil
+	default:
+		return number{}, fmt.Errorf("%v is not a number", v)
+	}
+}
+
+// arith applies op to a and b: integer arithmetic when both are
+// integers, floating point otherwise.
+func arith(a, b any, op string) (any, error) {
+	x, err := toNumber(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := toNumber(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if x.isInt && y.isInt {
+		switch op {
+		case "add":
+			return x.i + y.i, nil
+		case "sub":
+			return x.i - y.i, nil
+		case "mul":
+			return x.i * y.i, nil
+		}
+		if y.i == 0 {
+			return nil, errors.New(op + ": division by zero")
+		}
+		if op == "mod" {
+			return x.i % y.i, nil
+		}
+		return x.i / y.i, nil
+	}
+
+	switch op {
+	case "add":
+		return x.f + y.f, nil
+	case "sub":
+		return x.f - y.f, nil
+	case "mul":
+		return x.f * y.f, nil
+	}
+	if y.f == 0 {
+		return nil, errors.New(op + ": division by zero")
+	}
+	if op == "mod" {
+		return math.Mod(x.f, y.f), nil
+	}
+	return x.f / y.f, nil
+}
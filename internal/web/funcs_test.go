@@ -0,0 +1,122 @@
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func renderText(t *testing.T, text string, vars map[string]string) string {
+	t.Helper()
+	tmpl, err := NewTemplate("test", text)
+	require.NoError(t, err)
+	return RenderTemplate(tmpl, vars).String()
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	vars := map[string]string{
+		"name":  `Ana "Bia" Silva`,
+		"email": " Ana@Example.COM ",
+		"count": "41",
+		"price": "2.5",
+		"empty": "",
+	}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`{{json .name}}`, `"Ana \"Bia\" Silva"`},
+		{`{{jsonEscape .name}}`, `Ana \"Bia\" Silva`},
+		{`{{json "a & <b>"}}`, `"a & <b>"`},
+		{`{{quote .count}}`, `"41"`},
+		{`{{queryEscape .name}}`, `Ana+%22Bia%22+Silva`},
+		{`{{pathEscape "a b/c"}}`, `a%20b%2Fc`},
+		{`{{base64 "user:pass"}}`, `dXNlcjpwYXNz`},
+		{`{{base64Decode "dXNlcjpwYXNz"}}`, `user:pass`},
+		{`{{sha256 "abc"}}`, `ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad`},
+		{`{{hmacSHA256 "key" "The quick brown fox jumps over the lazy dog"}}`, `f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8`},
+		{`{{.email | trim | lower}}`, `ana@example.com`},
+		{`{{upper "abc"}}`, `ABC`},
+		{`{{replace "-" "" "1-2-3"}}`, `123`},
+		{`{{.empty | default "none"}}`, `none`},
+		{`{{.missing | default "none"}}`, `none`},
+		{`{{.count | default "none"}}`, `41`},
+		{`{{coalesce .missing .empty .count}}`, `41`},
+		{`{{add .count 1}}`, `42`},
+		{`{{sub 10 .count}}`, `-31`},
+		{`{{mul .price 2}}`, `5`},
+		{`{{div 7 2}}`, `3`},
+		{`{{div 7.0 2}}`, `3.5`},
+		{`{{mod .count 10}}`, `1`},
+		{`{{int "12"}}`, `12`},
+		{`{{float .price}}`, `2.5`},
+		{`{{date "2006-01-02" "2024-03-05T10:00:00Z"}}`, `2024-03-05`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, renderText(t, tt.text, vars))
+		})
+	}
+
+	t.Run("now and uuid", func(t *testing.T) {
+		assert.Equal(t, time.Now().Format("2006"), renderText(t, `{{now | date "2006"}}`, nil))
+		assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, renderText(t, `{{uuid}}`, nil))
+	})
+
+	t.Run("arithmetic errors stop the template", func(t *testing.T) {
+		tmpl, err := NewTemplate("test", `{{div 1 0}}`)
+		require.NoError(t, err)
+		assert.ErrorContains(t, tmpl.Execute(io.Discard, nil), "division by zero")
+	})
+}
+
+func TestNewBodyTemplate(t *testing.T) {
+	vars := map[string]string{"name": "Ana \"Bia\"\nSilva", "age": "42", "tags": `["a","b"]`}
+
+	t.Run("should escape values in a JSON body", func(t *testing.T) {
+		tmpl, err := NewBodyTemplate("body", `{"name": "{{.name}}", "upper": "{{upper .name}}", "age": {{.age}}}`)
+		require.NoError(t, err)
+
+		body := RenderTemplate(tmpl, vars).Bytes()
+		var got map[string]any
+		require.NoError(t, json.Unmarshal(body, &got), string(body))
+		assert.Equal(t, map[string]any{"name": "Ana \"Bia\"\nSilva", "upper": "ANA \"BIA\"\nSILVA", "age": float64(42)}, got)
+	})
+
+	t.Run("should leave json and raw values as they are", func(t *testing.T) {
+		tmpl, err := NewBodyTemplate("body", `{"name": {{json .name}}, "tags": {{raw .tags}}{{if .age}}, "adult": true{{end}}}`)
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"name": "Ana \"Bia\"\nSilva", "tags": ["a","b"], "adult": true}`, RenderTemplate(tmpl, vars).String())
+	})
+
+	t.Run("should leave quoted values as they are", func(t *testing.T) {
+		tmpl, err := NewBodyTemplate("body", `{"name": {{.name | quote}}, "age": {{quote .age}}}`)
+		require.NoError(t, err)
+
+		body := RenderTemplate(tmpl, vars).Bytes()
+		var got map[string]any
+		require.NoError(t, json.Unmarshal(body, &got), string(body))
+		assert.Equal(t, map[string]any{"name": "Ana \"Bia\"\nSilva", "age": "42"}, got)
+	})
+
+	t.Run("should keep missing values detectable", func(t *testing.T) {
+		gateway, err := NewHttpGateway(http.MethodPost, "https://api.example", `{"name": "{{.nickname}}"}`, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"body"}, gateway.Render(vars).MissingValues())
+	})
+
+	t.Run("should not escape bodies that aren't JSON", func(t *testing.T) {
+		tmpl, err := NewBodyTemplate("body", `name={{.name}}`)
+		require.NoError(t, err)
+
+		assert.Equal(t, "name=Ana \"Bia\"\nSilva", RenderTemplate(tmpl, vars).String())
+	})
+}
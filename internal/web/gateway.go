@@ -6,7 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"text/template"
 )
@@ -29,7 +32,7 @@ func NewHttpGateway(method, urlTemplate, bodyTemplate string, headers map[string
 		return nil, fmt.Errorf("invalid URL template: %w", err)
 	}
 
-	bodyTmpl, err := NewTemplate("body", bodyTemplate)
+	bodyTmpl, err := NewBodyTemplate("body", bodyTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("invalid body template: %w", err)
 	}
@@ -64,11 +67,7 @@ func NewHttpGatewayLegacy(token, method, urlTemplate, bodyTemplate string) *http
 	return gateway
 }
 
-func (hg *httpGatewayImpl) req(ctx context.Context, url string, body io.Reader, headers map[string]string) (Response, error) {
-	hg.mu.RLock()
-	method := hg.method
-	hg.mu.RUnlock()
-
+func (hg *httpGatewayImpl) req(ctx context.Context, method, url string, body io.Reader, headers map[string]string) (Response, error) {
 	switch method {
 	case http.MethodGet:
 		return hg.client.Get(ctx, url, headers)
@@ -85,17 +84,51 @@ func (hg *httpGatewayImpl) req(ctx context.Context, url string, body io.Reader,
 	}
 }
 
+// Request is a rendered HTTP request: the gateway's templates executed
+// against the variables of a single row.
+type Request struct {
+	Headers map[string]string
+	Method  string
+	URL     string
+	Body    []byte
+}
+
+// NoValue is what text/template renders for a variable missing from
+// the row, e.g. a CSV column the template references but the file (or
+// the csv.fields filter) doesn't provide.
+const NoValue = "<no value>"
+
+// MissingValues returns the parts of the request ("url", "body" or
+// "header <name>") that rendered a variable missing from the row.
+func (r Request) MissingValues() []string {
+	var parts []string
+	if strings.Contains(r.URL, NoValue) {
+		parts = append(parts, "url")
+	}
+	if bytes.Contains(r.Body, []byte(NoValue)) {
+		parts = append(parts, "body")
+	}
+	for _, name := range slices.Sorted(maps.Keys(r.Headers)) {
+		if strings.Contains(r.Headers[name], NoValue) {
+			parts = append(parts, "header "+name)
+		}
+	}
+	return parts
+}
+
 // Exec executes the request with the given variables to fill the body and url templates.
 // It supports template rendering for both URL, body, and header values.
 func (hg *httpGatewayImpl) Exec(ctx context.Context, variables map[string]string) (Response, error) {
-	hg.mu.RLock()
-	defer hg.mu.RUnlock()
+	r := hg.Render(variables)
 
-	// Render URL template
-	uri := RenderTemplate(hg.urlTemplate, variables).String()
+	return hg.req(ctx, r.Method, r.URL, bytes.NewReader(r.Body), r.Headers)
+}
 
-	// Render body template
-	body := RenderTemplate(hg.bodyTemplate, variables)
+// Render executes the URL, body and header templates against variables
+// without sending anything. Exec sends exactly what Render returns.
+func (hg *httpGatewayImpl) Render(variables map[string]string) Request {
+	hg.mu.RLock()
+	defer hg.mu.RUnlock()
 
 	// Render headers (supports templates in header values)
 	headers := make(map[string]string)
@@ -110,7 +143,45 @@ func (hg *httpGatewayImpl) Exec(ctx context.Context, variables map[string]string
 		}
 	}
 
-	return hg.req(ctx, uri, body, headers)
+	return Request{
+		Method:  hg.method,
+		URL:     RenderTemplate(hg.urlTemplate, variables).String(),
+		Body:    RenderTemplate(hg.bodyTemplate, variables).Bytes(),
+		Headers: headers,
+	}
+}
+
+// RenderRequest renders the request a gateway configured with method,
+// templates and headers would send for variables. Unlike Render, which
+// falls back to empty or literal output, every template that fails to
+// parse or execute is reported; the parts that rendered are returned
+// either way.
+func RenderRequest(method, urlTemplate, bodyTemplate string, headers, variables map[string]string) (Request, error) {
+	var errs []error
+	render := func(name, text string, parse func(name, text string) (*template.Template, error)) string {
+		tmpl, err := parse(name, text)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s template: %w", name, err))
+			return ""
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, variables); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s template: %w", name, err))
+		}
+		return buf.String()
+	}
+
+	r := Request{
+		Method:  method,
+		URL:     render("URL", urlTemplate, NewTemplate),
+		Body:    []byte(render("body", bodyTemplate, NewBodyTemplate)),
+		Headers: make(map[string]string, len(headers)),
+	}
+	for _, name := range slices.Sorted(maps.Keys(headers)) {
+		r.Headers[name] = render("header "+name, headers[name], NewTemplate)
+	}
+
+	return r, errors.Join(errs...)
 }
 
 // UpdateConfig updates the gateway configuration and templates at runtime (hot-reload).
@@ -124,7 +195,7 @@ func (hg *httpGatewayImpl) UpdateConfig(method, urlTemplate, bodyTemplate string
 		return fmt.Errorf("invalid URL template: %w", err)
 	}
 
-	bodyTmpl, err := NewTemplate("body", bodyTemplate)
+	bodyTmpl, err := NewBodyTemplate("body", bodyTemplate)
 	if err != nil {
 		return fmt.Errorf("invalid body template: %w", err)
 	}
@@ -138,9 +209,24 @@ func (hg *httpGatewayImpl) UpdateConfig(method, urlTemplate, bodyTemplate string
 	return nil
 }
 
-// NewTemplate creates a new template from a string
+// NewTemplate creates a new template from a string, with the request
+// template functions available.
 func NewTemplate(name string, templ string) (*template.Template, error) {
-	return template.New(name).Parse(templ)
+	return template.New(name).Funcs(templateFuncs).Parse(templ)
+}
+
+// NewBodyTemplate creates a body template. When the body is a JSON
+// document every value is escaped as JSON string content, unless its
+// action ends in json, jsonEscape, quote or raw.
+func NewBodyTemplate(name string, templ string) (*template.Template, error) {
+	tmpl, err := NewTemplate(name, templ)
+	if err != nil {
+		return nil, err
+	}
+	if looksLikeJSON(templ) {
+		escapeJSONActions(tmpl)
+	}
+	return tmpl, nil
 }
 
 // RenderTemplate renders a template with the given variables
@@ -154,7 +240,7 @@ func RenderTemplate[T map[string]string](t *template.Template, variables T) *byt
 
 // renderString renders a string template with variables
 func renderString(templateStr string, variables map[string]string) (string, error) {
-	tmpl, err := template.New("").Parse(templateStr)
+	tmpl, err := NewTemplate("header", templateStr)
 	if err != nil {
 		return "", err
 	}
@@ -67,3 +67,62 @@ func TestExec(t *testing.T) {
 		assert.Zero(t, res)
 	})
 }
+
+func TestRender(t *testing.T) {
+	t.Run("should render the request without sending it", func(t *testing.T) {
+		gateway, err := NewHttpGateway(http.MethodPost, "https://api.example/{{.id}}", `{ "key": "{{.value}}" }`, map[string]string{"Authorization": "Bearer {{.token}}"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		req := gateway.Render(map[string]string{"id": "1", "value": "v", "token": "t"})
+		assert.Equal(t, Request{
+			Method:  http.MethodPost,
+			URL:     "https://api.example/1",
+			Body:    []byte(`{ "key": "v" }`),
+			Headers: map[string]string{"Authorization": "Bearer t"},
+		}, req)
+	})
+}
+
+func TestRequest_MissingValues(t *testing.T) {
+	t.Run("should name every part that rendered a missing variable", func(t *testing.T) {
+		gateway, err := NewHttpGateway(http.MethodPost, "https://api.example/{{.id}}", `{ "key": "{{.value}}" }`, map[string]string{
+			"Authorization": "Bearer {{.token}}",
+			"Content-Type":  "application/json",
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		req := gateway.Render(map[string]string{"id": "1"})
+		assert.Equal(t, []string{"body", "header Authorization"}, req.MissingValues())
+	})
+
+	t.Run("should return nothing when every variable is present", func(t *testing.T) {
+		req := Request{URL: "https://api.example/1", Body: []byte("{}"), Headers: map[string]string{"A": "b"}}
+		assert.Empty(t, req.MissingValues())
+	})
+}
+
+func TestRenderRequest(t *testing.T) {
+	t.Run("should render the request the templates describe", func(t *testing.T) {
+		req, err := RenderRequest(http.MethodPut, "https://api.example/{{.id}}", `{"key": "{{.value}}"}`, map[string]string{"Authorization": "Bearer {{.token}}"},
+			map[string]string{"id": "1", "value": "v", "token": "t"})
+		assert.NoError(t, err)
+		assert.Equal(t, Request{
+			Method:  http.MethodPut,
+			URL:     "https://api.example/1",
+			Body:    []byte(`{"key": "v"}`),
+			Headers: map[string]string{"Authorization": "Bearer t"},
+		}, req)
+	})
+
+	t.Run("should report every template that does not parse", func(t *testing.T) {
+		req, err := RenderRequest(http.MethodPost, "https://api.example/{{.id}}", `{"key": "{{.value"}`, map[string]string{"X-Token": "{{if}}"},
+			map[string]string{"id": "1"})
+		assert.ErrorContains(t, err, "invalid body template")
+		assert.ErrorContains(t, err, "invalid header X-Token template")
+		assert.Equal(t, "https://api.example/1", req.URL)
+	})
+}
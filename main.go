@@ -1,20 +1,26 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/anibaldeboni/rapper/internal/cli"
 	"github.com/anibaldeboni/rapper/internal/config"
 	"github.com/anibaldeboni/rapper/internal/logs"
 	"github.com/anibaldeboni/rapper/internal/processor"
 	"github.com/anibaldeboni/rapper/internal/styles"
 	"github.com/anibaldeboni/rapper/internal/ui"
 	"github.com/anibaldeboni/rapper/internal/ui/logo"
+	"github.com/anibaldeboni/rapper/internal/ui/msgs"
 	"github.com/anibaldeboni/rapper/internal/updates"
 	"github.com/anibaldeboni/rapper/internal/utils"
 	"github.com/anibaldeboni/rapper/internal/web"
@@ -25,6 +31,7 @@ var (
 	workingDir *string
 	outputFile *string
 	workers    *int
+	backups    *int
 	updateMsg  = make(chan string)
 )
 
@@ -32,16 +39,26 @@ func init() {
 	go updateCheck(updateMsg)
 	cwd, _ := os.Getwd()
 	configPath = flag.String("config", cwd, "path to directory containing a config file")
-	workingDir = flag.String("dir", cwd, "path to directory containing the CSV files")
+	workingDir = flag.String("dir", cwd, "path to directory containing the CSV and JSON Lines files")
 	outputFile = flag.String("output", "", "path to output file, including the file name")
 	workers = flag.Int("workers", 1, fmt.Sprintf("number of request workers (max: %d)", processor.MaxWorkers))
+	backups = flag.Int("backups", config.DefaultBackups, "number of timestamped backups kept of each saved profile (0 disables them)")
 	flag.Usage = usage
 	flag.Parse()
 }
 
 func main() {
+	switch flag.Arg(0) {
+	case "run":
+		os.Exit(runHeadless(flag.Args()[1:]))
+	case "validate":
+		os.Exit(runValidate(flag.Args()[1:]))
+	case "migrate":
+		os.Exit(runMigrate(flag.Args()[1:]))
+	}
+
 	// Create config manager (supports multi-profile)
-	configMgr, err := config.NewManager(*configPath)
+	configMgr, err := config.NewManager(*configPath, config.WithBackups(*backups))
 	if err != nil {
 		handleExit(fmt.Errorf("could not read config file: %w", err))
 	}
@@ -54,6 +71,7 @@ func main() {
 	}
 
 	logger := logs.NewLogger(*outputFile)
+	logger.SetSecrets(cfg.Secrets())
 
 	// Create HTTP gateway with flexible headers
 	hg, err := web.NewHttpGateway(
@@ -78,9 +96,14 @@ func main() {
 		logger,
 		workerCount,
 	)
+	if err := csvProcessor.UpdateRequestConfig(cfg.Request); err != nil {
+		handleExit(fmt.Errorf("could not create HTTP gateway: %w", err))
+	}
+	csvProcessor.UpdateRateLimit(cfg.RateLimit)
 
 	// Register config change listener to update gateway and processor
 	configMgr.OnChange(func(newCfg *config.Config) {
+		logger.SetSecrets(newCfg.Secrets())
 		_ = hg.UpdateConfig(
 			newCfg.Request.Method,
 			newCfg.Request.URLTemplate,
@@ -88,17 +111,19 @@ func main() {
 			newCfg.Request.Headers,
 		)
 		csvProcessor.UpdateConfig(newCfg.CSV)
+		_ = csvProcessor.UpdateRequestConfig(newCfg.Request)
+		csvProcessor.UpdateRateLimit(newCfg.RateLimit)
 		if newCfg.Workers > 0 {
 			csvProcessor.SetWorkers(newCfg.Workers)
 		}
 	})
 
-	filePaths, err := utils.FindFiles(*workingDir, "*.csv")
+	filePaths, err := utils.FindFiles(*workingDir, processor.InputPatterns...)
 	if err != nil {
 		handleExit(fmt.Errorf("could not execute file scan in %s: %w", styles.Bold(*workingDir), err))
 	}
 	if len(filePaths) == 0 {
-		handleExit(fmt.Errorf("no CSV files found in %s", styles.Bold(*workingDir)))
+		handleExit(fmt.Errorf("no CSV or JSON Lines files found in %s", styles.Bold(*workingDir)))
 	}
 
 	// Use new AppModel with multi-view support
@@ -112,17 +137,96 @@ func main() {
 		_ = ttyOut.Close()
 	}()
 
-	if _, err := tea.NewProgram(
+	program := tea.NewProgram(
 		tui,
 		tea.WithInput(ttyIn),
 		tea.WithOutput(ttyOut),
-	).Run(); err != nil {
+	)
+
+	// Hot-reload profiles edited outside the app
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go configMgr.Watch(watchCtx, time.Second, func(r config.ReloadResult, err error) {
+		program.Send(msgs.ProfilesReloadedMsg{
+			Added:         r.Added,
+			Removed:       r.Removed,
+			Changed:       r.Changed,
+			ActiveChanged: r.ActiveChanged,
+			Errors:        r.Errors,
+			Err:           err,
+		})
+	})
+
+	if _, err := program.Run(); err != nil {
 		handleExit(fmt.Errorf("could not run the program: %w", err))
 	}
 
 	handleExit()
 }
 
+// runHeadless executes the `run` subcommand: it processes a single CSV
+// or JSON Lines file, or standard input, without the TUI and returns
+// the process exit code. Ctrl+C and SIGTERM cancel the run the same way
+// Ctrl+C does in the TUI.
+func runHeadless(args []string) int {
+	defaults := cli.RunOptions{
+		ConfigDir: *configPath,
+		Output:    *outputFile,
+	}
+	// Unlike the TUI, a run uses the profile's workers unless -workers
+	// is given, so the global flag only carries over when it was set.
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "workers" {
+			defaults.Workers = *workers
+		}
+	})
+	opts, err := cli.ParseRunFlags(args, defaults, os.Stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return cli.Run(ctx, opts, os.Stdin, os.Stdout, os.Stderr)
+}
+
+// runValidate executes the `validate` subcommand: it checks the profiles
+// and the given CSV files and returns the process exit code, non-zero
+// when anything is wrong.
+func runValidate(args []string) int {
+	opts, err := cli.ParseValidateFlags(args, cli.ValidateOptions{ConfigDir: *configPath}, os.Stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	return cli.Validate(opts, os.Stdout)
+}
+
+// runMigrate executes the `migrate` subcommand: it converts the legacy
+// profiles to the current format and returns the process exit code,
+// non-zero when a profile could not be converted.
+func runMigrate(args []string) int {
+	opts, err := cli.ParseMigrateFlags(args, cli.MigrateOptions{ConfigDir: *configPath}, os.Stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	return cli.Migrate(opts, os.Stdout, os.Stderr)
+}
+
 func usage() {
 	fmt.Printf("%s (%s)\n", styles.Bold(ui.AppName), ui.AppVersion)
 	fmt.Println("\nA CLI tool to send HTTP requests based on CSV files.")
@@ -130,6 +234,9 @@ func usage() {
 	fmt.Printf("If %s file is not provided, the request responses will not be saved.\n", styles.Bold("-output"))
 	fmt.Println("\nUsage:")
 	fmt.Printf("  %s [options]\n", styles.Bold(filepath.Base(os.Args[0])))
+	fmt.Printf("  %s [options] run [-file <csv|jsonl|->] [-profile <name>] [-max-errors <n>] [-resume] [-dry-run]\n", styles.Bold(filepath.Base(os.Args[0])))
+	fmt.Printf("  %s [options] validate [-profile <name>] [-format text|json] [<csv|jsonl>...]\n", styles.Bold(filepath.Base(os.Args[0])))
+	fmt.Printf("  %s [options] migrate [-dry-run] [<profile>...]\n", styles.Bold(filepath.Base(os.Args[0])))
 	fmt.Println("\nOptions:")
 	flag.PrintDefaults()
 	fmt.Println("\n", <-updateMsg)